@@ -0,0 +1,84 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/go-kit/kit/log"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/kubernetes"
+)
+
+type activatorCmd struct {
+	kubeConfigPath string
+	namespace      string
+	listenAddress  string
+	timeout        time.Duration
+}
+
+func newActivatorCmd() *cobra.Command {
+	p := &activatorCmd{}
+	cmd := &cobra.Command{
+		Use:   "activator",
+		Short: "Runs the activator which forwards the requests to functions with an idle timeout and scales them up from zero",
+		Long: fmt.Sprintf(`This command runs the HTTP proxy which the services of functions with an idle timeout point at.
+
+It forwards the requests to the pods of a function and records that the function is in use so that
+it is only scaled to zero once it receives no requests. It holds the requests to a function scaled
+to zero while its pods are scaled up again and then forwards them.
+The pods of the activator must be labelled with %s=true and name their container port %s.`, funktion.ActivatorLabel, funktion.ActivatorPortName),
+		Run: func(cmd *cobra.Command, args []string) {
+			handleError(p.run())
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace of the functions to activate. Defaults to $KUBERNETES_NAMESPACE")
+	f.StringVar(&p.listenAddress, "listen-address", ":8080", "the address to serve the activator on")
+	f.DurationVar(&p.timeout, "timeout", funktion.DefaultActivationTimeout, "how long to hold a request while the function is scaled up")
+	return cmd
+}
+
+func (p *activatorCmd) run() error {
+	logger := log.NewContext(log.NewLogfmtLogger(os.Stdout)).
+		With("ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller).
+		With("activator", "funktion")
+
+	cfg, err := createKubernetesClientConfig(p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	kclient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	namespace := p.namespace
+	if len(namespace) == 0 {
+		namespace = os.Getenv("KUBERNETES_NAMESPACE")
+	}
+	if len(namespace) == 0 {
+		return fmt.Errorf("No namespace argument or $KUBERNETES_NAMESPACE environment variable specified")
+	}
+
+	activator := funktion.NewActivator(kclient, logger, namespace, p.timeout)
+	logger.Log("msg", "serving activator", "address", p.listenAddress, "namespace", namespace)
+	return http.ListenAndServe(p.listenAddress, activator)
+}
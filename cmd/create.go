@@ -20,14 +20,20 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/funktionio/funktion/pkg/cron"
 	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/funktionio/funktion/pkg/spec"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
 	"k8s.io/client-go/1.5/pkg/api/v1"
 )
 
@@ -47,9 +53,21 @@ type createFunctionCmd struct {
 	apply         bool
 	functionsOnly bool
 
-	envVars []string
-
-	configMaps map[string]*v1.ConfigMap
+	envVars      []string
+	secretEnv    []string
+	secretMounts []string
+
+	minReplicas       int32
+	maxReplicas       int32
+	targetCPU         int32
+	targetConcurrency int32
+	idleTimeout       time.Duration
+	schedule          string
+	schedulePayload   string
+	pod               podSettingsFlags
+	gateway           gatewayFlags
+
+	functions map[string]*spec.Function
 }
 
 func init() {
@@ -175,59 +193,70 @@ func (p *createFunctionCmd) createFromFile() error {
 
 func (p *createFunctionCmd) setupCommonFlags(f *pflag.FlagSet) {
 	f.StringArrayVarP(&p.envVars, "env", "e", []string{}, "pass one or more environment variables using the form NAME=VALUE")
+	f.StringArrayVar(&p.secretEnv, "secret-env", []string{}, "pass one or more environment variables read from a key of a secret using the form NAME=secretName:key")
+	f.StringArrayVar(&p.secretMounts, "secret-mount", []string{}, "mount one or more secrets as files into the function's pods using the form secretName:/path")
 	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
 	f.StringVar(&p.namespace, "namespace", "", "the namespace to create the resource")
 	f.BoolVarP(&p.watch, "watch", "w", false, "whether to keep watching the files for changes to the function source code")
 	f.BoolVarP(&p.debug, "debug", "d", false, "enable debugging for the function?")
 	f.StringVarP(&p.file, "file", "f", "", "the file name that contains the source code for the function to create")
+	f.Int32Var(&p.minReplicas, "min-replicas", 0, "the minimum number of pods when autoscaling the function")
+	f.Int32Var(&p.maxReplicas, "max-replicas", 0, "the maximum number of pods which enables autoscaling of the function")
+	f.Int32Var(&p.targetCPU, "target-cpu", 0, "the average CPU utilization percentage of the pods to autoscale the function to")
+	f.Int32Var(&p.targetConcurrency, "target-concurrency", 0, "the average number of concurrent requests per pod to autoscale the function to")
+	f.StringVar(&p.schedule, "schedule", "", "a cron expression such as '*/5 * * * *' on which to invoke the function")
+	f.StringVar(&p.schedulePayload, "schedule-payload", "", "the body to POST to the function on each scheduled invocation")
+	f.DurationVar(&p.idleTimeout, "idle-timeout", 0, "how long the function may receive no requests before it is scaled to zero pods. The function is scaled up again on its next request")
+	p.pod.addFlags(f)
+	p.gateway.addFlags(f)
 }
 
 func (p *createFunctionCmd) createFunctionFromCLI() error {
 	p.functionsOnly = true
-	listOpts, err := funktion.CreateFunctionListOptions()
-	if err != nil {
-		return err
-	}
 	file := p.file
 	if len(file) > 0 {
 		return p.createFromFile()
-	} else {
-		kubeclient := p.kubeclient
-		cms := kubeclient.ConfigMaps(p.namespace)
-		resources, err := cms.List(*listOpts)
-		if err != nil {
-			return err
-		}
-		p.configMaps = map[string]*v1.ConfigMap{}
-		for _, resource := range resources.Items {
-			p.configMaps[resource.Name] = &resource
-		}
+	}
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	functions := client.Functions(p.namespace)
+	list, err := functions.List(api.ListOptions{})
+	failed := funktion.ListFailures(err)
+	if err != nil && failed == nil {
+		return err
+	}
+	p.functions = map[string]*spec.Function{}
+	for _, function := range list.Items {
+		p.functions[function.Name] = function
+	}
+	for name := range failed {
+		// a broken Function is replaced rather than created
+		p.functions[name] = &spec.Function{ObjectMeta: v1.ObjectMeta{Name: name}}
+	}
 
-		name := nameFromFile(file, p.name)
-		if len(name) == 0 {
-			name, err = p.generateName()
-			if err != nil {
-				return err
-			}
-		}
-		update := p.configMaps[name] != nil
-		cm, err := p.createFunction(name)
+	name := nameFromFile(file, p.name)
+	if len(name) == 0 {
+		name, err = p.generateName()
 		if err != nil {
 			return err
 		}
-		message := "created"
-		if update {
-			_, err = cms.Update(cm)
-			message = "updated"
-		} else {
-			_, err = cms.Create(cm)
-		}
-		if err == nil {
-			fmt.Printf("Function %s %s\n", name, message)
-		}
 	}
-	if err == nil && p.watch {
-		p.watchFiles()
+	update := p.functions[name] != nil
+	function, err := p.createFunction(name)
+	if err != nil {
+		return err
+	}
+	message := "created"
+	if update {
+		_, err = functions.Update(function)
+		message = "updated"
+	} else {
+		_, err = functions.Create(function)
+	}
+	if err == nil {
+		fmt.Printf("Function %s %s\n", name, message)
 	}
 	return err
 }
@@ -237,6 +266,16 @@ func (p *createFunctionCmd) watchFiles() {
 	if len(files) == 0 {
 		return
 	}
+	watchFiles(files, func(fileName string) {
+		if err := p.applyFile(fileName); err != nil {
+			fmt.Printf("Failed to apply function file %s due to %v\n", fileName, err)
+		}
+	})
+}
+
+// watchFiles calls onChange with the name of each file matching the given pattern whenever it
+// changes until the process is terminated
+func watchFiles(files string, onChange func(fileName string)) {
 	fmt.Println("Watching files: ", files)
 	fmt.Println("Please press Ctrl-C to terminate")
 	watcher, err := fsnotify.NewWatcher()
@@ -276,10 +315,7 @@ func (p *createFunctionCmd) watchFiles() {
 					}
 				}
 			}
-			err = p.applyFile(event.Name)
-			if err != nil {
-				fmt.Printf("Failed to apply function file %s due to %v\n", event.Name, err)
-			}
+			onChange(event.Name)
 
 		case err := <-watcher.Errors:
 			log.Println("error:", err)
@@ -324,27 +360,22 @@ func (p *createFunctionCmd) applyFile(fileName string) error {
 	if len(runtime) == 0 {
 		return nil
 	}
-	listOpts, err := funktion.CreateFunctionListOptions()
-	if err != nil {
-		return err
-	}
 	name := nameFromFile(fileName, "")
 	if len(name) == 0 {
 		return fmt.Errorf("Could not generate a function name!")
 	}
 
-	kubeclient := p.kubeclient
-	cms := kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return err
 	}
-	var old *v1.ConfigMap = nil
-	for _, resource := range resources.Items {
-		if resource.Name == name {
-			old = &resource
-			break
+	functions := client.Functions(p.namespace)
+	old, err := functions.Get(name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
 		}
+		old = nil
 	}
 	defaultLabels := map[string]string{}
 	abs, err := filepath.Abs(fileName)
@@ -354,21 +385,22 @@ func (p *createFunctionCmd) applyFile(fileName string) error {
 			defaultLabels[funktion.ProjectLabel] = folderName
 		}
 	}
-	cm, err := p.createFunctionFromSource(name, source, runtime, defaultLabels)
+	function, err := p.createFunctionFromSource(name, source, runtime, defaultLabels)
 	if err != nil {
 		return err
 	}
 	message := "created"
 	if old != nil {
-		oldSource := old.Data[funktion.SourceProperty]
-		if source == oldSource && cm.Data[funktion.EnvVarsProperty] == old.Data[funktion.EnvVarsProperty] {
+		if source == old.Spec.Source && reflect.DeepEqual(function.Spec.Env, old.Spec.Env) &&
+			reflect.DeepEqual(function.Spec.SecretEnv, old.Spec.SecretEnv) && reflect.DeepEqual(function.Spec.SecretMounts, old.Spec.SecretMounts) &&
+			reflect.DeepEqual(function.Spec.Pod, old.Spec.Pod) && reflect.DeepEqual(function.Spec.Gateway, old.Spec.Gateway) {
 			// source not changed so lets not update!
 			return nil
 		}
-		_, err = cms.Update(cm)
+		_, err = functions.Update(function)
 		message = "updated"
 	} else {
-		_, err = cms.Create(cm)
+		_, err = functions.Create(function)
 	}
 	if err == nil {
 		log.Println("Function", name, message)
@@ -380,28 +412,19 @@ func (p *createFunctionCmd) applyFile(fileName string) error {
 // or an empty string if the file does not map to a runtime function source file
 func (p *createFunctionCmd) findRuntimeFromFileName(fileName string) (string, error) {
 	// TODO we may want to use a cache and watch the runtimes to minimise API churn here on runtimes...
-	listOpts, err := funktion.CreateRuntimeListOptions()
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return "", err
 	}
-	kubeclient := p.kubeclient
-	cms := kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	runtimes, err := client.Runtimes(p.namespace).List(api.ListOptions{})
 	if err != nil {
 		return "", err
 	}
 	ext := strings.TrimPrefix(filepath.Ext(fileName), ".")
-	for _, resource := range resources.Items {
-		data := resource.Data
-		if data != nil {
-			extensions := data[funktion.FileExtensionsProperty]
-			if len(extensions) > 0 {
-				values := strings.Split(extensions, ",")
-				for _, value := range values {
-					if ext == value {
-						return resource.Name, nil
-					}
-				}
+	for _, runtime := range runtimes.Items {
+		for _, value := range runtime.Spec.FileExtensions {
+			if ext == value {
+				return runtime.Name, nil
 			}
 		}
 	}
@@ -432,14 +455,14 @@ func (p *createFunctionCmd) generateName() (string, error) {
 	counter := 1
 	for {
 		name := prefix + strconv.Itoa(counter)
-		if p.configMaps[name] == nil {
+		if p.functions[name] == nil {
 			return name, nil
 		}
 		counter++
 	}
 }
 
-func (p *createFunctionCmd) createFunction(name string) (*v1.ConfigMap, error) {
+func (p *createFunctionCmd) createFunction(name string) (*spec.Function, error) {
 	source := p.source
 	if len(source) == 0 {
 		file := p.file
@@ -464,33 +487,97 @@ func (p *createFunctionCmd) createFunction(name string) (*v1.ConfigMap, error) {
 	return p.createFunctionFromSource(name, source, runtime, defaultLabels)
 }
 
-func (p *createFunctionCmd) createFunctionFromSource(name, source, runtime string, extraLabels map[string]string) (*v1.ConfigMap, error) {
-	labels := map[string]string{
-		funktion.KindLabel:    funktion.FunctionKind,
-		funktion.RuntimeLabel: runtime,
-	}
+func (p *createFunctionCmd) createFunctionFromSource(name, source, runtime string, extraLabels map[string]string) (*spec.Function, error) {
+	labels := map[string]string{}
 	for k, v := range extraLabels {
-		if labels[k] == "" {
-			labels[k] = v
-		}
-	}
-	data := map[string]string{
-		funktion.SourceProperty: source,
-	}
-	if p.debug {
-		data[funktion.DebugProperty] = "true"
-	}
-	if len(p.envVars) > 0 {
-		data[funktion.EnvVarsProperty] = strings.Join(p.envVars, "\n")
+		labels[k] = v
 	}
-	cm := &v1.ConfigMap{
+	function := &spec.Function{
 		ObjectMeta: v1.ObjectMeta{
 			Name:   name,
 			Labels: labels,
 		},
-		Data: data,
+		Spec: spec.FunctionSpec{
+			Runtime: runtime,
+			Source:  source,
+			Debug:   p.debug,
+		},
+	}
+	if len(p.envVars) > 0 {
+		env, err := parseEnvVarArgs(p.envVars)
+		if err != nil {
+			return nil, err
+		}
+		function.Spec.Env = env
+	}
+	for _, arg := range p.secretEnv {
+		env, err := funktion.ParseSecretEnvVar(arg)
+		if err != nil {
+			return nil, err
+		}
+		function.Spec.SecretEnv = append(function.Spec.SecretEnv, env)
+	}
+	for _, arg := range p.secretMounts {
+		mount, err := funktion.ParseSecretMount(arg)
+		if err != nil {
+			return nil, err
+		}
+		function.Spec.SecretMounts = append(function.Spec.SecretMounts, mount)
+	}
+	if p.maxReplicas > 0 {
+		function.Spec.Autoscaling = &spec.AutoscalingSpec{
+			MinReplicas:                    p.minReplicas,
+			MaxReplicas:                    p.maxReplicas,
+			TargetCPUUtilizationPercentage: p.targetCPU,
+			TargetConcurrency:              p.targetConcurrency,
+		}
+	} else if p.minReplicas > 0 || p.targetCPU > 0 || p.targetConcurrency > 0 {
+		return nil, fmt.Errorf("The --max-replicas flag is required to autoscale the function")
+	}
+	if p.idleTimeout > 0 {
+		if p.maxReplicas > 0 {
+			return nil, fmt.Errorf("The --idle-timeout flag can not be combined with autoscaling using --max-replicas")
+		}
+		function.Spec.IdleTimeout = p.idleTimeout.String()
+	}
+	if len(p.schedule) > 0 {
+		if _, err := cron.Parse(p.schedule); err != nil {
+			return nil, err
+		}
+		function.Spec.Schedule = &spec.ScheduleSpec{
+			Cron:    p.schedule,
+			Payload: p.schedulePayload,
+		}
+	} else if len(p.schedulePayload) > 0 {
+		return nil, fmt.Errorf("The --schedule flag is required to invoke the function with a payload")
+	}
+	pod, err := p.pod.podSettings()
+	if err != nil {
+		return nil, err
+	}
+	function.Spec.Pod = pod
+	gateway, err := p.gateway.gatewaySpec()
+	if err != nil {
+		return nil, err
 	}
-	return cm, nil
+	function.Spec.Gateway = gateway
+	return function, nil
+}
+
+// parseEnvVarArgs parses environment variables of the form NAME=VALUE
+func parseEnvVarArgs(args []string) ([]v1.EnvVar, error) {
+	answer := []v1.EnvVar{}
+	for _, arg := range args {
+		pair := strings.SplitN(arg, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("Environment variable does not contain `=` but was `%s`", arg)
+		}
+		answer = append(answer, v1.EnvVar{
+			Name:  pair[0],
+			Value: pair[1],
+		})
+	}
+	return answer, nil
 }
 
 func loadFileSource(fileName string) (string, error) {
@@ -503,19 +590,13 @@ func loadFileSource(fileName string) (string, error) {
 }
 
 func (p *createFunctionCmd) checkRuntimeExists(name string) error {
-	listOpts, err := funktion.CreateRuntimeListOptions()
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return err
 	}
-	cms, err := p.kubeclient.ConfigMaps(p.namespace).List(*listOpts)
-	if err != nil {
-		return err
+	_, err = client.Runtimes(p.namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("No runtime exists called `%s`", name)
 	}
-	for _, resource := range cms.Items {
-		if resource.Name == name {
-			return nil
-		}
-	}
-	return fmt.Errorf("No runtime exists called `%s`", name)
-
+	return err
 }
@@ -20,6 +20,7 @@ import (
 	"log"
 	"net/url"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -28,9 +29,9 @@ import (
 
 	"k8s.io/client-go/1.5/kubernetes"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
 	"k8s.io/client-go/1.5/pkg/api/v1"
 
-	"github.com/funktionio/funktion/pkg/funktion"
 	"github.com/funktionio/funktion/pkg/spec"
 )
 
@@ -54,6 +55,7 @@ type createFlowCmd struct {
 	args          []string
 	trace         bool
 	logResult     bool
+	pod           podSettingsFlags
 }
 
 func newCreateFlowCmd() *cobra.Command {
@@ -80,6 +82,7 @@ func newCreateFlowCmd() *cobra.Command {
 	f.BoolVar(&p.logResult, "log-result", true, "whether to log the result of the subcription to the log of the subcription pod")
 	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
 	f.StringVar(&p.namespace, "namespace", "", "the namespace to create the flow inside")
+	p.pod.addFlags(f)
 	return cmd
 }
 
@@ -131,8 +134,13 @@ func (p *createFlowCmd) run() error {
 	}
 	funktionYml := string(funktionData)
 
+	pod, err := p.pod.podSettings()
+	if err != nil {
+		return err
+	}
+
 	message := stepsText(steps)
-	return p.applyFlowWithConnector(name, funktionYml, connectorName, message)
+	return p.applyFlowWithConnector(name, funktionYml, connectorName, message, pod)
 }
 
 func (p *createCmdCommon) applyFlow(fileName, source string) error {
@@ -144,58 +152,61 @@ func (p *createCmdCommon) applyFlow(fileName, source string) error {
 	message := fmt.Sprintf("from file %s", fileName)
 	// TODO parse from the steps!
 	connectorName := "timer"
-	return p.applyFlowWithConnector(name, source, connectorName, message)
+	return p.applyFlowWithConnector(name, source, connectorName, message, nil)
 }
 
-func (p *createCmdCommon) applyFlowWithConnector(name, funktionYml, connectorName, message string) error {
+func (p *createCmdCommon) applyFlowWithConnector(name, funktionYml, connectorName, message string, pod *spec.PodSettings) error {
 	connector, err := p.checkConnectorExists(connectorName)
 	if err != nil {
 		return err
 	}
 
-	applicationProperties := ""
-	if connector.Data != nil {
-		applicationProperties = connector.Data[funktion.ApplicationPropertiesProperty]
-	}
+	applicationProperties := connector.Spec.ApplicationProperties
 	if len(applicationProperties) == 0 {
 		applicationProperties = "# put your spring boot configuration properties here..."
 	}
 
-	labels := map[string]string{
-		funktion.KindLabel:      funktion.FlowKind,
-		funktion.ConnectorLabel: connectorName,
-	}
-	data := map[string]string{
-		funktion.FunktionYmlProperty:           funktionYml,
-		funktion.ApplicationPropertiesProperty: applicationProperties,
+	funktionConfig := &spec.FunkionConfig{}
+	err = yaml.Unmarshal([]byte(funktionYml), funktionConfig)
+	if err != nil {
+		return fmt.Errorf("Failed to parse the flow %s due to %v", name, err)
 	}
-	cm := v1.ConfigMap{
+	flow := &spec.Flow{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      name,
 			Namespace: p.namespace,
-			Labels:    labels,
 		},
-		Data: data,
+		Spec: spec.FlowSpec{
+			Connector:             connectorName,
+			Funktion:              funktionConfig,
+			ApplicationProperties: applicationProperties,
+			Pod:                   pod,
+		},
 	}
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	flows := client.Flows(p.namespace)
 	update := false
-	old, err := p.kubeclient.ConfigMaps(p.namespace).Get(name)
+	old, err := flows.Get(name)
 	if err == nil {
 		update = true
 	}
 
 	action := "created"
 	if update {
-		if old.Data != nil && old.Labels != nil &&
-			old.Data[funktion.FunktionYmlProperty] == cm.Data[funktion.FunktionYmlProperty] &&
-			old.Data[funktion.ApplicationPropertiesProperty] == cm.Data[funktion.ApplicationPropertiesProperty] &&
-			old.Labels[funktion.ConnectorLabel] == cm.Labels[funktion.ConnectorLabel] {
+		if reflect.DeepEqual(old.Spec.Funktion, flow.Spec.Funktion) &&
+			old.Spec.ApplicationProperties == flow.Spec.ApplicationProperties &&
+			old.Spec.Connector == flow.Spec.Connector &&
+			reflect.DeepEqual(old.Spec.Pod, flow.Spec.Pod) {
 			// source not changed so lets not update!
 			return nil
 		}
-		_, err = p.kubeclient.ConfigMaps(p.namespace).Update(&cm)
+		_, err = flows.Update(flow)
 		action = "updated"
 	} else {
-		_, err = p.kubeclient.ConfigMaps(p.namespace).Create(&cm)
+		_, err = flows.Create(flow)
 	}
 
 	if err == nil {
@@ -264,22 +275,16 @@ func parseHeaders(text string) (map[string]string, error) {
 	return m, nil
 }
 
-func (p *createCmdCommon) checkConnectorExists(name string) (*v1.ConfigMap, error) {
-	listOpts, err := funktion.CreateConnectorListOptions()
+func (p *createCmdCommon) checkConnectorExists(name string) (*spec.Connector, error) {
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return nil, err
 	}
-	cms := p.kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
-	if err != nil {
-		return nil, err
-	}
-	for _, resource := range resources.Items {
-		if resource.Name == name {
-			return &resource, nil
-		}
+	connector, err := client.Connectors(p.namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("Connector \"%s\" not found so cannot create this flow", name)
 	}
-	return nil, fmt.Errorf("Connector \"%s\" not found so cannot create this flow", name)
+	return connector, err
 }
 
 func (p *createFlowCmd) generateName(steps []spec.FunktionStep) (string, error) {
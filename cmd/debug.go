@@ -20,7 +20,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/funktionio/funktion/pkg/funktion"
@@ -145,36 +144,26 @@ func (p *debugCmd) run() error {
 }
 
 func (p *debugCmd) createPortText(kindText, name string) (string, error) {
-	kind, listOpts, err := listOptsForKind(kindText)
+	kind, _, err := listOptsForKind(kindText)
 	if err != nil {
 		return "", err
 	}
-	cms := p.kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return "", err
 	}
-	var found *v1.ConfigMap
-	for _, resource := range resources.Items {
-		if name == resource.Name {
-			found = &resource
-		}
-	}
-	if found == nil {
-		return "", fmt.Errorf("No %s resource found for name %s", kind, name)
-	}
 
 	debugPort := 0
-	if kind == functionKind {
-		// ensure debug mode is enabled on the function
-		if found.Data == nil {
-			found.Data = map[string]string{}
+	switch kind {
+	case functionKind:
+		function, err := client.Functions(p.namespace).Get(name)
+		if err != nil {
+			return "", notFoundError(kind, name, err)
 		}
-		data := found.Data
-		debugMode := data[funktion.DebugProperty]
-		if strings.ToLower(debugMode) != "true" {
-			data[funktion.DebugProperty] = "true"
-			_, err = cms.Update(found)
+		// ensure debug mode is enabled on the function
+		if !function.Spec.Debug {
+			function.Spec.Debug = true
+			_, err = client.Functions(p.namespace).Update(function)
 			if err != nil {
 				return "", fmt.Errorf("Failed to update Function %s to enable debug mode %v", name, err)
 			}
@@ -182,46 +171,38 @@ func (p *debugCmd) createPortText(kindText, name string) (string, error) {
 		}
 
 		// lets use the debug port on the runtime
-		runtime := ""
-		labels := found.Labels
-		if labels != nil {
-			runtime = labels[funktion.RuntimeLabel]
+		if len(function.Spec.Runtime) > 0 {
+			return p.createPortText(runtimeKind, function.Spec.Runtime)
 		}
-		if len(runtime) > 0 {
-			return p.createPortText(runtimeKind, runtime)
-		}
-	} else if kind == runtimeKind || kind == connectorKind {
-		data := found.Data
-		if data != nil {
-			portValue := data[funktion.DebugPortProperty]
-			if len(portValue) > 0 {
-				debugPort, err = strconv.Atoi(portValue)
-				if err != nil {
-					return "", fmt.Errorf("Failed to convert debug port `%s` to a number due to %v", portValue, err)
-				}
-			}
+	case runtimeKind:
+		runtime, err := client.Runtimes(p.namespace).Get(name)
+		if err != nil {
+			return "", notFoundError(kind, name, err)
 		}
-		annotations := found.Annotations
-		if kind == runtimeKind && annotations != nil {
-			flag := annotations[funktion.ChromeDevToolsAnnotation]
-			if flag == "true" {
+		debugPort = runtime.Spec.DebugPort
+		flag := runtime.Annotations[funktion.ChromeDevToolsAnnotation]
+		if flag == "true" {
+			p.supportsChromeDevTools = true
+		} else if len(flag) == 0 {
+			// TODO handle older nodejs runtimes which don't have the annotation
+			// remove after next funktion-connectors release!
+			if runtime.Name == "nodejs" {
 				p.supportsChromeDevTools = true
-			} else if len(flag) == 0 {
-				// TODO handle older nodejs runtimes which don't have the annotation
-				// remove after next funktion-connectors release!
-				if found.Name == "nodejs" {
-					p.supportsChromeDevTools = true
-				}
 			}
 		}
-	} else if kind == flowKind {
-		connector := ""
-		data := found.Labels
-		if data != nil {
-			connector = data[funktion.ConnectorLabel]
+	case connectorKind:
+		connector, err := client.Connectors(p.namespace).Get(name)
+		if err != nil {
+			return "", notFoundError(kind, name, err)
+		}
+		debugPort = connector.Spec.DebugPort
+	case flowKind:
+		flow, err := client.Flows(p.namespace).Get(name)
+		if err != nil {
+			return "", notFoundError(kind, name, err)
 		}
-		if len(connector) > 0 {
-			return p.createPortText(runtimeKind, connector)
+		if len(flow.Spec.Connector) > 0 {
+			return p.createPortText(connectorKind, flow.Spec.Connector)
 		}
 	}
 	if debugPort == 0 {
@@ -273,62 +254,34 @@ func (p *debugCmd) viewLog(pod *v1.Pod) error {
 		}
 
 		if p.supportsChromeDevTools {
-			return p.findChromeDevToolsURL(pod, binaryFile)
+			return p.findChromeDevToolsURL(pod)
 		}
 	}
 	return nil
 }
 
-func (p *debugCmd) findChromeDevToolsURL(pod *v1.Pod, binaryFile string) error {
-	name := pod.Name
-
-	args := []string{"logs", "-f", name}
-	cmd := exec.Command(binaryFile, args...)
-
-	cmdReader, err := cmd.StdoutPipe()
+func (p *debugCmd) findChromeDevToolsURL(pod *v1.Pod) error {
+	stream, err := k8sutil.PodLogStream(p.kubeclient, pod, &v1.PodLogOptions{Follow: true})
 	if err != nil {
-		return fmt.Errorf("failed to get stdout for command %s %s: %v", filepath.Base(binaryFile), strings.Join(args, " "), err)
+		return fmt.Errorf("failed to read the log of pod %s: %v", pod.Name, err)
 	}
+	defer stream.Close()
 
-	scanner := bufio.NewScanner(cmdReader)
+	scanner := bufio.NewScanner(stream)
 	line := 0
-	go func() {
-		for scanner.Scan() {
-			if line++; line > 50 {
-				fmt.Printf("No log line found starting with `%s` in the first %d lines. Maybe debug is not really enabled in this pod?\n", chromeDevToolsURLPrefix, line)
-				cmdReader.Close()
-				killCmd(cmd)
-			}
-			text := strings.TrimSpace(scanner.Text())
-			if strings.HasPrefix(text, chromeDevToolsURLPrefix) {
-				fmt.Printf("\nTo Debug open: %s\n\n", text)
-				cmdReader.Close()
-				killCmd(cmd)
-				if p.chromeDevTools {
-					browser.OpenURL(text)
-				}
+	for scanner.Scan() {
+		if line++; line > 50 {
+			fmt.Printf("No log line found starting with `%s` in the first %d lines. Maybe debug is not really enabled in this pod?\n", chromeDevToolsURLPrefix, line)
+			return nil
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, chromeDevToolsURLPrefix) {
+			fmt.Printf("\nTo Debug open: %s\n\n", text)
+			if p.chromeDevTools {
+				browser.OpenURL(text)
 			}
+			return nil
 		}
-	}()
-
-	if err := cmd.Start(); err != nil {
-		killCmd(cmd)
-		return fmt.Errorf("failed to start command %s %s: %v", filepath.Base(binaryFile), strings.Join(args, " "), err)
-	}
-
-	err = cmd.Wait()
-	if err != nil {
-		killCmd(cmd)
-		// ignore errors as we get an error if we kill it
 	}
 	return nil
 }
-
-func killCmd(cmd *exec.Cmd) {
-	if cmd != nil {
-		p := cmd.Process
-		if p != nil {
-			p.Kill()
-		}
-	}
-}
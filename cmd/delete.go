@@ -17,11 +17,11 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/funktionio/funktion/pkg/funktion"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/1.5/kubernetes"
 	"k8s.io/client-go/1.5/pkg/api"
-	"k8s.io/client-go/1.5/pkg/api/v1"
-	"strings"
+	"k8s.io/client-go/1.5/pkg/api/errors"
 )
 
 type deleteCmd struct {
@@ -71,12 +71,11 @@ func newDeleteCmd() *cobra.Command {
 }
 
 func (p *deleteCmd) run() error {
-	kind, listOpts, err := listOptsForKind(p.kind)
+	kind, _, err := listOptsForKind(p.kind)
 	if err != nil {
 		return err
 	}
-	cms := p.kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return err
 	}
@@ -85,9 +84,13 @@ func (p *deleteCmd) run() error {
 		if !p.all {
 			return fmt.Errorf("No `name` specified or the `--all` flag specified so cannot delete a %s", kind)
 		}
+		names, err := resourceNames(client, p.namespace, kind)
+		if err != nil {
+			return err
+		}
 		count := 0
-		for _, resource := range resources.Items {
-			err = p.deleteResource(&resource)
+		for _, name := range names {
+			err = p.deleteResource(client, kind, name)
 			if err != nil {
 				return err
 			}
@@ -95,30 +98,86 @@ func (p *deleteCmd) run() error {
 		}
 		fmt.Printf("Deleted %d %s resource(s)\n", count, kind)
 	} else {
-		for _, resource := range resources.Items {
-			if resource.Name == name {
-				err = p.deleteResource(&resource)
-				if err == nil {
-					fmt.Printf("Deleted %s \"%s\" resource\n", kind, name)
-				}
-				return err
-			}
+		err = p.deleteResource(client, kind, name)
+		if err == nil {
+			fmt.Printf("Deleted %s \"%s\" resource\n", kind, name)
 		}
-		return fmt.Errorf("%s \"%s\" not found", kind, name)
+		return err
 	}
 	return nil
 }
 
-func (p *deleteCmd) deleteResource(cm *v1.ConfigMap) error {
-	kind := strings.ToLower(cm.Kind)
-	name := cm.Name
-	ns := cm.Namespace
-	if len(ns) == 0 {
-		ns = p.namespace
+func (p *deleteCmd) deleteResource(client *funktion.Client, kind string, name string) error {
+	var err error
+	switch kind {
+	case flowKind:
+		err = client.Flows(p.namespace).Delete(name)
+	case connectorKind:
+		err = client.Connectors(p.namespace).Delete(name)
+	case runtimeKind:
+		err = client.Runtimes(p.namespace).Delete(name)
+	default:
+		err = client.Functions(p.namespace).Delete(name)
+	}
+	if errors.IsNotFound(err) {
+		return notFoundError(kind, name, err)
 	}
-	err := p.kubeclient.ConfigMaps(ns).Delete(name, &api.DeleteOptions{})
 	if err != nil {
 		return fmt.Errorf("Failed to delete %s \"%s\" due to: %v", kind, name, err)
 	}
 	return nil
 }
+
+// resourceNames returns the names of the resources of the given kind
+func resourceNames(client *funktion.Client, namespace string, kind string) ([]string, error) {
+	opts := api.ListOptions{}
+	names := []string{}
+	switch kind {
+	case flowKind:
+		list, err := client.Flows(namespace).List(opts)
+		if err != nil && funktion.ListFailures(err) == nil {
+			return nil, err
+		}
+		names = appendFailedNames(names, err)
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	case connectorKind:
+		list, err := client.Connectors(namespace).List(opts)
+		if err != nil && funktion.ListFailures(err) == nil {
+			return nil, err
+		}
+		names = appendFailedNames(names, err)
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	case runtimeKind:
+		list, err := client.Runtimes(namespace).List(opts)
+		if err != nil && funktion.ListFailures(err) == nil {
+			return nil, err
+		}
+		names = appendFailedNames(names, err)
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	default:
+		list, err := client.Functions(namespace).List(opts)
+		if err != nil && funktion.ListFailures(err) == nil {
+			return nil, err
+		}
+		names = appendFailedNames(names, err)
+		for _, item := range list.Items {
+			names = append(names, item.Name)
+		}
+	}
+	return names, nil
+}
+
+// appendFailedNames appends the names of the resources which could not be converted so that
+// broken resources are deleted too
+func appendFailedNames(names []string, err error) []string {
+	for name := range funktion.ListFailures(err) {
+		names = append(names, name)
+	}
+	return names
+}
@@ -26,7 +26,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"k8s.io/client-go/1.5/kubernetes"
-	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/api/errors"
 	"strconv"
 )
 
@@ -39,7 +39,6 @@ type editConnectorCmd struct {
 	name           string
 	listProperties bool
 
-	configMaps            map[string]*v1.ConfigMap
 	schema                *spec.ConnectorSchema
 	applicationProperties *properties.Properties
 
@@ -110,26 +109,17 @@ func parseProperties(args []string) (map[string]string, error) {
 }
 
 func (p *editConnectorCmd) run() error {
-	listOpts, err := funktion.CreateConnectorListOptions()
+	name := p.name
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return err
 	}
-	kubeclient := p.kubeclient
-	cms := kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	connector, err := client.Connectors(p.namespace).Get(name)
 	if err != nil {
-		return err
-	}
-	var connector *v1.ConfigMap
-	for _, resource := range resources.Items {
-		if resource.Name == p.name {
-			connector = &resource
-			break
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("No Connector called `%s` exists in namespace %s", name, p.namespace)
 		}
-	}
-	name := p.name
-	if connector == nil {
-		return fmt.Errorf("No Connector called `%s` exists in namespace %s", name, p.namespace)
+		return err
 	}
 	err = p.loadConnectorSchema(name, connector)
 	if err != nil {
@@ -147,22 +137,14 @@ func (p *editConnectorCmd) run() error {
 	return err
 }
 
-func (p *editConnectorCmd) loadConnectorSchema(name string, connector *v1.ConfigMap) error {
+func (p *editConnectorCmd) loadConnectorSchema(name string, connector *spec.Connector) error {
 	// lets load the connector model
-	if connector.Data == nil {
-		return fmt.Errorf("No data in the Connector %s", name)
-	}
-	schemaYaml := connector.Data[funktion.SchemaYmlProperty]
-	if len(schemaYaml) == 0 {
+	if connector.Spec.Schema == nil {
 		return fmt.Errorf("No YAML for data key %s in Connector %s", funktion.SchemaYmlProperty, name)
 	}
-	schema, err := funktion.LoadConnectorSchema([]byte(schemaYaml))
-	if err != nil {
-		return err
-	}
-	p.schema = schema
+	p.schema = connector.Spec.Schema
 
-	propertiesText := connector.Data[funktion.ApplicationPropertiesProperty]
+	propertiesText := connector.Spec.ApplicationProperties
 	if len(propertiesText) > 0 {
 		props, err := properties.LoadString(propertiesText)
 		if err != nil {
@@ -175,7 +157,7 @@ func (p *editConnectorCmd) loadConnectorSchema(name string, connector *v1.Config
 	return nil
 }
 
-func (p *editConnectorCmd) listConnectorProperties(name string, connector *v1.ConfigMap) error {
+func (p *editConnectorCmd) listConnectorProperties(name string, connector *spec.Connector) error {
 	compProps := p.schema.ComponentProperties
 	if len(compProps) == 0 {
 		fmt.Printf("The connector `%s` has no properties to configure!", name)
@@ -203,7 +185,7 @@ func (p *editConnectorCmd) listConnectorProperties(name string, connector *v1.Co
 	return nil
 }
 
-func (p *editConnectorCmd) editConnector(name string, connector *v1.ConfigMap) error {
+func (p *editConnectorCmd) editConnector(name string, connector *spec.Connector) error {
 	compProps := p.schema.ComponentProperties
 	if len(compProps) == 0 {
 		fmt.Printf("The connector `%s` has no properties to configure!", name)
@@ -268,13 +250,13 @@ func (p *editConnectorCmd) editConnector(name string, connector *v1.ConfigMap) e
 		p.applicationProperties.Write(w, properties.UTF8)
 		w.Flush()
 		propText := b.String()
-		cms := p.kubeclient.ConfigMaps(p.namespace)
-		latestCon, err := cms.Get(name)
+		connectors := client.Connectors(p.namespace)
+		latestCon, err := connectors.Get(name)
 		if err != nil {
 			return err
 		}
-		latestCon.Data[funktion.ApplicationPropertiesProperty] = propText
-		_, err = cms.Update(latestCon)
+		latestCon.Spec.ApplicationProperties = propText
+		_, err = connectors.Update(latestCon)
 		if err == nil {
 			fmt.Printf("Connector %s updated\n", name)
 		}
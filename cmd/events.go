@@ -0,0 +1,160 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+type eventsCmd struct {
+	kubeclient     *kubernetes.Clientset
+	cmd            *cobra.Command
+	kubeConfigPath string
+
+	namespace string
+	kind      string
+	name      string
+}
+
+func init() {
+	RootCmd.AddCommand(newEventsCmd())
+}
+
+func newEventsCmd() *cobra.Command {
+	p := &eventsCmd{}
+	cmd := &cobra.Command{
+		Use:   "events KIND NAME [flags]",
+		Short: "lists the events for the given function or flow",
+		Long:  `This command will list the events posted by the operator for a function or flow along with the events of its Deployment and Service`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			if len(args) < 1 {
+				handleError(fmt.Errorf("No resource kind argument supplied! Possible values ['fn', 'flow']"))
+				return
+			}
+			p.kind = args[0]
+			kind, _, err := listOptsForKind(p.kind)
+			if err != nil {
+				handleError(err)
+				return
+			}
+			if len(args) < 2 {
+				handleError(fmt.Errorf("No %s name specified!", kind))
+				return
+			}
+			p.name = args[1]
+			err = createKubernetesClient(cmd, p.kubeConfigPath, &p.kubeclient, &p.namespace)
+			if err != nil {
+				handleError(err)
+				return
+			}
+			handleError(p.run())
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace to query")
+	return cmd
+}
+
+func (p *eventsCmd) run() error {
+	deploymentName, err := nameForDeployment(p.kubeclient, p.namespace, p.kind, p.name)
+	if err != nil {
+		return err
+	}
+	serviceName, err := nameForService(p.kubeclient, p.namespace, p.kind, p.name)
+	if err != nil {
+		return err
+	}
+	list, err := p.kubeclient.Events(p.namespace).List(api.ListOptions{})
+	if err != nil {
+		return err
+	}
+	events := filterEvents(list.Items, p.name, deploymentName, serviceName)
+	if len(events) == 0 {
+		fmt.Printf("No events found for %s %s\n", p.kind, p.name)
+		return nil
+	}
+
+	printEventRow("LASTSEEN", "COUNT", "KIND", "TYPE", "REASON", "MESSAGE")
+	for _, event := range events {
+		lastSeen := shortHumanDuration(time.Now().Sub(event.LastTimestamp.Time))
+		printEventRow(lastSeen, fmt.Sprintf("%d", event.Count), event.InvolvedObject.Kind, event.Type, event.Reason, event.Message)
+	}
+	return nil
+}
+
+// filterEvents returns the events of the ConfigMap, Deployment and Service with the given names
+// sorted by when they were last seen
+func filterEvents(list []v1.Event, name, deploymentName, serviceName string) []v1.Event {
+	events := []v1.Event{}
+	for _, event := range list {
+		ref := event.InvolvedObject
+		switch ref.Kind {
+		case "ConfigMap":
+			if ref.Name == name {
+				events = append(events, event)
+			}
+		case "Deployment":
+			if ref.Name == deploymentName {
+				events = append(events, event)
+			}
+		case "Service":
+			if ref.Name == serviceName {
+				events = append(events, event)
+			}
+		}
+	}
+	sort.Sort(eventsByLastTimestamp(events))
+	return events
+}
+
+func printEventRow(lastSeen, count, kind, eventType, reason, message string) {
+	fmt.Printf("%-9s %-6s %-11s %-8s %-18s %s\n", lastSeen, count, kind, eventType, reason, message)
+}
+
+// shortHumanDuration formats a duration in the same style as kubectl
+func shortHumanDuration(d time.Duration) string {
+	if seconds := int(d.Seconds()); seconds < -1 {
+		return "<invalid>"
+	} else if seconds < 0 {
+		return "0s"
+	} else if seconds < 60 {
+		return fmt.Sprintf("%ds", seconds)
+	} else if minutes := int(d.Minutes()); minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	} else if hours := int(d.Hours()); hours < 24 {
+		return fmt.Sprintf("%dh", hours)
+	} else if hours < 24*365 {
+		return fmt.Sprintf("%dd", hours/24)
+	}
+	return fmt.Sprintf("%dy", int(d.Hours()/24/365))
+}
+
+type eventsByLastTimestamp []v1.Event
+
+func (e eventsByLastTimestamp) Len() int      { return len(e) }
+func (e eventsByLastTimestamp) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e eventsByLastTimestamp) Less(i, j int) bool {
+	return e[i].LastTimestamp.Time.Before(e[j].LastTimestamp.Time)
+}
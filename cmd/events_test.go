@@ -0,0 +1,58 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestFilterEvents(t *testing.T) {
+	now := time.Now()
+	event := func(kind, name, reason string, age time.Duration) v1.Event {
+		return v1.Event{
+			InvolvedObject: v1.ObjectReference{Kind: kind, Name: name},
+			Reason:         reason,
+			LastTimestamp:  unversioned.NewTime(now.Add(-age)),
+		}
+	}
+	events := filterEvents([]v1.Event{
+		event("ConfigMap", "hello", "Updated", time.Minute),
+		event("Deployment", "hello", "ScalingReplicaSet", 2*time.Minute),
+		event("Service", "hello", "CreatedLoadBalancer", 3*time.Minute),
+		event("ConfigMap", "other", "Created", 4*time.Minute),
+		event("Pod", "hello-1234", "Pulled", 5*time.Minute),
+		event("ConfigMap", "hello", "Created", 10*time.Minute),
+	}, "hello", "hello", "hello")
+
+	reasons := []string{}
+	for _, e := range events {
+		reasons = append(reasons, e.Reason)
+	}
+	assertEquals(t, strings.Join(reasons, ","), "Created,CreatedLoadBalancer,ScalingReplicaSet,Updated")
+}
+
+func TestShortHumanDuration(t *testing.T) {
+	assertEquals(t, shortHumanDuration(-5*time.Second), "<invalid>")
+	assertEquals(t, shortHumanDuration(30*time.Second), "30s")
+	assertEquals(t, shortHumanDuration(5*time.Minute), "5m")
+	assertEquals(t, shortHumanDuration(3*time.Hour), "3h")
+	assertEquals(t, shortHumanDuration(50*time.Hour), "2d")
+	assertEquals(t, shortHumanDuration(2*365*24*time.Hour), "2y")
+}
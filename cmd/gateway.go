@@ -0,0 +1,83 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/go-kit/kit/log"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/kubernetes"
+)
+
+type gatewayCmd struct {
+	kubeConfigPath string
+	namespace      string
+	listenAddress  string
+}
+
+func newGatewayCmd() *cobra.Command {
+	p := &gatewayCmd{}
+	cmd := &cobra.Command{
+		Use:   "gateway",
+		Short: "Runs the HTTP gateway which routes requests to functions by name",
+		Long: fmt.Sprintf(`This command runs the HTTP gateway which forwards requests to %sNAME to the service of the function NAME.
+
+Functions can require an API key or a JSON Web Token, allow CORS requests and limit the size and duration of their requests.
+The operator manages a gateway deployment when it is run with --gateway-image.`, funktion.GatewayPathPrefix),
+		Run: func(cmd *cobra.Command, args []string) {
+			handleError(p.run())
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace of the functions to route to. Defaults to $KUBERNETES_NAMESPACE")
+	f.StringVar(&p.listenAddress, "listen-address", fmt.Sprintf(":%d", funktion.GatewayPort), "the address to serve the gateway on")
+	return cmd
+}
+
+func (p *gatewayCmd) run() error {
+	logger := log.NewContext(log.NewLogfmtLogger(os.Stdout)).
+		With("ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller).
+		With("gateway", "funktion")
+
+	cfg, err := createKubernetesClientConfig(p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	kclient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	namespace := p.namespace
+	if len(namespace) == 0 {
+		namespace = os.Getenv("KUBERNETES_NAMESPACE")
+	}
+	if len(namespace) == 0 {
+		return fmt.Errorf("No namespace argument or $KUBERNETES_NAMESPACE environment variable specified")
+	}
+
+	gateway, err := funktion.NewGateway(kclient, logger, namespace)
+	if err != nil {
+		return err
+	}
+	go gateway.Run(make(chan struct{}))
+	logger.Log("msg", "serving gateway", "address", p.listenAddress, "namespace", namespace)
+	return http.ListenAndServe(p.listenAddress, gateway)
+}
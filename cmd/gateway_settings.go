@@ -0,0 +1,86 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/1.5/pkg/api/resource"
+)
+
+// gatewayFlags are the flags which configure how the gateway serves a function
+type gatewayFlags struct {
+	auth           string
+	authSecret     string
+	corsOrigins    []string
+	maxRequestSize string
+	timeout        time.Duration
+}
+
+func (p *gatewayFlags) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&p.auth, "gateway-auth", "", fmt.Sprintf("how the gateway authenticates requests to the function: one of %s", strings.Join(funktion.GatewayAuthModes, ", ")))
+	f.StringVar(&p.authSecret, "gateway-auth-secret", "", "the secretName:key of the API key or of the HMAC key which signs the JSON Web Tokens")
+	f.StringArrayVar(&p.corsOrigins, "cors-origin", []string{}, "one or more origins allowed to call the function from a browser through the gateway. Use * to allow any origin")
+	f.StringVar(&p.maxRequestSize, "max-request-size", "", "the largest request body the gateway forwards to the function such as 1Mi")
+	f.DurationVar(&p.timeout, "gateway-timeout", 0, "how long the gateway waits for the function to respond")
+}
+
+// gatewaySpec returns the gateway settings of the flags or nil if none were given
+func (p *gatewayFlags) gatewaySpec() (*spec.GatewaySpec, error) {
+	if len(p.auth) == 0 && len(p.authSecret) == 0 && len(p.corsOrigins) == 0 && len(p.maxRequestSize) == 0 && p.timeout == 0 {
+		return nil, nil
+	}
+	answer := &spec.GatewaySpec{
+		Auth:           p.auth,
+		CORSOrigins:    p.corsOrigins,
+		MaxRequestSize: p.maxRequestSize,
+	}
+	if len(p.auth) > 0 {
+		found := false
+		for _, mode := range funktion.GatewayAuthModes {
+			found = found || mode == p.auth
+		}
+		if !found {
+			return nil, fmt.Errorf("The --gateway-auth flag must be one of %s but was `%s`", strings.Join(funktion.GatewayAuthModes, ", "), p.auth)
+		}
+		if len(p.authSecret) == 0 {
+			return nil, fmt.Errorf("The --gateway-auth-secret flag is required to authenticate requests")
+		}
+	}
+	if len(p.authSecret) > 0 {
+		if len(p.auth) == 0 {
+			return nil, fmt.Errorf("The --gateway-auth flag is required to use --gateway-auth-secret")
+		}
+		secret, err := funktion.ParseSecretKey(p.authSecret)
+		if err != nil {
+			return nil, err
+		}
+		answer.AuthSecret = secret
+	}
+	if len(p.maxRequestSize) > 0 {
+		if _, err := resource.ParseQuantity(p.maxRequestSize); err != nil {
+			return nil, fmt.Errorf("Invalid --max-request-size `%s`: %v", p.maxRequestSize, err)
+		}
+	}
+	if p.timeout > 0 {
+		answer.Timeout = p.timeout.String()
+	}
+	return answer, nil
+}
@@ -17,8 +17,8 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"sort"
 
-	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 
 	"k8s.io/client-go/1.5/kubernetes"
@@ -79,16 +79,11 @@ func newGetCmd() *cobra.Command {
 }
 
 func (p *getCmd) run() error {
-	kind, listOpts, err := listOptsForKind(p.kind)
+	kind, _, err := listOptsForKind(p.kind)
 	if err != nil {
 		return err
 	}
 	kubeclient := p.kubeclient
-	cms := kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
-	if err != nil {
-		return err
-	}
 	p.deployments = map[string]*v1beta1.Deployment{}
 	p.services = map[string]*v1.Service{}
 	ds, err := kubeclient.Deployments(p.namespace).List(api.ListOptions{})
@@ -118,100 +113,230 @@ func (p *getCmd) run() error {
 			p.services[name] = &copy
 		}
 	}
+	client, err := createClient(kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
 	name := p.name
-	if len(name) == 0 {
+	opts := api.ListOptions{}
+	var failed map[string]error
+	switch kind {
+	case functionKind:
+		functions := []*spec.Function{}
+		if len(name) == 0 {
+			list, err := client.Functions(p.namespace).List(opts)
+			failed = funktion.ListFailures(err)
+			if err != nil && failed == nil {
+				return err
+			}
+			functions = list.Items
+		} else {
+			function, err := client.Functions(p.namespace).Get(name)
+			if err != nil {
+				return notFoundError(kind, name, err)
+			}
+			functions = append(functions, function)
+		}
 		p.printHeader(kind)
-		for _, resource := range resources.Items {
-			p.printResource(&resource, kind)
-		}
-
-	} else {
-		found := false
-		for _, resource := range resources.Items {
-			if resource.Name == name {
-				p.printHeader(kind)
-				p.printResource(&resource, kind)
-				found = true
-				break
+		for _, function := range functions {
+			p.printFunction(function)
+		}
+		p.printFailures(kind, failed)
+	case flowKind:
+		flows := []*spec.Flow{}
+		if len(name) == 0 {
+			list, err := client.Flows(p.namespace).List(opts)
+			failed = funktion.ListFailures(err)
+			if err != nil && failed == nil {
+				return err
+			}
+			flows = list.Items
+		} else {
+			flow, err := client.Flows(p.namespace).Get(name)
+			if err != nil {
+				return notFoundError(kind, name, err)
 			}
+			flows = append(flows, flow)
 		}
-		if !found {
-			return fmt.Errorf("%s \"%s\" not found", kind, name)
+		p.printHeader(kind)
+		for _, flow := range flows {
+			p.printFlow(flow)
 		}
+		p.printFailures(kind, failed)
+	default:
+		metas, err := p.resourceMetas(client, kind)
+		failed = funktion.ListFailures(err)
+		if err != nil && failed == nil {
+			return err
+		}
+		if len(name) > 0 {
+			var found *v1.ObjectMeta
+			for _, meta := range metas {
+				if meta.Name == name {
+					found = meta
+				}
+			}
+			if failure, ok := failed[name]; ok {
+				return failure
+			}
+			if found == nil {
+				return fmt.Errorf("%s \"%s\" not found", kind, name)
+			}
+			metas = []*v1.ObjectMeta{found}
+			failed = nil
+		}
+		p.printHeader(kind)
+		for _, meta := range metas {
+			printRuntimeRow(meta.Name, meta.Labels[funktion.VersionLabel])
+		}
+		p.printFailures(kind, failed)
 	}
 	return nil
 }
 
+// resourceMetas returns the metadata of the Runtimes or Connectors along with the error for those
+// which could not be converted
+func (p *getCmd) resourceMetas(client *funktion.Client, kind string) ([]*v1.ObjectMeta, error) {
+	answer := []*v1.ObjectMeta{}
+	if kind == runtimeKind {
+		list, err := client.Runtimes(p.namespace).List(api.ListOptions{})
+		if err != nil && funktion.ListFailures(err) == nil {
+			return nil, err
+		}
+		for _, runtime := range list.Items {
+			answer = append(answer, &runtime.ObjectMeta)
+		}
+		return answer, err
+	}
+	list, err := client.Connectors(p.namespace).List(api.ListOptions{})
+	if err != nil && funktion.ListFailures(err) == nil {
+		return nil, err
+	}
+	for _, connector := range list.Items {
+		answer = append(answer, &connector.ObjectMeta)
+	}
+	return answer, err
+}
+
 func (p *getCmd) printHeader(kind string) {
 	switch kind {
 	case flowKind:
-		printFlowRow("NAME", "PODS", "STEPS")
+		printFlowRow("NAME", "PODS", "STATUS", "STEPS")
 	case functionKind:
-		printFunctionRow("NAME", "PODS", "URL")
+		printFunctionRow("NAME", "PODS", "STATUS", "URL")
 	default:
 		printRuntimeRow("NAME", "VERSION")
 	}
 }
 
-func (p *getCmd) printResource(cm *v1.ConfigMap, kind string) {
-	switch kind {
-	case functionKind:
-		printFunctionRow(cm.Name, p.podText(cm), p.functionURLText(cm))
-	case flowKind:
-		printFlowRow(cm.Name, p.podText(cm), p.flowStepsText(cm))
-	default:
-		printRuntimeRow(cm.Name, p.runtimeVersion(cm))
+func (p *getCmd) printFunction(function *spec.Function) {
+	status := objectStatus(&function.ObjectMeta)
+	printFunctionRow(function.Name, p.podText(function.Name, status), statusText(status), p.functionURLText(function.Name, status))
+}
+
+func (p *getCmd) printFlow(flow *spec.Flow) {
+	status := objectStatus(&flow.ObjectMeta)
+	printFlowRow(flow.Name, p.podText(flow.Name, status), statusText(status), p.flowStepsText(flow, status))
+}
+
+// printFailures prints a row with the error for each resource which could not be converted
+func (p *getCmd) printFailures(kind string, failed map[string]error) {
+	names := []string{}
+	for name := range failed {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	for _, name := range names {
+		message := failed[name].Error()
+		switch kind {
+		case flowKind:
+			printFlowRow(name, p.podText(name, nil), funktion.FailedPhase, message)
+		case functionKind:
+			printFunctionRow(name, p.podText(name, nil), funktion.FailedPhase, message)
+		default:
+			printRuntimeRow(name, message)
+		}
+	}
+}
+
+// objectStatus returns the status written by the operator or the error parsing it
+func objectStatus(meta *v1.ObjectMeta) *funktion.Status {
+	status, err := funktion.GetObjectStatus(meta)
+	if err != nil {
+		status = &funktion.Status{
+			LastError: err.Error(),
+		}
+	}
+	return status
 }
 
-func printFunctionRow(name string, pod string, flow string) {
-	fmt.Printf("%-32s %-9s %s\n", name, pod, flow)
+func printFunctionRow(name string, pod string, status string, url string) {
+	fmt.Printf("%-32s %-9s %-9s %s\n", name, pod, status, url)
 }
 
-func printFlowRow(name string, pod string, flow string) {
-	fmt.Printf("%-32s %-9s %s\n", name, pod, flow)
+func printFlowRow(name string, pod string, status string, flow string) {
+	fmt.Printf("%-32s %-9s %-9s %s\n", name, pod, status, flow)
 }
 
 func printRuntimeRow(name string, version string) {
 	fmt.Printf("%-32s %s\n", name, version)
 }
 
-func (p *getCmd) podText(cm *v1.ConfigMap) string {
-	name := cm.Name
+func (p *getCmd) podText(name string, status *funktion.Status) string {
 	deployment := p.deployments[name]
 	if deployment == nil {
+		if status != nil && len(status.Deployment) > 0 {
+			return fmt.Sprintf("%d/%d", status.ReadyReplicas, status.Replicas)
+		}
 		return ""
 	}
-	var status = deployment.Status
-	return fmt.Sprintf("%d/%d", status.AvailableReplicas, status.Replicas)
+	var deploymentStatus = deployment.Status
+	return fmt.Sprintf("%d/%d", deploymentStatus.AvailableReplicas, deploymentStatus.Replicas)
 }
 
-func (p *getCmd) functionURLText(cm *v1.ConfigMap) string {
-	name := cm.Name
-	service := p.services[name]
-	if service == nil || service.Annotations == nil {
+// statusText returns the phase of the resource as reported by the operator
+func statusText(status *funktion.Status) string {
+	if status == nil {
+		return "Unknown"
+	}
+	if len(status.Phase) == 0 && len(status.LastError) > 0 {
+		return funktion.FailedPhase
+	}
+	return status.Phase
+}
+
+// errorText returns the reason the resource could not be reconciled if it failed
+func errorText(status *funktion.Status) string {
+	if status == nil || len(status.LastError) == 0 {
+		return ""
+	}
+	if len(status.Phase) > 0 && status.Phase != funktion.FailedPhase {
 		return ""
 	}
-	return service.Annotations[exposeURLAnnotation]
+	return status.LastError
 }
 
-func (p *getCmd) runtimeVersion(cm *v1.ConfigMap) string {
-	labels := cm.Labels
-	if labels != nil {
-		return labels[funktion.VersionLabel]
+func (p *getCmd) functionURLText(name string, status *funktion.Status) string {
+	if text := errorText(status); len(text) > 0 {
+		return text
 	}
-	return ""
+	service := p.services[name]
+	if service == nil || service.Annotations == nil {
+		if status != nil {
+			return status.URL
+		}
+		return ""
+	}
+	return service.Annotations[funktion.ExposeURLAnnotation]
 }
 
-func (p *getCmd) flowStepsText(cm *v1.ConfigMap) string {
-	yamlText := cm.Data[funktion.FunktionYmlProperty]
-	if len(yamlText) == 0 {
-		return fmt.Sprintf("No `%s` property specified", funktion.FunktionYmlProperty)
+func (p *getCmd) flowStepsText(flow *spec.Flow, status *funktion.Status) string {
+	if text := errorText(status); len(text) > 0 {
+		return text
 	}
-	fc := spec.FunkionConfig{}
-	err := yaml.Unmarshal([]byte(yamlText), &fc)
-	if err != nil {
-		return fmt.Sprintf("Failed to parse `%s` YAML: %v", funktion.FunktionYmlProperty, err)
+	fc := flow.Spec.Funktion
+	if fc == nil {
+		return fmt.Sprintf("No `%s` property specified", funktion.FunktionYmlProperty)
 	}
 	if len(fc.Flows) == 0 {
 		return "No funktion flows"
@@ -32,6 +32,7 @@ import (
 
 	"k8s.io/client-go/1.5/dynamic"
 	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api"
 	"k8s.io/client-go/1.5/pkg/api/v1"
 	"k8s.io/client-go/1.5/pkg/runtime"
 )
@@ -241,12 +242,12 @@ func (p *installConnectorCmd) installConnectors(uri string, version string) erro
 	if err != nil {
 		return err
 	}
-	listOpts, err := funktion.CreateConnectorListOptions()
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return err
 	}
-	cms := p.kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	connectors := client.Connectors(p.namespace)
+	resources, err := connectors.List(api.ListOptions{})
 	if err != nil {
 		return err
 	}
@@ -293,11 +294,15 @@ func (p *installConnectorCmd) installConnectors(uri string, version string) erro
 				}
 			}
 
+			connector, err := funktion.ConfigMapToConnector(cm)
+			if err != nil {
+				return fmt.Errorf("Failed to %s Connector %s due to %v", operation, name, err)
+			}
 			if update {
 				operation = "update"
-				_, err = cms.Update(cm)
+				_, err = connectors.Update(connector)
 			} else {
-				_, err = cms.Create(cm)
+				_, err = connectors.Create(connector)
 			}
 			if err != nil {
 				return fmt.Errorf("Failed to %s Connector %s due to %v", operation, name, err)
@@ -338,12 +343,12 @@ func (p *installRuntimeCmd) installRuntimes(uri string, version string) error {
 	if err != nil {
 		return err
 	}
-	listOpts, err := funktion.CreateRuntimeListOptions()
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
 	if err != nil {
 		return err
 	}
-	cms := p.kubeclient.ConfigMaps(p.namespace)
-	resources, err := cms.List(*listOpts)
+	runtimes := client.Runtimes(p.namespace)
+	resources, err := runtimes.List(api.ListOptions{})
 	if err != nil {
 		return err
 	}
@@ -370,11 +375,15 @@ func (p *installRuntimeCmd) installRuntimes(uri string, version string) error {
 			}
 		}
 
+		runtime, err := funktion.ConfigMapToRuntime(cm)
+		if err != nil {
+			return fmt.Errorf("Failed to %s Runtime %s due to %v", operation, name, err)
+		}
 		if update {
 			operation = "update"
-			_, err = cms.Update(cm)
+			_, err = runtimes.Update(runtime)
 		} else {
-			_, err = cms.Create(cm)
+			_, err = runtimes.Create(runtime)
 		}
 		if err != nil {
 			return fmt.Errorf("Failed to %s Runtime %s due to %v", operation, name, err)
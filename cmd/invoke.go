@@ -0,0 +1,342 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/rest"
+)
+
+type invokeCmd struct {
+	kubeclient     *kubernetes.Clientset
+	cmd            *cobra.Command
+	kubeConfigPath string
+
+	namespace   string
+	name        string
+	data        string
+	file        string
+	method      string
+	headers     []string
+	proxy       bool
+	timeout     time.Duration
+	repeat      int
+	concurrency int
+}
+
+// invocationResult is the outcome of one request of a load check
+type invocationResult struct {
+	status  int
+	latency time.Duration
+	err     error
+}
+
+func init() {
+	RootCmd.AddCommand(newInvokeCmd())
+}
+
+func newInvokeCmd() *cobra.Command {
+	p := &invokeCmd{}
+	cmd := &cobra.Command{
+		Use:   "invoke fn NAME [flags]",
+		Short: "invokes a function and displays its response",
+		Long: `This command calls a function through its exposed URL or, if it is not exposed, through the service proxy of the API server.
+
+The status, headers and body of the response are displayed. With --repeat the function is called many times and
+the number of responses with each status and the latency percentiles are displayed instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			if err := p.parseArgs(args); err != nil {
+				handleError(err)
+				return
+			}
+			handleError(p.run())
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace of the function")
+	f.StringVarP(&p.data, "data", "d", "", "the body of the request")
+	f.StringVarP(&p.file, "file", "f", "", "the file containing the body of the request")
+	f.StringVarP(&p.method, "method", "X", "", "the HTTP method of the request. Defaults to POST if there is a body and GET otherwise")
+	f.StringArrayVarP(&p.headers, "header", "H", []string{}, "one or more headers of the request using the form NAME:VALUE")
+	f.BoolVar(&p.proxy, "proxy", false, "whether to call the function through the API server even if it is exposed")
+	f.DurationVar(&p.timeout, "timeout", time.Minute, "how long to wait for each response")
+	f.IntVar(&p.repeat, "repeat", 1, "the number of times to invoke the function")
+	f.IntVar(&p.concurrency, "concurrency", 1, "the number of requests to make at the same time when repeating")
+	return cmd
+}
+
+func (p *invokeCmd) parseArgs(args []string) error {
+	if len(args) < 2 {
+		return usageError(p.cmd, "Expected the arguments `fn NAME`")
+	}
+	kind, _, err := listOptsForKind(args[0])
+	if err != nil {
+		return err
+	}
+	if kind != functionKind {
+		return usageError(p.cmd, "Only functions can be invoked but was given kind `%s`", args[0])
+	}
+	p.name = args[1]
+	if len(p.data) > 0 && len(p.file) > 0 {
+		return usageError(p.cmd, "Only one of --data and --file can be specified")
+	}
+	if p.repeat < 1 || p.concurrency < 1 {
+		return usageError(p.cmd, "The --repeat and --concurrency flags must be at least 1")
+	}
+	return createKubernetesClient(p.cmd, p.kubeConfigPath, &p.kubeclient, &p.namespace)
+}
+
+func (p *invokeCmd) run() error {
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Functions(p.namespace).Get(p.name); err != nil {
+		return notFoundError(functionKind, p.name, err)
+	}
+	body := []byte(p.data)
+	if len(p.file) > 0 {
+		data, err := ioutil.ReadFile(p.file)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+	method := strings.ToUpper(p.method)
+	if len(method) == 0 {
+		method = "GET"
+		if len(body) > 0 {
+			method = "POST"
+		}
+	}
+	headers, err := parseHeaderArgs(p.headers)
+	if err != nil {
+		return err
+	}
+	url, client, err := p.functionURL()
+	if err != nil {
+		return err
+	}
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range headers {
+			req.Header[k] = v
+		}
+		if host := headers.Get("Host"); len(host) > 0 {
+			req.Host = host
+		}
+		return req, nil
+	}
+	if p.repeat == 1 {
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return printResponse(resp)
+	}
+	return p.loadCheck(client, newRequest)
+}
+
+// functionURL returns the URL to invoke the function on along with the client to use. Functions
+// which are not exposed are called through the service proxy of the API server.
+func (p *invokeCmd) functionURL() (string, *http.Client, error) {
+	svc, err := p.kubeclient.Services(p.namespace).Get(p.name)
+	if err != nil {
+		return "", nil, fmt.Errorf("No service for function %s: %v", p.name, err)
+	}
+	if url := svc.Annotations[funktion.ExposeURLAnnotation]; len(url) > 0 && !p.proxy {
+		return url, &http.Client{Timeout: p.timeout}, nil
+	}
+	if len(svc.Spec.Ports) == 0 {
+		return "", nil, fmt.Errorf("Service %s has no ports", svc.Name)
+	}
+	cfg, err := createKubernetesClientConfig(p.kubeConfigPath)
+	if err != nil {
+		return "", nil, err
+	}
+	transport, err := rest.TransportFor(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	host := strings.TrimSuffix(cfg.Host, "/")
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	port := svc.Spec.Ports[0].Name
+	if len(port) == 0 {
+		port = fmt.Sprintf("%d", svc.Spec.Ports[0].Port)
+	}
+	url := fmt.Sprintf("%s/api/v1/namespaces/%s/services/%s:%s/proxy/", host, p.namespace, svc.Name, port)
+	return url, &http.Client{Transport: transport, Timeout: p.timeout}, nil
+}
+
+// parseHeaderArgs parses headers of the form NAME:VALUE
+func parseHeaderArgs(args []string) (http.Header, error) {
+	answer := http.Header{}
+	for _, arg := range args {
+		pair := strings.SplitN(arg, ":", 2)
+		if len(pair) != 2 || len(strings.TrimSpace(pair[0])) == 0 {
+			return nil, fmt.Errorf("Header does not have the form NAME:VALUE but was `%s`", arg)
+		}
+		answer.Add(strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1]))
+	}
+	return answer, nil
+}
+
+func printResponse(resp *http.Response) error {
+	fmt.Printf("%s %s\n", resp.Proto, resp.Status)
+	names := []string{}
+	for k := range resp.Header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		for _, v := range resp.Header[k] {
+			fmt.Printf("%s: %s\n", k, v)
+		}
+	}
+	fmt.Println()
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+// loadCheck invokes the function the given number of times and displays the statuses and latencies
+func (p *invokeCmd) loadCheck(client *http.Client, newRequest func() (*http.Request, error)) error {
+	jobs := make(chan struct{}, p.repeat)
+	for i := 0; i < p.repeat; i++ {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	results := make(chan invocationResult, p.repeat)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				results <- invokeOnce(client, newRequest)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+
+	statuses := map[int]int{}
+	errors := map[string]int{}
+	latencies := []time.Duration{}
+	for r := range results {
+		if r.err != nil {
+			errors[r.err.Error()]++
+			continue
+		}
+		statuses[r.status]++
+		latencies = append(latencies, r.latency)
+	}
+
+	fmt.Printf("Invoked function %s %d times with %d concurrent requests in %s (%.1f requests/second)\n\n",
+		p.name, p.repeat, p.concurrency, elapsed, float64(p.repeat)/elapsed.Seconds())
+	codes := []int{}
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	fmt.Println("STATUS  COUNT")
+	for _, code := range codes {
+		fmt.Printf("%-7d %d\n", code, statuses[code])
+	}
+	if len(errors) > 0 {
+		fmt.Println("\nERRORS")
+		for msg, count := range errors {
+			fmt.Printf("%d x %s\n", count, msg)
+		}
+	}
+	if len(latencies) > 0 {
+		sort.Sort(durations(latencies))
+		fmt.Println("\nLATENCY")
+		fmt.Printf("min  %s\n", latencies[0])
+		for _, pc := range []float64{50, 90, 95, 99} {
+			fmt.Printf("p%-3g %s\n", pc, percentile(latencies, pc))
+		}
+		fmt.Printf("max  %s\n", latencies[len(latencies)-1])
+	}
+	return nil
+}
+
+func invokeOnce(client *http.Client, newRequest func() (*http.Request, error)) invocationResult {
+	req, err := newRequest()
+	if err != nil {
+		return invocationResult{err: err}
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return invocationResult{err: err}
+	}
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return invocationResult{status: resp.StatusCode, latency: time.Since(start), err: err}
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+// percentile returns the nearest-rank percentile of the sorted latencies
+func percentile(sorted []time.Duration, pc float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(pc/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
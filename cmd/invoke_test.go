@@ -0,0 +1,43 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	latencies := []time.Duration{}
+	for i := 1; i <= 10; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assertEquals(t, percentile(latencies, 50).String(), "5ms")
+	assertEquals(t, percentile(latencies, 90).String(), "9ms")
+	assertEquals(t, percentile(latencies, 99).String(), "10ms")
+	assertEquals(t, percentile(latencies[:1], 50).String(), "1ms")
+}
+
+func TestParseHeaderArgs(t *testing.T) {
+	headers, err := parseHeaderArgs([]string{"Content-Type: application/json", "X-API-Key:abc:def"})
+	if err != nil {
+		t.Fatalf("Failed to parse the headers: %v", err)
+	}
+	assertEquals(t, headers.Get("Content-Type"), "application/json")
+	assertEquals(t, headers.Get("X-Api-Key"), "abc:def")
+	if _, err := parseHeaderArgs([]string{"no-colon"}); err == nil {
+		t.Errorf("Expected an error parsing a header without a colon")
+	}
+}
@@ -17,15 +17,14 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/funktionio/funktion/pkg/k8sutil"
 	"github.com/spf13/cobra"
 
 	"k8s.io/client-go/1.5/kubernetes"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
 	"k8s.io/client-go/1.5/pkg/api/v1"
 	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 )
@@ -40,8 +39,17 @@ type logCmd struct {
 	name      string
 	follow    bool
 
+	since      time.Duration
+	tail       int64
+	timestamps bool
+	container  string
+	previous   bool
+
 	podAction k8sutil.PodAction
-	logCmd    *exec.Cmd
+	// stopLog stops streaming the log of the previous pod
+	stopLog   chan struct{}
+	lastPod   string
+	stoppedAt unversioned.Time
 }
 
 func init() {
@@ -56,7 +64,10 @@ func newLogCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "logs KIND NAME [flags]",
 		Short: "tails the log of the given function or flow",
-		Long:  `This command will tail the log of the latest container implementing the function or flow`,
+		Long: `This command will tail the log of the latest container implementing the function or flow.
+
+The log is streamed through the API server so kubectl is not required. When following the log, the log of
+the new pod is streamed whenever the pods of the function or flow are replaced or restarted.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			p.cmd = cmd
 			if len(args) < 1 {
@@ -87,6 +98,11 @@ func newLogCmd() *cobra.Command {
 	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace to query")
 	f.StringVarP(&p.name, "name", "v", "latest", "the version of the connectors to install")
 	f.BoolVarP(&p.follow, "follow", "f", true, "Whether or not to follow the log")
+	f.DurationVar(&p.since, "since", 0, "only show the log newer than a relative duration such as 5s, 2m or 3h")
+	f.Int64Var(&p.tail, "tail", -1, "the number of recent lines of the log to show. Defaults to all of the log")
+	f.BoolVar(&p.timestamps, "timestamps", false, "whether to include a timestamp on each line of the log")
+	f.StringVarP(&p.container, "container", "c", "", "the container of the pod to show the log of. Defaults to the first container")
+	f.BoolVarP(&p.previous, "previous", "p", false, "show the log of the previous instance of the container if it has been restarted")
 	return cmd
 }
 
@@ -121,38 +137,75 @@ func (p *logCmd) run() error {
 	if err != nil {
 		return err
 	}
-	p.podAction.WatchPods(p.kubeclient, p.namespace, listOpts)
-	return p.podAction.WatchLoop()
-}
-
-func (p *logCmd) viewLog(pod *v1.Pod) error {
-	if pod != nil {
-		binaryFile, err := k8sutil.ResolveKubectlBinary(p.kubeclient)
+	if !p.follow || p.previous {
+		// lets show the log of the latest pod even if it is not ready such as when it is crashing
+		pods, err := kubeclient.Pods(p.namespace).List(*listOpts)
 		if err != nil {
 			return err
 		}
-		name := pod.Name
-		if p.logCmd != nil {
-			process := p.logCmd.Process
-			if process != nil {
-				process.Kill()
-			}
-		}
-		args := []string{"logs"}
-		if p.follow {
-			args = append(args, "-f")
+		pod := k8sutil.LatestPod(pods.Items)
+		if pod == nil {
+			return fmt.Errorf("No pods found for Deployment `%s`", name)
 		}
-		args = append(args, name)
-
-		fmt.Printf("\n%s %s\n\n", filepath.Base(binaryFile), strings.Join(args, " "))
-		cmd := exec.Command(binaryFile, args...)
-		p.logCmd = cmd
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err = cmd.Start()
-		if err != nil {
-			return err
+		return k8sutil.StreamPodLog(p.kubeclient, pod, p.logOptions(false), os.Stdout, nil)
+	}
+	p.podAction.WatchPods(p.kubeclient, p.namespace, listOpts)
+	return p.podAction.WatchLoop()
+}
+
+func (p *logCmd) logOptions(follow bool) *v1.PodLogOptions {
+	opts := &v1.PodLogOptions{
+		Container:  p.container,
+		Follow:     follow,
+		Previous:   p.previous,
+		Timestamps: p.timestamps,
+	}
+	if p.since > 0 {
+		seconds := int64(p.since.Seconds())
+		if seconds < 1 {
+			seconds = 1
 		}
+		opts.SinceSeconds = &seconds
+	}
+	if p.tail >= 0 {
+		tail := p.tail
+		opts.TailLines = &tail
+	}
+	return opts
+}
+
+func (p *logCmd) viewLog(pod *v1.Pod) error {
+	if p.stopLog != nil {
+		close(p.stopLog)
+		p.stopLog = nil
+		p.stoppedAt = unversioned.Now()
+	}
+	if pod == nil {
+		return nil
 	}
+	stopc := make(chan struct{})
+	p.stopLog = stopc
+	opts := p.followLogOptions(pod)
+
+	fmt.Printf("\nlogs of pod %s\n\n", pod.Name)
+	go func() {
+		if err := k8sutil.StreamPodLog(p.kubeclient, pod, opts, os.Stdout, stopc); err != nil {
+			fmt.Printf("Failed to stream the log of pod %s: %v\n", pod.Name, err)
+		}
+	}()
 	return nil
 }
+
+// followLogOptions returns the options to follow the log of the given pod. If the log of the same
+// pod was shown before then only the log written since it was stopped is shown
+func (p *logCmd) followLogOptions(pod *v1.Pod) *v1.PodLogOptions {
+	opts := p.logOptions(true)
+	if pod.Name == p.lastPod && opts.SinceSeconds == nil {
+		// lets not repeat the log already shown when the same pod becomes ready again
+		since := p.stoppedAt
+		opts.SinceTime = &since
+		opts.TailLines = nil
+	}
+	p.lastPod = pod.Name
+	return opts
+}
@@ -0,0 +1,100 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestLogOptions(t *testing.T) {
+	p := &logCmd{tail: -1}
+	opts := p.logOptions(false)
+	assertEquals(t, fmt.Sprint(opts.Follow), "false")
+	if opts.SinceSeconds != nil {
+		t.Errorf("Expected no SinceSeconds but got %d", *opts.SinceSeconds)
+	}
+	if opts.TailLines != nil {
+		t.Errorf("Expected no TailLines but got %d", *opts.TailLines)
+	}
+
+	p = &logCmd{
+		since:      2 * time.Minute,
+		tail:       20,
+		timestamps: true,
+		container:  "sidecar",
+		previous:   true,
+	}
+	opts = p.logOptions(true)
+	assertEquals(t, fmt.Sprint(opts.Follow), "true")
+	assertEquals(t, fmt.Sprint(opts.Previous), "true")
+	assertEquals(t, fmt.Sprint(opts.Timestamps), "true")
+	assertEquals(t, opts.Container, "sidecar")
+	assertEquals(t, int64String(opts.SinceSeconds), "120")
+	assertEquals(t, int64String(opts.TailLines), "20")
+
+	// a duration of less than a second still limits the log
+	p = &logCmd{since: 300 * time.Millisecond, tail: 0}
+	opts = p.logOptions(false)
+	assertEquals(t, int64String(opts.SinceSeconds), "1")
+	assertEquals(t, int64String(opts.TailLines), "0")
+}
+
+func TestFollowLogOptionsResumesTheSamePod(t *testing.T) {
+	first := &v1.Pod{ObjectMeta: v1.ObjectMeta{Name: "hello-1"}}
+	second := &v1.Pod{ObjectMeta: v1.ObjectMeta{Name: "hello-2"}}
+	p := &logCmd{tail: 10}
+
+	opts := p.followLogOptions(first)
+	if opts.SinceTime != nil {
+		t.Errorf("Expected no SinceTime for a new pod but got %v", opts.SinceTime)
+	}
+	assertEquals(t, int64String(opts.TailLines), "10")
+
+	stoppedAt := unversioned.NewTime(time.Now().Add(-time.Minute))
+	p.stoppedAt = stoppedAt
+	opts = p.followLogOptions(first)
+	if opts.SinceTime == nil || !opts.SinceTime.Time.Equal(stoppedAt.Time) {
+		t.Errorf("Expected SinceTime %v but got %v", stoppedAt, opts.SinceTime)
+	}
+	if opts.TailLines != nil {
+		t.Errorf("Expected no TailLines when resuming but got %d", *opts.TailLines)
+	}
+
+	opts = p.followLogOptions(second)
+	if opts.SinceTime != nil {
+		t.Errorf("Expected no SinceTime for a new pod but got %v", opts.SinceTime)
+	}
+	assertEquals(t, int64String(opts.TailLines), "10")
+
+	// an explicit --since is used rather than resuming
+	p = &logCmd{since: time.Minute, tail: -1, lastPod: first.Name, stoppedAt: stoppedAt}
+	opts = p.followLogOptions(first)
+	if opts.SinceTime != nil {
+		t.Errorf("Expected no SinceTime with --since but got %v", opts.SinceTime)
+	}
+	assertEquals(t, int64String(opts.SinceSeconds), "60")
+}
+
+func int64String(value *int64) string {
+	if value == nil {
+		return "<nil>"
+	}
+	return fmt.Sprint(*value)
+}
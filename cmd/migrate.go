@@ -0,0 +1,162 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	funktionclient "github.com/funktionio/funktion/pkg/client"
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+type migrateCmd struct {
+	kubeclient     *kubernetes.Clientset
+	funktionclient *funktionclient.Clientset
+	cmd            *cobra.Command
+	kubeConfigPath string
+
+	namespace string
+	dryRun    bool
+}
+
+func init() {
+	RootCmd.AddCommand(newMigrateCmd())
+}
+
+func newMigrateCmd() *cobra.Command {
+	p := &migrateCmd{}
+	cmd := &cobra.Command{
+		Use:   "migrate [flags]",
+		Short: "migrates the Functions, Flows, Runtimes and Connectors stored in ConfigMaps to typed resources",
+		Long: `This command creates a typed Function, Flow, Runtime or Connector resource for each ConfigMap created by older versions of funktion.
+
+The ConfigMaps are kept and from then on are maintained by the operator from the typed resources.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			err := createKubernetesClient(cmd, p.kubeConfigPath, &p.kubeclient, &p.namespace)
+			if err != nil {
+				handleError(err)
+				return
+			}
+			p.funktionclient, err = createFunktionClient(p.kubeConfigPath)
+			if err != nil {
+				handleError(err)
+				return
+			}
+			handleError(p.run())
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace to migrate")
+	f.BoolVar(&p.dryRun, "dry-run", false, "only list the resources which would be migrated")
+	return cmd
+}
+
+func (p *migrateCmd) run() error {
+	if !p.dryRun {
+		err := funktion.CreateThirdPartyResources(p.kubeclient, p.funktionclient.RESTClient())
+		if err != nil {
+			return err
+		}
+	}
+	// connectors and runtimes first so that flows and functions can be reconciled straight away
+	for _, kind := range []string{connectorKind, runtimeKind, flowKind, functionKind} {
+		_, listOpts, err := listOptsForKind(kind)
+		if err != nil {
+			return err
+		}
+		resources, err := p.kubeclient.ConfigMaps(p.namespace).List(*listOpts)
+		if err != nil {
+			return err
+		}
+		count := 0
+		for _, resource := range resources.Items {
+			if resource.Annotations[funktion.TypedResourceAnnotation] == "true" {
+				continue
+			}
+			migrated, err := p.migrateResource(kind, &resource)
+			if err != nil {
+				return fmt.Errorf("Failed to migrate %s \"%s\" due to: %v", kind, resource.Name, err)
+			}
+			if migrated {
+				count++
+			}
+		}
+		if p.dryRun {
+			fmt.Printf("Would migrate %d %s resource(s)\n", count, kind)
+		} else {
+			fmt.Printf("Migrated %d %s resource(s)\n", count, kind)
+		}
+	}
+	return nil
+}
+
+// migrateResource creates the typed resource for the given ConfigMap returning false if it already exists
+func (p *migrateCmd) migrateResource(kind string, cm *v1.ConfigMap) (bool, error) {
+	ns := cm.Namespace
+	if len(ns) == 0 {
+		ns = p.namespace
+	}
+	var err error
+	switch kind {
+	case connectorKind:
+		connector, e := funktion.ConfigMapToConnector(cm)
+		if e != nil || p.dryRun {
+			return e == nil, e
+		}
+		_, err = p.funktionclient.Connectors(ns).Create(connector)
+	case runtimeKind:
+		runtime, e := funktion.ConfigMapToRuntime(cm)
+		if e != nil || p.dryRun {
+			return e == nil, e
+		}
+		_, err = p.funktionclient.Runtimes(ns).Create(runtime)
+	case flowKind:
+		flow, e := funktion.ConfigMapToFlow(cm)
+		if e != nil || p.dryRun {
+			return e == nil, e
+		}
+		_, err = p.funktionclient.Flows(ns).Create(flow)
+	case functionKind:
+		function, e := funktion.ConfigMapToFunction(cm)
+		if e != nil || p.dryRun {
+			return e == nil, e
+		}
+		_, err = p.funktionclient.Functions(ns).Create(function)
+	default:
+		return false, fmt.Errorf("Unknown kind `%s`", kind)
+	}
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// lets mark the ConfigMap as rendered from the typed resource straight away so that the CLI
+	// changes the typed resource from now on
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[funktion.TypedResourceAnnotation] = "true"
+	if _, err = p.kubeclient.ConfigMaps(ns).Update(cm); err != nil {
+		return true, fmt.Errorf("Failed to mark ConfigMap %s as migrated: %v", cm.Name, err)
+	}
+	return true, nil
+}
@@ -16,17 +16,24 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/funktionio/funktion/pkg/analytics"
 	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/funktionio/funktion/pkg/leaderelection"
 	"github.com/go-kit/kit/log"
 
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
+	"k8s.io/client-go/1.5/kubernetes"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/rest"
 	"k8s.io/client-go/1.5/tools/clientcmd"
 )
 
@@ -37,6 +44,27 @@ func init() {
 type operateCmd struct {
 	namespace     string
 	allNamespaces bool
+	workers       int
+	listenAddress string
+	telemetry     string
+
+	webhookAddress string
+	tlsCertFile    string
+	tlsKeyFile     string
+
+	leaderElect   bool
+	lockName      string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+
+	expose       string
+	exposeDomain string
+	exposeHost   string
+	exposePath   string
+
+	gatewayImage          string
+	gatewayServiceAccount string
 }
 
 func newOperateCmd() *cobra.Command {
@@ -50,13 +78,37 @@ func newOperateCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newActivatorCmd())
+	cmd.AddCommand(newGatewayCmd())
+
 	f := cmd.Flags()
 	f.StringVarP(&p.namespace, "namespace", "n", "", "the name of the namespace to watch for resources")
 	f.BoolVarP(&p.allNamespaces, "all", "a", false, "if enabled all namespaces will be watched. This option typically requires a cluster administrator role")
+	f.IntVar(&p.workers, "workers", 1, "the number of resources to reconcile concurrently")
+	f.StringVar(&p.listenAddress, "listen-address", ":8080", "the address to serve /metrics, /healthz and /readyz on. An empty address disables the endpoints")
+	f.StringVar(&p.telemetry, "telemetry", "", fmt.Sprintf("where to send telemetry events: one of %s. Defaults to $%s or %s which disables telemetry if Google Analytics can not be reached", strings.Join(analytics.SinkNames, ", "), analytics.TelemetryEnvVar, analytics.AutoSink))
+	f.StringVar(&p.webhookAddress, "webhook-listen-address", ":8443", "the address to serve the validating admission webhook on over HTTPS")
+	f.StringVar(&p.tlsCertFile, "tls-cert-file", "", "the TLS certificate of the admission webhook. The webhook is only served if a certificate and private key are specified")
+	f.StringVar(&p.tlsKeyFile, "tls-private-key-file", "", "the TLS private key of the admission webhook")
+	f.BoolVar(&p.leaderElect, "leader-elect", false, "if enabled only the elected leader of the running operators reconciles resources so that several replicas can be run for availability. Requires permission to get, create and update the lock ConfigMap")
+	f.StringVar(&p.lockName, "lock-name", "funktion-operator", "the name of the ConfigMap used as the leader election lock")
+	f.DurationVar(&p.leaseDuration, "lease-duration", leaderelection.DefaultLeaseDuration, "how long a standby operator waits before taking over from a leader which stopped renewing its lease")
+	f.DurationVar(&p.renewDeadline, "renew-deadline", leaderelection.DefaultRenewDeadline, "how long the leader retries renewing its lease before it stops reconciling")
+	f.DurationVar(&p.retryPeriod, "retry-period", leaderelection.DefaultRetryPeriod, "how long to wait between attempts to acquire or renew the leader lease")
+	f.StringVar(&p.expose, "expose", funktion.ExposeNone, fmt.Sprintf("how to expose the services of functions: one of %s. With %s the exposecontroller exposes them", strings.Join(funktion.ExposeModes, ", "), funktion.ExposeNone))
+	f.StringVar(&p.exposeDomain, "expose-domain", "", "the DNS domain which resolves to the ingress controller or router such as 192.168.99.100.nip.io")
+	f.StringVar(&p.exposeHost, "expose-host", funktion.DefaultExposeHostTemplate, "the template of the host of an exposed function given its .Name, .Namespace and the .Domain")
+	f.StringVar(&p.exposePath, "expose-path", funktion.DefaultExposePathTemplate, "the template of the path of an exposed function given its .Name, .Namespace and the .Domain")
+	f.StringVar(&p.gatewayImage, "gateway-image", "", "the image of the gateway which routes /fn/NAME to each function. If specified the operator creates a gateway deployment and service in the namespace it watches, so it can not be combined with --all")
+	f.StringVar(&p.gatewayServiceAccount, "gateway-service-account", "", "the service account of the gateway which must be able to watch configmaps and services and read secrets")
 	return cmd
 }
 
 func (p *operateCmd) operate(cmd *cobra.Command, args []string) error {
+	if p.allNamespaces && len(p.gatewayImage) > 0 {
+		return usageError(cmd, "The --gateway-image flag can not be combined with --all as the gateway is created in the single namespace the operator watches")
+	}
+
 	logger := log.NewContext(log.NewLogfmtLogger(os.Stdout)).
 		With("ts", log.DefaultTimestampUTC, "caller", log.DefaultCaller).
 		With("operator", "funktion")
@@ -72,6 +124,8 @@ func (p *operateCmd) operate(cmd *cobra.Command, args []string) error {
 
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
 
+	// lets include the command's own flags so that parsing does not fail on them
+	flagset.AddFlagSet(cmd.Flags())
 	flagset.Parse(os.Args[1:])
 
 	cfg, err := kubeConfig.ClientConfig()
@@ -80,40 +134,108 @@ func (p *operateCmd) operate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// the namespace the operator runs in which also holds the leader election lock
+	currentNamespace := os.Getenv("KUBERNETES_NAMESPACE")
+	if len(currentNamespace) <= 0 {
+		currentNamespace, _, err = kubeConfig.Namespace()
+		if err != nil {
+			return fmt.Errorf("Could not detect namespace %v", err)
+		}
+	}
+
 	namespace := p.namespace
 	if p.allNamespaces {
 		namespace = api.NamespaceAll
 		fmt.Printf("Funktion operator is starting watching namespace: %s\n", namespace)
 	} else {
 		if len(namespace) == 0 {
-			namespace = os.Getenv("KUBERNETES_NAMESPACE")
+			namespace = currentNamespace
 			if len(namespace) <= 0 {
-				namespace, _, err := kubeConfig.Namespace()
-				if err != nil {
-					return fmt.Errorf("Could not detect namespace %v", err)
-				}
-				if len(namespace) <= 0 {
-					return fmt.Errorf("No namespace argument or $KUBERNETES_NAMESPACE environment variable specified and we could not detect the current namespace!")
-				}
+				return fmt.Errorf("No namespace argument or $KUBERNETES_NAMESPACE environment variable specified and we could not detect the current namespace!")
 			}
 		}
 		fmt.Printf("Funktion operator is starting watching namespace: '%s'\n", namespace)
 	}
+	if p.workers < 1 {
+		return fmt.Errorf("The number of workers must be at least 1 but was %d", p.workers)
+	}
 	ko, err := funktion.New(cfg, logger, namespace)
 	if err != nil {
 		logger.Log("error", err)
 		return err
 	}
+	err = ko.SetExposeOptions(funktion.ExposeOptions{
+		Mode:         p.expose,
+		Domain:       p.exposeDomain,
+		HostTemplate: p.exposeHost,
+		PathTemplate: p.exposePath,
+	})
+	if err != nil {
+		return err
+	}
+	err = ko.SetGatewayOptions(funktion.GatewayOptions{
+		Image:          p.gatewayImage,
+		ServiceAccount: p.gatewayServiceAccount,
+	})
+	if err != nil {
+		return err
+	}
 
 	stopc := make(chan struct{})
-	errc := make(chan error)
+	errc := make(chan error, 1)
 	var wg sync.WaitGroup
 
-	wg.Add(1)
-	go func() {
-		if err := ko.Run(stopc); err != nil {
+	telemetry := p.telemetry
+	if len(telemetry) == 0 {
+		telemetry = os.Getenv(analytics.TelemetryEnvVar)
+	}
+	if len(telemetry) == 0 {
+		telemetry = analytics.AutoSink
+	}
+	sink, err := analytics.NewSink(telemetry, logger, ko.MetricsRegistry())
+	if err != nil {
+		return err
+	}
+	analytics.SetSink(sink)
+
+	run := func(stop <-chan struct{}) {
+		if err := ko.Run(p.workers, stop); err != nil {
 			errc <- err
 		}
+	}
+	if p.leaderElect {
+		elector, err := p.createLeaderElector(cfg, logger, currentNamespace, run, errc, stopc)
+		if err != nil {
+			logger.Log("msg", "failed to create leader elector", "error", err)
+			return err
+		}
+		// lets report standby replicas as ready on /readyz even though they do not run the informers
+		ko.SetElector(elector)
+		run = elector.Run
+	}
+
+	if len(p.listenAddress) > 0 {
+		go func() {
+			logger.Log("msg", "serving metrics and health checks", "address", p.listenAddress)
+			if err := http.ListenAndServe(p.listenAddress, ko.Handler()); err != nil {
+				errc <- err
+			}
+		}()
+	}
+
+	if len(p.webhookAddress) > 0 && len(p.tlsCertFile) > 0 && len(p.tlsKeyFile) > 0 {
+		// all replicas serve the webhook so that it does not depend on the elected leader
+		go func() {
+			logger.Log("msg", "serving admission webhook", "address", p.webhookAddress, "path", funktion.ValidatePath)
+			if err := http.ListenAndServeTLS(p.webhookAddress, p.tlsCertFile, p.tlsKeyFile, ko.AdmissionHandler()); err != nil {
+				errc <- err
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		run(stopc)
 		wg.Done()
 	}()
 
@@ -133,3 +255,37 @@ func (p *operateCmd) operate(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+// createLeaderElector creates an elector which only runs the operator while it holds the lease
+func (p *operateCmd) createLeaderElector(cfg *rest.Config, logger log.Logger, namespace string, run func(<-chan struct{}), errc chan<- error, stopc <-chan struct{}) (*leaderelection.LeaderElector, error) {
+	if len(namespace) <= 0 {
+		return nil, fmt.Errorf("Could not detect the namespace for the leader election lock. Please specify $KUBERNETES_NAMESPACE")
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	return leaderelection.New(leaderelection.Config{
+		Client:           client,
+		Logger:           logger,
+		Namespace:        namespace,
+		Name:             p.lockName,
+		Identity:         fmt.Sprintf("%s_%d", identity, os.Getpid()),
+		LeaseDuration:    p.leaseDuration,
+		RenewDeadline:    p.renewDeadline,
+		RetryPeriod:      p.retryPeriod,
+		OnStartedLeading: run,
+		OnStoppedLeading: func() {
+			select {
+			case <-stopc:
+			default:
+				// lets exit so that we come back as a standby rather than reconcile alongside the new leader
+				errc <- fmt.Errorf("Lost the leader lease %s/%s", namespace, p.lockName)
+			}
+		},
+	})
+}
@@ -0,0 +1,114 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/1.5/pkg/api/resource"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+// podSettingsFlags are the flags which override the resources and scheduling of the pods of a function or flow
+type podSettingsFlags struct {
+	cpuRequest     string
+	memoryRequest  string
+	cpuLimit       string
+	memoryLimit    string
+	nodeSelector   []string
+	tolerations    []string
+	affinity       string
+	serviceAccount string
+}
+
+func (p *podSettingsFlags) addFlags(f *pflag.FlagSet) {
+	f.StringVar(&p.cpuRequest, "cpu-request", "", "the CPU to request for the container such as 100m")
+	f.StringVar(&p.memoryRequest, "memory-request", "", "the memory to request for the container such as 128Mi")
+	f.StringVar(&p.cpuLimit, "cpu-limit", "", "the CPU limit of the container such as 500m")
+	f.StringVar(&p.memoryLimit, "memory-limit", "", "the memory limit of the container such as 256Mi")
+	f.StringArrayVar(&p.nodeSelector, "node-selector", []string{}, "one or more node labels of the form NAME=VALUE to schedule the pods on")
+	f.StringArrayVar(&p.tolerations, "toleration", []string{}, "one or more taints of the form key=value:Effect which the pods tolerate")
+	f.StringVar(&p.affinity, "affinity", "", "the affinity of the pods as YAML or JSON")
+	f.StringVar(&p.serviceAccount, "service-account", "", "the service account to run the pods as")
+}
+
+// podSettings returns the pod settings of the flags or nil if none were given
+func (p *podSettingsFlags) podSettings() (*spec.PodSettings, error) {
+	settings := &spec.PodSettings{}
+	found := false
+	for _, q := range []struct {
+		flag     string
+		text     string
+		list     *v1.ResourceList
+		resource v1.ResourceName
+	}{
+		{"--cpu-request", p.cpuRequest, &settings.Resources.Requests, v1.ResourceCPU},
+		{"--memory-request", p.memoryRequest, &settings.Resources.Requests, v1.ResourceMemory},
+		{"--cpu-limit", p.cpuLimit, &settings.Resources.Limits, v1.ResourceCPU},
+		{"--memory-limit", p.memoryLimit, &settings.Resources.Limits, v1.ResourceMemory},
+	} {
+		if len(q.text) == 0 {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(q.text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s `%s`: %v", q.flag, q.text, err)
+		}
+		if *q.list == nil {
+			*q.list = v1.ResourceList{}
+		}
+		(*q.list)[q.resource] = quantity
+		found = true
+	}
+	for _, arg := range p.nodeSelector {
+		pair := strings.SplitN(arg, "=", 2)
+		if len(pair) != 2 || len(pair[0]) == 0 {
+			return nil, fmt.Errorf("Node selector does not have the form NAME=VALUE but was `%s`", arg)
+		}
+		if settings.NodeSelector == nil {
+			settings.NodeSelector = map[string]string{}
+		}
+		settings.NodeSelector[pair[0]] = pair[1]
+		found = true
+	}
+	for _, arg := range p.tolerations {
+		toleration, err := funktion.ParseToleration(arg)
+		if err != nil {
+			return nil, err
+		}
+		settings.Tolerations = append(settings.Tolerations, toleration)
+		found = true
+	}
+	if len(p.affinity) > 0 {
+		settings.Affinity = &v1.Affinity{}
+		if err := yaml.Unmarshal([]byte(p.affinity), settings.Affinity); err != nil {
+			return nil, fmt.Errorf("Failed to parse --affinity: %v", err)
+		}
+		found = true
+	}
+	if len(p.serviceAccount) > 0 {
+		settings.ServiceAccount = p.serviceAccount
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return settings, nil
+}
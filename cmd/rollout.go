@@ -0,0 +1,211 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/kubernetes"
+)
+
+type rolloutCmd struct {
+	kubeclient     *kubernetes.Clientset
+	cmd            *cobra.Command
+	kubeConfigPath string
+
+	namespace  string
+	name       string
+	toRevision int
+}
+
+func init() {
+	RootCmd.AddCommand(newRolloutCmd())
+}
+
+func newRolloutCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "manages the revisions of a function",
+		Long:  `This command lists the revisions of a function and restores previous revisions`,
+	}
+	cmd.AddCommand(newRolloutHistoryCmd())
+	cmd.AddCommand(newRolloutUndoCmd())
+	return cmd
+}
+
+func newRolloutHistoryCmd() *cobra.Command {
+	p := &rolloutCmd{}
+	cmd := &cobra.Command{
+		Use:   "history fn NAME",
+		Short: "lists the revisions of a function",
+		Long:  `This command lists the revisions recorded for each change to the source or environment variables of a function`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			if err := p.parseArgs(args); err != nil {
+				handleError(err)
+				return
+			}
+			handleError(p.history())
+		},
+	}
+	p.setupFlags(cmd)
+	return cmd
+}
+
+func newRolloutUndoCmd() *cobra.Command {
+	p := &rolloutCmd{}
+	cmd := &cobra.Command{
+		Use:   "undo fn NAME [--to-revision N]",
+		Short: "restores a previous revision of a function",
+		Long:  `This command restores the source and environment variables of a function from the revision before the active one or from the given revision`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			if err := p.parseArgs(args); err != nil {
+				handleError(err)
+				return
+			}
+			handleError(p.undo())
+		},
+	}
+	p.setupFlags(cmd)
+	cmd.Flags().IntVar(&p.toRevision, "to-revision", 0, "the revision to restore. Defaults to the revision before the active one")
+	return cmd
+}
+
+func (p *rolloutCmd) setupFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace of the function")
+}
+
+func (p *rolloutCmd) parseArgs(args []string) error {
+	if len(args) < 2 {
+		return usageError(p.cmd, "Expected the arguments `fn NAME`")
+	}
+	kind, _, err := listOptsForKind(args[0])
+	if err != nil {
+		return err
+	}
+	if kind != functionKind {
+		return usageError(p.cmd, "Only functions have revisions but was given kind `%s`", args[0])
+	}
+	p.name = args[1]
+	return createKubernetesClient(p.cmd, p.kubeConfigPath, &p.kubeclient, &p.namespace)
+}
+
+// activeRevision returns the number of the revision the operator last rolled out
+func (p *rolloutCmd) activeRevision() (int, error) {
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return 0, err
+	}
+	function, err := client.Functions(p.namespace).Get(p.name)
+	if err != nil {
+		return 0, notFoundError(functionKind, p.name, err)
+	}
+	status := objectStatus(&function.ObjectMeta)
+	if status == nil {
+		return 0, nil
+	}
+	return status.Revision, nil
+}
+
+func (p *rolloutCmd) history() error {
+	active, err := p.activeRevision()
+	if err != nil {
+		return err
+	}
+	revisions, err := funktion.ListRevisions(p.kubeclient, p.namespace, p.name)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		fmt.Printf("No revisions recorded for %s \"%s\"\n", functionKind, p.name)
+		return nil
+	}
+	printRevisionRow("REVISION", "ACTIVE", "CREATED", "HASH")
+	for _, r := range revisions {
+		activeText := ""
+		if r.Number == active {
+			activeText = "*"
+		}
+		hash := r.Hash
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		printRevisionRow(fmt.Sprintf("%d", r.Number), activeText, r.Timestamp.Local().Format(time.RFC3339), hash)
+	}
+	return nil
+}
+
+func printRevisionRow(revision string, active string, created string, hash string) {
+	fmt.Printf("%-10s %-8s %-26s %s\n", revision, active, created, hash)
+}
+
+func (p *rolloutCmd) undo() error {
+	active, err := p.activeRevision()
+	if err != nil {
+		return err
+	}
+	if active == 0 && p.toRevision == 0 {
+		return fmt.Errorf("The active revision of %s \"%s\" is not known yet. Please specify --to-revision", functionKind, p.name)
+	}
+	revisions, err := funktion.ListRevisions(p.kubeclient, p.namespace, p.name)
+	if err != nil {
+		return err
+	}
+	var target *funktion.Revision
+	for _, r := range revisions {
+		if p.toRevision > 0 {
+			if r.Number == p.toRevision {
+				target = r
+			}
+		} else if r.Number < active {
+			// lets pick the latest revision before the active one
+			target = r
+		}
+	}
+	if target == nil {
+		if p.toRevision > 0 {
+			return fmt.Errorf("Revision %d of %s \"%s\" not found", p.toRevision, functionKind, p.name)
+		}
+		return fmt.Errorf("No revision of %s \"%s\" before the active revision %d", functionKind, p.name, active)
+	}
+	if target.Number == active {
+		fmt.Printf("%s \"%s\" is already at revision %d\n", functionKind, p.name, active)
+		return nil
+	}
+
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	functions := client.Functions(p.namespace)
+	function, err := functions.Get(p.name)
+	if err != nil {
+		return notFoundError(functionKind, p.name, err)
+	}
+	if err := funktion.ApplyRevision(function, target); err != nil {
+		return fmt.Errorf("Failed to restore revision %d of %s \"%s\" due to: %v", target.Number, functionKind, p.name, err)
+	}
+	if _, err := functions.Update(function); err != nil {
+		return fmt.Errorf("Failed to restore revision %d of %s \"%s\" due to: %v", target.Number, functionKind, p.name, err)
+	}
+	fmt.Printf("Rolled back %s \"%s\" to revision %d\n", functionKind, p.name, target.Number)
+	return nil
+}
@@ -21,8 +21,11 @@ import (
 	"k8s.io/client-go/1.5/dynamic"
 	"k8s.io/client-go/1.5/kubernetes"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/rest"
 	"k8s.io/client-go/1.5/tools/clientcmd"
 
+	funktionclient "github.com/funktionio/funktion/pkg/client"
 	"github.com/funktionio/funktion/pkg/config"
 	"github.com/funktionio/funktion/pkg/constants"
 	"github.com/funktionio/funktion/pkg/funktion"
@@ -90,6 +93,32 @@ func createKubernetesClient(cmd *cobra.Command, kubeConfigPath string, kubeclien
 }
 
 func createKubernetesDynamicClient(kubeConfigPath string) (*dynamic.Client, error) {
+	cfg, err := createKubernetesClientConfig(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewClient(cfg)
+}
+
+func createFunktionClient(kubeConfigPath string) (*funktionclient.Clientset, error) {
+	cfg, err := createKubernetesClientConfig(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return funktionclient.NewForConfig(cfg)
+}
+
+// createClient creates the client for the funktion resources which changes the resources the
+// operator renders from typed resources through the typed resources
+func createClient(kubeclient kubernetes.Interface, kubeConfigPath string) (*funktion.Client, error) {
+	tclient, err := createFunktionClient(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return funktion.NewClient(kubeclient, tclient), nil
+}
+
+func createKubernetesClientConfig(kubeConfigPath string) (*rest.Config, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	if len(kubeConfigPath) > 0 {
 		loadingRules.ExplicitPath = kubeConfigPath
@@ -105,7 +134,7 @@ func createKubernetesDynamicClient(kubeConfigPath string) (*dynamic.Client, erro
 		fmt.Printf("failed to create Kubernetes client config due to %v\n", err)
 		return nil, err
 	}
-	return dynamic.NewClient(cfg)
+	return cfg, nil
 }
 
 func handleError(err error) {
@@ -138,6 +167,14 @@ func listOptsForKind(kind string) (string, *api.ListOptions, error) {
 	}
 }
 
+// notFoundError returns the error reported when a named resource does not exist
+func notFoundError(kind string, name string, err error) error {
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("%s \"%s\" not found", kind, name)
+	}
+	return err
+}
+
 func nameForDeployment(kube *kubernetes.Clientset, namespace string, kind string, name string) (string, error) {
 	// TODO we may need to map a function or flow to a different named resource if we have a naming clash
 	// so we may need to look at a label or annotation on the function / flow
@@ -0,0 +1,412 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/util/intstr"
+)
+
+// nodeHarness serves a nodejs Function over HTTP using the same contract as the nodejs Runtime:
+// the module exports a function(context, callback) which is given the request and calls back
+// with the status and the body of the response
+const nodeHarness = `var http = require('http');
+var fn = require(process.env.FUNKTION_SOURCE);
+var port = parseInt(process.env.FUNKTION_PORT, 10);
+
+http.createServer(function (req, res) {
+  var chunks = [];
+  req.on('data', function (chunk) { chunks.push(chunk); });
+  req.on('end', function () {
+    var text = Buffer.concat(chunks).toString();
+    var body;
+    if (text.length > 0) {
+      try { body = JSON.parse(text); } catch (e) { body = text; }
+    }
+    var context = {
+      request: { method: req.method, url: req.url, headers: req.headers, body: body }
+    };
+    var done = false;
+    var callback = function (status, result) {
+      if (done) { return; }
+      done = true;
+      if (result !== null && typeof result === 'object' && !Buffer.isBuffer(result)) {
+        res.setHeader('Content-Type', 'application/json');
+        result = JSON.stringify(result);
+      }
+      res.writeHead(status || 200);
+      res.end(result === undefined || result === null ? '' : result);
+    };
+    try {
+      fn(context, callback);
+    } catch (e) {
+      console.error(e.stack || e);
+      callback(500, String(e));
+    }
+  });
+}).listen(port, 'localhost');
+`
+
+type runCmd struct {
+	cmd *cobra.Command
+
+	file        string
+	runtime     string
+	runtimeFile string
+	port        int
+	envVars     []string
+	node        string
+	docker      string
+	watch       bool
+
+	name         string
+	source       string
+	env          []v1.EnvVar
+	localRuntime *localRuntime
+	harness      string
+
+	lock    sync.Mutex
+	process *localProcess
+}
+
+// localRuntime is how a Runtime runs a Function in a container
+type localRuntime struct {
+	name      string
+	image     string
+	port      int32
+	mountPath string
+	env       []v1.EnvVar
+}
+
+// localProcess is the node process or docker container running the Function
+type localProcess struct {
+	cmd      *exec.Cmd
+	stopping chan struct{}
+	done     chan struct{}
+}
+
+func init() {
+	RootCmd.AddCommand(newRunCmd())
+}
+
+func newRunCmd() *cobra.Command {
+	p := &runCmd{}
+	cmd := &cobra.Command{
+		Use:   "run -f FILENAME [flags]",
+		Short: "runs a function locally for offline development",
+		Long: `This command serves a function over HTTP on localhost without a kubernetes cluster.
+
+By default a local nodejs process calls the function exported by the source file with module.exports = function(context, callback)
+for each request. Given a local copy of a Runtime with --runtime-file the function is run in the image of the Runtime with docker
+instead, with the source mounted at the sourceMountPath of the Runtime.
+
+The function is restarted whenever its source file changes.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			handleError(p.run())
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&p.file, "file", "f", "", "the file containing the source code of the function")
+	f.StringVarP(&p.runtime, "runtime", "r", "", "the name of the runtime to use from the --runtime-file. Defaults to the runtime for the extension of the file")
+	f.StringVar(&p.runtimeFile, "runtime-file", "", "a YAML file containing the Runtime, its ConfigMap or a List of them. The function is run in the runtime's image with docker")
+	f.IntVarP(&p.port, "port", "p", 8080, "the port on localhost to serve the function on")
+	f.StringArrayVarP(&p.envVars, "env", "e", []string{}, "pass one or more environment variables using the form NAME=VALUE")
+	f.StringVar(&p.node, "node", "node", "the nodejs binary used when there is no --runtime-file")
+	f.StringVar(&p.docker, "docker", "docker", "the docker binary used to run the runtime's image")
+	f.BoolVarP(&p.watch, "watch", "w", true, "whether to restart the function when the source file changes")
+	return cmd
+}
+
+func (p *runCmd) run() error {
+	if len(p.file) == 0 {
+		return usageError(p.cmd, "No file argument specified!")
+	}
+	if !isExistingFile(p.file) {
+		return fmt.Errorf("No source file %s", p.file)
+	}
+	source, err := filepath.Abs(p.file)
+	if err != nil {
+		return err
+	}
+	p.source = source
+	p.name = nameFromFile(p.file, "")
+	if len(p.envVars) > 0 {
+		p.env, err = parseEnvVarArgs(p.envVars)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(p.runtimeFile) > 0 {
+		p.localRuntime, err = loadLocalRuntime(p.runtimeFile, p.runtime, p.file)
+		if err != nil {
+			return err
+		}
+	} else {
+		dir, err := ioutil.TempDir("", "funktion-run")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		p.harness = filepath.Join(dir, "harness.js")
+		if err := ioutil.WriteFile(p.harness, []byte(nodeHarness), 0644); err != nil {
+			return err
+		}
+	}
+
+	p.lock.Lock()
+	err = p.start()
+	p.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if p.watch {
+		go watchFiles(p.file, func(fileName string) {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			fmt.Printf("Restarting function %s as %s changed\n", p.name, fileName)
+			p.stop()
+			if err := p.start(); err != nil {
+				fmt.Printf("Failed to restart function %s due to %v\n", p.name, err)
+			}
+		})
+	}
+
+	term := make(chan os.Signal)
+	signal.Notify(term, os.Interrupt, syscall.SIGTERM)
+	<-term
+	fmt.Println()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stop()
+	return nil
+}
+
+// start runs the Function. The lock must be held.
+func (p *runCmd) start() error {
+	var cmd *exec.Cmd
+	if p.localRuntime != nil {
+		cmd = exec.Command(p.docker, p.dockerArgs()...)
+	} else {
+		cmd = exec.Command(p.node, p.harness)
+		cmd.Env = append(os.Environ(), "FUNKTION_SOURCE="+p.source, fmt.Sprintf("FUNKTION_PORT=%d", p.port))
+		for _, env := range p.env {
+			cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+		}
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	proc := &localProcess{
+		cmd:      cmd,
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		err := cmd.Wait()
+		close(proc.done)
+		select {
+		case <-proc.stopping:
+		default:
+			// lets keep watching so that fixing the source restarts the function
+			fmt.Printf("Function %s exited: %v\n", p.name, err)
+		}
+	}()
+	p.process = proc
+	fmt.Printf("Function %s is running at http://localhost:%d/\n", p.name, p.port)
+	return nil
+}
+
+// stop kills the running Function. The lock must be held.
+func (p *runCmd) stop() {
+	proc := p.process
+	if proc == nil {
+		return
+	}
+	p.process = nil
+	close(proc.stopping)
+	if p.localRuntime != nil {
+		// killing the docker client would leave the container running
+		exec.Command(p.docker, "rm", "-f", p.containerName()).Run()
+	}
+	proc.cmd.Process.Kill()
+	<-proc.done
+}
+
+func (p *runCmd) containerName() string {
+	return "funktion-run-" + p.name
+}
+
+func (p *runCmd) dockerArgs() []string {
+	r := p.localRuntime
+	args := []string{
+		"run", "--rm",
+		"--name", p.containerName(),
+		"-p", fmt.Sprintf("127.0.0.1:%d:%d", p.port, r.port),
+		"-v", fmt.Sprintf("%s:%s:ro", p.source, path.Join(r.mountPath, funktion.SourceFileName)),
+	}
+	for _, env := range append(r.env, p.env...) {
+		args = append(args, "-e", env.Name+"="+env.Value)
+	}
+	return append(args, r.image)
+}
+
+// loadLocalRuntime reads the Runtime to run the given source file with from a YAML file
+func loadLocalRuntime(fileName string, name string, sourceFile string) (*localRuntime, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	runtimes, err := parseRuntimes(data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the runtimes in %s: %v", fileName, err)
+	}
+	runtime := selectRuntime(runtimes, name, sourceFile)
+	if runtime == nil {
+		if len(name) > 0 {
+			return nil, fmt.Errorf("No runtime called `%s` in %s", name, fileName)
+		}
+		return nil, fmt.Errorf("No runtime for file %s in %s. Please pass --runtime", sourceFile, fileName)
+	}
+	return newLocalRuntime(runtime)
+}
+
+// parseRuntimes parses a Runtime, the ConfigMap of a Runtime or a List of them
+func parseRuntimes(data []byte) ([]*spec.Runtime, error) {
+	header := struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}{}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	docs := [][]byte{data}
+	if header.Kind == "List" {
+		docs = [][]byte{}
+		for _, item := range header.Items {
+			docs = append(docs, item)
+		}
+	}
+	answer := []*spec.Runtime{}
+	for _, doc := range docs {
+		meta := struct {
+			Kind string `json:"kind"`
+		}{}
+		if err := yaml.Unmarshal(doc, &meta); err != nil {
+			return nil, err
+		}
+		switch meta.Kind {
+		case funktion.RuntimeKind:
+			runtime := &spec.Runtime{}
+			if err := yaml.Unmarshal(doc, runtime); err != nil {
+				return nil, err
+			}
+			answer = append(answer, runtime)
+		case "ConfigMap":
+			cm := &v1.ConfigMap{}
+			if err := yaml.Unmarshal(doc, cm); err != nil {
+				return nil, err
+			}
+			if cm.Labels[funktion.KindLabel] != funktion.RuntimeKind {
+				continue
+			}
+			runtime, err := funktion.ConfigMapToRuntime(cm)
+			if err != nil {
+				return nil, err
+			}
+			answer = append(answer, runtime)
+		}
+	}
+	return answer, nil
+}
+
+// selectRuntime returns the runtime with the given name or, if no name is given, the only
+// runtime or the one for the extension of the source file
+func selectRuntime(runtimes []*spec.Runtime, name string, sourceFile string) *spec.Runtime {
+	if len(name) > 0 {
+		for _, runtime := range runtimes {
+			if runtime.Name == name {
+				return runtime
+			}
+		}
+		return nil
+	}
+	if len(runtimes) == 1 {
+		return runtimes[0]
+	}
+	ext := strings.TrimPrefix(filepath.Ext(sourceFile), ".")
+	for _, runtime := range runtimes {
+		for _, value := range runtime.Spec.FileExtensions {
+			if ext == value {
+				return runtime
+			}
+		}
+	}
+	return nil
+}
+
+func newLocalRuntime(runtime *spec.Runtime) (*localRuntime, error) {
+	d := runtime.Spec.Deployment
+	if d == nil || len(d.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("Runtime %s has no deployment with a container", runtime.Name)
+	}
+	container := d.Spec.Template.Spec.Containers[0]
+	answer := &localRuntime{
+		name:      runtime.Name,
+		image:     container.Image,
+		mountPath: runtime.Spec.SourceMountPath,
+	}
+	if len(answer.mountPath) == 0 {
+		answer.mountPath = funktion.DefaultSourceMountPath
+	}
+	for _, env := range container.Env {
+		// values read from the cluster such as from Secrets are not available locally
+		if env.ValueFrom == nil {
+			answer.env = append(answer.env, env)
+		}
+	}
+	if svc := runtime.Spec.Service; svc != nil && len(svc.Spec.Ports) > 0 {
+		target := svc.Spec.Ports[0].TargetPort
+		if target.Type == intstr.Int && target.IntVal > 0 {
+			answer.port = target.IntVal
+		}
+	}
+	if answer.port == 0 && len(container.Ports) > 0 {
+		answer.port = container.Ports[0].ContainerPort
+	}
+	if answer.port == 0 {
+		return nil, fmt.Errorf("Could not find the port of Runtime %s. Its service should have a numeric targetPort", runtime.Name)
+	}
+	return answer, nil
+}
@@ -0,0 +1,98 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+const runtimesYaml = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: nodejs
+    labels:
+      funktion.fabric8.io/kind: Runtime
+  data:
+    fileExtensions: js
+    sourceMountPath: /usr/src/app/funktion
+    deployment: |
+      apiVersion: extensions/v1beta1
+      kind: Deployment
+      spec:
+        template:
+          spec:
+            containers:
+            - image: funktion/nodejs-runtime
+              env:
+              - name: LOG_LEVEL
+                value: info
+    service: |
+      apiVersion: v1
+      kind: Service
+      spec:
+        ports:
+        - port: 80
+          targetPort: 8888
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: not-a-runtime
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: python
+    labels:
+      funktion.fabric8.io/kind: Runtime
+  data:
+    fileExtensions: py
+`
+
+func TestLoadLocalRuntime(t *testing.T) {
+	runtimes, err := parseRuntimes([]byte(runtimesYaml))
+	if err != nil {
+		t.Fatalf("Failed to parse the runtimes: %v", err)
+	}
+	if len(runtimes) != 2 {
+		t.Fatalf("Expected 2 runtimes but got %d", len(runtimes))
+	}
+	runtime := selectRuntime(runtimes, "", "hello.js")
+	if runtime == nil {
+		t.Fatalf("Expected a runtime for a .js file")
+	}
+	r, err := newLocalRuntime(runtime)
+	if err != nil {
+		t.Fatalf("Failed to create the local runtime: %v", err)
+	}
+	assertEquals(t, r.image, "funktion/nodejs-runtime")
+	assertEquals(t, r.mountPath, "/usr/src/app/funktion")
+	assertEquals(t, r.env[0].Value, "info")
+	if r.port != 8888 {
+		t.Errorf("Expected the port of the runtime to be 8888 but got %d", r.port)
+	}
+
+	p := &runCmd{name: "hello", source: "/src/hello.js", port: 9000, localRuntime: r}
+	assertEquals(t, strings.Join(p.dockerArgs(), " "), "run --rm --name funktion-run-hello -p 127.0.0.1:9000:8888 -v /src/hello.js:/usr/src/app/funktion/source.js:ro -e LOG_LEVEL=info funktion/nodejs-runtime")
+
+	if selectRuntime(runtimes, "ruby", "hello.rb") != nil {
+		t.Errorf("Expected no runtime called ruby")
+	}
+	if _, err := newLocalRuntime(selectRuntime(runtimes, "python", "")); err == nil {
+		t.Errorf("Expected a runtime without a deployment to fail")
+	}
+}
@@ -0,0 +1,150 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/funktion"
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/1.5/kubernetes"
+)
+
+type trafficCmd struct {
+	kubeclient     *kubernetes.Clientset
+	cmd            *cobra.Command
+	kubeConfigPath string
+
+	namespace string
+	name      string
+	targets   []string
+}
+
+func init() {
+	RootCmd.AddCommand(newTrafficCmd())
+}
+
+func newTrafficCmd() *cobra.Command {
+	p := &trafficCmd{}
+	cmd := &cobra.Command{
+		Use:   "traffic fn NAME [revN=PERCENT ...]",
+		Short: "splits the requests of a function between its revisions",
+		Long: `This command splits the requests of a function between its revisions such as for a canary release.
+
+For example to send 90% of the requests to revision 3 and 10% to revision 4:
+
+  funktion traffic fn hello rev3=90 rev4=10
+
+Sending all the requests to the active revision removes the split. Without any revisions the current split is displayed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			p.cmd = cmd
+			if err := p.parseArgs(args); err != nil {
+				handleError(err)
+				return
+			}
+			handleError(p.run())
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&p.kubeConfigPath, "kubeconfig", "", "the directory to look for the kubernetes configuration")
+	f.StringVarP(&p.namespace, "namespace", "n", "", "the namespace of the function")
+	return cmd
+}
+
+func (p *trafficCmd) parseArgs(args []string) error {
+	if len(args) < 2 {
+		return usageError(p.cmd, "Expected the arguments `fn NAME [revN=PERCENT ...]`")
+	}
+	kind, _, err := listOptsForKind(args[0])
+	if err != nil {
+		return err
+	}
+	if kind != functionKind {
+		return usageError(p.cmd, "Only the traffic of functions can be split but was given kind `%s`", args[0])
+	}
+	p.name = args[1]
+	p.targets = args[2:]
+	return createKubernetesClient(p.cmd, p.kubeConfigPath, &p.kubeclient, &p.namespace)
+}
+
+func (p *trafficCmd) run() error {
+	client, err := createClient(p.kubeclient, p.kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	functions := client.Functions(p.namespace)
+	function, err := functions.Get(p.name)
+	if err != nil {
+		return notFoundError(functionKind, p.name, err)
+	}
+	active := 0
+	if status := objectStatus(&function.ObjectMeta); status != nil {
+		active = status.Revision
+	}
+	if len(p.targets) == 0 {
+		printTraffic(function.Spec.Traffic, active)
+		return nil
+	}
+
+	targets, err := funktion.ParseTraffic(strings.Join(p.targets, ","))
+	if err != nil {
+		return usageError(p.cmd, "%v", err)
+	}
+	revisions, err := funktion.ListRevisions(p.kubeclient, p.namespace, p.name)
+	if err != nil {
+		return err
+	}
+	known := map[int]bool{}
+	for _, r := range revisions {
+		known[r.Number] = true
+	}
+	for _, t := range targets {
+		if !known[t.Revision] {
+			return fmt.Errorf("Revision %d of %s \"%s\" not found", t.Revision, functionKind, p.name)
+		}
+	}
+
+	if len(targets) == 1 && targets[0].Revision == active {
+		// all the requests go to the active revision anyway
+		targets = nil
+	}
+	function.Spec.Traffic = targets
+	if _, err := functions.Update(function); err != nil {
+		return fmt.Errorf("Failed to split the traffic of %s \"%s\" due to: %v", functionKind, p.name, err)
+	}
+	if targets == nil {
+		fmt.Printf("Sending all the requests of %s \"%s\" to the active revision %d\n", functionKind, p.name, active)
+		return nil
+	}
+	printTraffic(targets, active)
+	return nil
+}
+
+func printTraffic(targets []spec.TrafficTarget, active int) {
+	if len(targets) == 0 {
+		fmt.Printf("All the requests go to the active revision %d\n", active)
+		return
+	}
+	fmt.Printf("%-10s %-8s %s\n", "REVISION", "ACTIVE", "PERCENT")
+	for _, t := range targets {
+		activeText := ""
+		if t.Revision == active {
+			activeText = "*"
+		}
+		fmt.Printf("%-10d %-8s %d%%\n", t.Revision, activeText, t.Percent)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/funktionio/funktion/pkg/funktion"
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
 
@@ -29,10 +30,6 @@ import (
 	"k8s.io/client-go/1.5/pkg/api/v1"
 )
 
-const (
-	exposeURLAnnotation = "fabric8.io/exposeUrl"
-)
-
 type locationCom struct {
 	kubeclient     *kubernetes.Clientset
 	cmd            *cobra.Command
@@ -109,7 +106,7 @@ func (p *locationCom) openService(serviceName string) error {
 	}
 	for _, service := range svcs.Items {
 		if serviceName == service.Name {
-			url := service.ObjectMeta.Annotations[exposeURLAnnotation]
+			url := service.ObjectMeta.Annotations[funktion.ExposeURLAnnotation]
 			if p.open {
 				fmt.Printf("\nOpening URL %s\n", url)
 				browser.OpenURL(url)
@@ -130,7 +127,7 @@ func CheckService(c *kubernetes.Clientset, ns string, serviceName string) error
 	if err != nil {
 		return err
 	}
-	url := svc.ObjectMeta.Annotations[exposeURLAnnotation]
+	url := svc.ObjectMeta.Annotations[funktion.ExposeURLAnnotation]
 	if url == "" {
 		fmt.Print(".")
 		return errors.New("")
@@ -16,63 +16,60 @@ package analytics
 
 import (
 	"sync"
-
-	ga "github.com/jpillora/go-ogle-analytics"
-)
-
-const (
-	id       = "UA-85532162-2"
-	category = "funktion-operator"
 )
 
 var (
-	client *ga.Client
-	once   sync.Once
+	lock sync.RWMutex
+	sink Sink = NopSink{}
 )
 
-func send(e *ga.Event) {
-	mustClient().Send(e)
+// SetSink changes the sink the operator's telemetry events are sent to
+func SetSink(s Sink) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if s == nil {
+		s = NopSink{}
+	}
+	sink = s
 }
 
-func mustClient() *ga.Client {
-	once.Do(func() {
-		c, err := ga.NewClient(id)
-		if err != nil {
-			panic(err)
-		}
-		client = c
-	})
-	return client
+func send(action string) {
+	lock.RLock()
+	s := sink
+	lock.RUnlock()
+
+	s.Event(category, action)
 }
 
 func FlowCreated() {
-	send(ga.NewEvent(category, "flow_created"))
+	send("flow_created")
 }
 
 func FlowDeleted() {
-	send(ga.NewEvent(category, "flow_deleted"))
+	send("flow_deleted")
 }
 
 func ConnectorCreated() {
-	send(ga.NewEvent(category, "connector_created"))
+	send("connector_created")
 }
 
 func ConnectorDeleted() {
-	send(ga.NewEvent(category, "connector_deleted"))
+	send("connector_deleted")
 }
 
 func FunctionCreated() {
-	send(ga.NewEvent(category, "function_created"))
+	send("function_created")
 }
 
 func FunctionDeleted() {
-	send(ga.NewEvent(category, "function_deleted"))
+	send("function_deleted")
 }
 
 func RuntimeCreated() {
-	send(ga.NewEvent(category, "runtime_created"))
+	send("runtime_created")
 }
 
 func RuntimeDeleted() {
-	send(ga.NewEvent(category, "runtime_deleted"))
+	send("runtime_deleted")
 }
@@ -0,0 +1,130 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package analytics
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/metrics"
+	"github.com/go-kit/kit/log"
+	ga "github.com/jpillora/go-ogle-analytics"
+)
+
+const (
+	id       = "UA-85532162-2"
+	category = "funktion-operator"
+
+	// TelemetryEnvVar is the environment variable used to choose the telemetry sink
+	TelemetryEnvVar = "FUNKTION_TELEMETRY"
+
+	// NoneSink discards telemetry events
+	NoneSink = "none"
+	// LogSink logs telemetry events
+	LogSink = "log"
+	// MetricsSink counts telemetry events in a metric
+	MetricsSink = "metrics"
+	// GoogleAnalyticsSink sends telemetry events to Google Analytics
+	GoogleAnalyticsSink = "ga"
+	// AutoSink uses Google Analytics if it can be reached or none otherwise, e.g. in air-gapped clusters
+	AutoSink = "auto"
+
+	gaAddress      = "www.google-analytics.com:443"
+	gaProbeTimeout = 3 * time.Second
+)
+
+// SinkNames are the names of the sinks accepted by NewSink
+var SinkNames = []string{NoneSink, LogSink, MetricsSink, GoogleAnalyticsSink, AutoSink}
+
+// Sink receives the operator's telemetry events
+type Sink interface {
+	Event(category, action string)
+}
+
+// NewSink creates the sink with the given name. The registry is used by the metrics sink.
+func NewSink(name string, logger log.Logger, registry *metrics.Registry) (Sink, error) {
+	switch name {
+	case NoneSink, "":
+		return NopSink{}, nil
+	case LogSink:
+		return &logSink{logger: logger}, nil
+	case MetricsSink:
+		if registry == nil {
+			return nil, fmt.Errorf("No metrics registry for the %s telemetry sink", MetricsSink)
+		}
+		return &metricsSink{
+			events: registry.NewCounterVec("funktion_operator_telemetry_events_total", "The number of telemetry events by action", "action"),
+		}, nil
+	case GoogleAnalyticsSink:
+		return newGASink(logger)
+	case AutoSink:
+		conn, err := net.DialTimeout("tcp", gaAddress, gaProbeTimeout)
+		if err != nil {
+			logger.Log("msg", "Google Analytics is not reachable so telemetry is disabled", "err", err)
+			return NopSink{}, nil
+		}
+		conn.Close()
+		return newGASink(logger)
+	}
+	return nil, fmt.Errorf("Unknown telemetry sink %s. Valid values are %v", name, SinkNames)
+}
+
+// NopSink discards all events
+type NopSink struct{}
+
+// Event does nothing
+func (NopSink) Event(category, action string) {}
+
+type logSink struct {
+	logger log.Logger
+}
+
+func (s *logSink) Event(category, action string) {
+	s.logger.Log("msg", "telemetry event", "category", category, "action", action)
+}
+
+type metricsSink struct {
+	events *metrics.CounterVec
+}
+
+func (s *metricsSink) Event(category, action string) {
+	s.events.Inc(action)
+}
+
+type gaSink struct {
+	client *ga.Client
+	logger log.Logger
+}
+
+func newGASink(logger log.Logger) (Sink, error) {
+	client, err := ga.NewClient(id)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the Google Analytics client: %v", err)
+	}
+	return &gaSink{
+		client: client,
+		logger: logger,
+	}, nil
+}
+
+// Event sends the event in the background so that a slow network does not hold up the operator
+func (s *gaSink) Event(category, action string) {
+	go func() {
+		if err := s.client.Send(ga.NewEvent(category, action)); err != nil {
+			s.logger.Log("msg", "failed to send telemetry event", "action", action, "err", err)
+		}
+	}()
+}
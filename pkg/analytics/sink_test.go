@@ -0,0 +1,59 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package analytics
+
+import (
+	"testing"
+
+	"github.com/funktionio/funktion/pkg/metrics"
+	"github.com/go-kit/kit/log"
+)
+
+func TestMetricsSink(t *testing.T) {
+	s, err := NewSink(MetricsSink, log.NewNopLogger(), metrics.NewRegistry())
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	SetSink(s)
+	defer SetSink(nil)
+
+	FunctionCreated()
+	FunctionCreated()
+	FlowDeleted()
+
+	events := s.(*metricsSink).events
+	if v := events.Value("function_created"); v != 2 {
+		t.Errorf("Expected 2 function_created events but got %v", v)
+	}
+	if v := events.Value("flow_deleted"); v != 1 {
+		t.Errorf("Expected 1 flow_deleted event but got %v", v)
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	s, err := NewSink("", log.NewNopLogger(), nil)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	if _, ok := s.(NopSink); !ok {
+		t.Errorf("Expected the default sink to discard events but got %T", s)
+	}
+	if _, err := NewSink("unknown", log.NewNopLogger(), nil); err == nil {
+		t.Errorf("Expected an error for an unknown sink")
+	}
+	if _, err := NewSink(MetricsSink, log.NewNopLogger(), nil); err == nil {
+		t.Errorf("Expected an error for the metrics sink without a registry")
+	}
+}
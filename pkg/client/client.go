@@ -0,0 +1,185 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package client is a typed client for the funktion ThirdPartyResources
+// (Functions, Flows, Runtimes and Connectors) in the spec package. It is written by hand on
+// top of the client-go REST client rather than generated with client-gen.
+package client
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/runtime/serializer"
+	"k8s.io/client-go/1.5/pkg/watch"
+	"k8s.io/client-go/1.5/rest"
+)
+
+// Interface gives access to the funktion resources in a namespace
+type Interface interface {
+	Functions(namespace string) FunctionInterface
+	Flows(namespace string) FlowInterface
+	Runtimes(namespace string) RuntimeInterface
+	Connectors(namespace string) ConnectorInterface
+}
+
+// Clientset is the REST implementation of Interface
+type Clientset struct {
+	restClient *rest.RESTClient
+}
+
+// NewForConfig creates a Clientset for the funktion API group
+func NewForConfig(cfg *rest.Config) (*Clientset, error) {
+	config := *cfg
+	config.GroupVersion = &spec.GroupVersion
+	config.APIPath = "/apis"
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: api.Codecs}
+
+	restClient, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &Clientset{restClient: restClient}, nil
+}
+
+// RESTClient returns the underlying REST client
+func (c *Clientset) RESTClient() *rest.RESTClient {
+	return c.restClient
+}
+
+// Functions returns the Functions in the given namespace
+func (c *Clientset) Functions(namespace string) FunctionInterface {
+	return &functions{c.resource(namespace, spec.FunctionResource)}
+}
+
+// Flows returns the Flows in the given namespace
+func (c *Clientset) Flows(namespace string) FlowInterface {
+	return &flows{c.resource(namespace, spec.FlowResource)}
+}
+
+// Runtimes returns the Runtimes in the given namespace
+func (c *Clientset) Runtimes(namespace string) RuntimeInterface {
+	return &runtimes{c.resource(namespace, spec.RuntimeResource)}
+}
+
+// Connectors returns the Connectors in the given namespace
+func (c *Clientset) Connectors(namespace string) ConnectorInterface {
+	return &connectors{c.resource(namespace, spec.ConnectorResource)}
+}
+
+func (c *Clientset) resource(namespace string, r spec.Resource) *resourceClient {
+	return &resourceClient{
+		client:    c.restClient,
+		namespace: namespace,
+		plural:    r.Plural,
+	}
+}
+
+// resourceClient performs the REST calls for one kind of resource, encoding and
+// decoding the typed resources as JSON
+type resourceClient struct {
+	client    *rest.RESTClient
+	namespace string
+	plural    string
+}
+
+func (r *resourceClient) list(opts api.ListOptions, into interface{}) error {
+	req := r.client.Get().Namespace(r.namespace).Resource(r.plural)
+	if opts.LabelSelector != nil {
+		req = req.Param("labelSelector", opts.LabelSelector.String())
+	}
+	return decode(req.DoRaw())(into)
+}
+
+func (r *resourceClient) get(name string, into interface{}) error {
+	return decode(r.client.Get().Namespace(r.namespace).Resource(r.plural).Name(name).DoRaw())(into)
+}
+
+func (r *resourceClient) create(obj interface{}, into interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return decode(r.client.Post().Namespace(r.namespace).Resource(r.plural).Body(body).DoRaw())(into)
+}
+
+func (r *resourceClient) update(name string, obj interface{}, into interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return decode(r.client.Put().Namespace(r.namespace).Resource(r.plural).Name(name).Body(body).DoRaw())(into)
+}
+
+func (r *resourceClient) delete(name string) error {
+	return r.client.Delete().Namespace(r.namespace).Resource(r.plural).Name(name).Do().Error()
+}
+
+func (r *resourceClient) watch(opts api.ListOptions, newObject func() runtime.Object) (watch.Interface, error) {
+	req := r.client.Get().Namespace(r.namespace).Resource(r.plural).Param("watch", "true")
+	if opts.LabelSelector != nil {
+		req = req.Param("labelSelector", opts.LabelSelector.String())
+	}
+	if len(opts.ResourceVersion) > 0 {
+		req = req.Param("resourceVersion", opts.ResourceVersion)
+	}
+	stream, err := req.Stream()
+	if err != nil {
+		return nil, err
+	}
+	return watch.NewStreamWatcher(&watchDecoder{
+		decoder:   json.NewDecoder(stream),
+		closer:    stream,
+		newObject: newObject,
+	}), nil
+}
+
+func decode(data []byte, err error) func(into interface{}) error {
+	return func(into interface{}) error {
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, into)
+	}
+}
+
+// watchDecoder decodes the watch events of a ThirdPartyResource into typed resources
+type watchDecoder struct {
+	decoder   *json.Decoder
+	closer    io.Closer
+	newObject func() runtime.Object
+}
+
+func (d *watchDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	var e struct {
+		Type   watch.EventType `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	if err := d.decoder.Decode(&e); err != nil {
+		return watch.Error, nil, err
+	}
+	obj := d.newObject()
+	if err := json.Unmarshal(e.Object, obj); err != nil {
+		return watch.Error, nil, err
+	}
+	return e.Type, obj, nil
+}
+
+func (d *watchDecoder) Close() {
+	d.closer.Close()
+}
@@ -0,0 +1,64 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package client
+
+import (
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/watch"
+)
+
+// ConnectorInterface manages the Connectors in a namespace
+type ConnectorInterface interface {
+	List(opts api.ListOptions) (*spec.ConnectorList, error)
+	Get(name string) (*spec.Connector, error)
+	Create(connector *spec.Connector) (*spec.Connector, error)
+	Update(connector *spec.Connector) (*spec.Connector, error)
+	Delete(name string) error
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
+
+type connectors struct {
+	*resourceClient
+}
+
+func (c *connectors) List(opts api.ListOptions) (*spec.ConnectorList, error) {
+	answer := &spec.ConnectorList{}
+	return answer, c.list(opts, answer)
+}
+
+func (c *connectors) Get(name string) (*spec.Connector, error) {
+	answer := &spec.Connector{}
+	return answer, c.get(name, answer)
+}
+
+func (c *connectors) Create(connector *spec.Connector) (*spec.Connector, error) {
+	answer := &spec.Connector{}
+	return answer, c.create(connector, answer)
+}
+
+func (c *connectors) Update(connector *spec.Connector) (*spec.Connector, error) {
+	answer := &spec.Connector{}
+	return answer, c.update(connector.Name, connector, answer)
+}
+
+func (c *connectors) Delete(name string) error {
+	return c.delete(name)
+}
+
+func (c *connectors) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func() runtime.Object { return &spec.Connector{} })
+}
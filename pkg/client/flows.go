@@ -0,0 +1,64 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package client
+
+import (
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/watch"
+)
+
+// FlowInterface manages the Flows in a namespace
+type FlowInterface interface {
+	List(opts api.ListOptions) (*spec.FlowList, error)
+	Get(name string) (*spec.Flow, error)
+	Create(flow *spec.Flow) (*spec.Flow, error)
+	Update(flow *spec.Flow) (*spec.Flow, error)
+	Delete(name string) error
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
+
+type flows struct {
+	*resourceClient
+}
+
+func (c *flows) List(opts api.ListOptions) (*spec.FlowList, error) {
+	answer := &spec.FlowList{}
+	return answer, c.list(opts, answer)
+}
+
+func (c *flows) Get(name string) (*spec.Flow, error) {
+	answer := &spec.Flow{}
+	return answer, c.get(name, answer)
+}
+
+func (c *flows) Create(flow *spec.Flow) (*spec.Flow, error) {
+	answer := &spec.Flow{}
+	return answer, c.create(flow, answer)
+}
+
+func (c *flows) Update(flow *spec.Flow) (*spec.Flow, error) {
+	answer := &spec.Flow{}
+	return answer, c.update(flow.Name, flow, answer)
+}
+
+func (c *flows) Delete(name string) error {
+	return c.delete(name)
+}
+
+func (c *flows) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func() runtime.Object { return &spec.Flow{} })
+}
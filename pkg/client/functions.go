@@ -0,0 +1,64 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package client
+
+import (
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/watch"
+)
+
+// FunctionInterface manages the Functions in a namespace
+type FunctionInterface interface {
+	List(opts api.ListOptions) (*spec.FunctionList, error)
+	Get(name string) (*spec.Function, error)
+	Create(function *spec.Function) (*spec.Function, error)
+	Update(function *spec.Function) (*spec.Function, error)
+	Delete(name string) error
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
+
+type functions struct {
+	*resourceClient
+}
+
+func (c *functions) List(opts api.ListOptions) (*spec.FunctionList, error) {
+	answer := &spec.FunctionList{}
+	return answer, c.list(opts, answer)
+}
+
+func (c *functions) Get(name string) (*spec.Function, error) {
+	answer := &spec.Function{}
+	return answer, c.get(name, answer)
+}
+
+func (c *functions) Create(function *spec.Function) (*spec.Function, error) {
+	answer := &spec.Function{}
+	return answer, c.create(function, answer)
+}
+
+func (c *functions) Update(function *spec.Function) (*spec.Function, error) {
+	answer := &spec.Function{}
+	return answer, c.update(function.Name, function, answer)
+}
+
+func (c *functions) Delete(name string) error {
+	return c.delete(name)
+}
+
+func (c *functions) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func() runtime.Object { return &spec.Function{} })
+}
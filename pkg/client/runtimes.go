@@ -0,0 +1,64 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package client
+
+import (
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/watch"
+)
+
+// RuntimeInterface manages the Runtimes in a namespace
+type RuntimeInterface interface {
+	List(opts api.ListOptions) (*spec.RuntimeList, error)
+	Get(name string) (*spec.Runtime, error)
+	Create(runtime *spec.Runtime) (*spec.Runtime, error)
+	Update(runtime *spec.Runtime) (*spec.Runtime, error)
+	Delete(name string) error
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
+
+type runtimes struct {
+	*resourceClient
+}
+
+func (c *runtimes) List(opts api.ListOptions) (*spec.RuntimeList, error) {
+	answer := &spec.RuntimeList{}
+	return answer, c.list(opts, answer)
+}
+
+func (c *runtimes) Get(name string) (*spec.Runtime, error) {
+	answer := &spec.Runtime{}
+	return answer, c.get(name, answer)
+}
+
+func (c *runtimes) Create(runtime *spec.Runtime) (*spec.Runtime, error) {
+	answer := &spec.Runtime{}
+	return answer, c.create(runtime, answer)
+}
+
+func (c *runtimes) Update(runtime *spec.Runtime) (*spec.Runtime, error) {
+	answer := &spec.Runtime{}
+	return answer, c.update(runtime.Name, runtime, answer)
+}
+
+func (c *runtimes) Delete(name string) error {
+	return c.delete(name)
+}
+
+func (c *runtimes) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func() runtime.Object { return &spec.Runtime{} })
+}
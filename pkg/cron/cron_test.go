@@ -0,0 +1,60 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// a Wednesday
+	start := time.Date(2017, time.March, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2017, time.March, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2017, time.March, 15, 10, 10, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2017, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2017, time.March, 16, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * mon-fri", time.Date(2017, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2017, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2017, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"15,45 8-9 * jan *", time.Date(2018, time.January, 1, 8, 15, 0, 0, time.UTC)},
+		{"@daily", time.Date(2017, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", test.expr, err)
+			continue
+		}
+		if next := s.Next(start); !next.Equal(test.expected) {
+			t.Errorf("Expected %s to next fire at %s but got %s", test.expr, test.expected, next)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected the cron expression `%s` to be invalid", expr)
+		}
+	}
+}
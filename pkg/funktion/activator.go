@@ -0,0 +1,314 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/k8sutil"
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/labels"
+)
+
+const (
+	// DefaultActivationTimeout is how long the activator holds a request while the Function scales up
+	DefaultActivationTimeout = 2 * time.Minute
+
+	activationPollInterval = 500 * time.Millisecond
+	// activityInterval is how often the activator records the activity of a Function it forwards
+	// requests to on its Deployment
+	activityInterval = 30 * time.Second
+	// backendInterval is how long the activator forwards requests to the pods it found for a
+	// Function before looking them up again
+	backendInterval = 5 * time.Second
+)
+
+// Activator is the HTTP proxy which the Services of Functions with an idle timeout point at. It
+// forwards the requests to the ready pods of the Function and records the activity of the
+// Function on its Deployment so that the operator only scales it to zero once it receives no
+// requests. The first request to a Function scaled to zero scales its Deployment back up; requests
+// are held until its pods are ready.
+//
+// The activator must run in the namespace of the Functions it activates in pods labelled with
+// ActivatorLabel which name their container port ActivatorPortName. The Function is the first
+// part of the host name of a request.
+type Activator struct {
+	kclient   kubernetes.Interface
+	logger    log.Logger
+	namespace string
+	timeout   time.Duration
+
+	lock        sync.Mutex
+	activations map[string]*activation
+	backends    map[string]*backend
+	activity    map[string]time.Time
+}
+
+// activation is the scaling up of a Function shared by the requests held for it
+type activation struct {
+	done    chan struct{}
+	targets []*url.URL
+	err     error
+}
+
+// backend holds the ready pods of a Function which requests are forwarded to in turn
+type backend struct {
+	targets []*url.URL
+	found   time.Time
+	count   int
+}
+
+func (b *backend) next() *url.URL {
+	b.count++
+	return b.targets[b.count%len(b.targets)]
+}
+
+// NewActivator creates an activator for the Functions in the given namespace
+func NewActivator(kclient kubernetes.Interface, logger log.Logger, namespace string, timeout time.Duration) *Activator {
+	if timeout <= 0 {
+		timeout = DefaultActivationTimeout
+	}
+	return &Activator{
+		kclient:     kclient,
+		logger:      logger,
+		namespace:   namespace,
+		timeout:     timeout,
+		activations: map[string]*activation{},
+		backends:    map[string]*backend{},
+		activity:    map[string]time.Time{},
+	}
+}
+
+func (a *Activator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := functionNameFromHost(r.Host)
+	if len(name) == 0 {
+		http.Error(w, fmt.Sprintf("Could not find the Function for host %s", r.Host), http.StatusNotFound)
+		return
+	}
+	target, err := a.target(name)
+	if err != nil {
+		a.logger.Log("msg", "failed to activate function", "name", name, "namespace", a.namespace, "err", err)
+		code := http.StatusBadGateway
+		if errors.IsNotFound(err) {
+			code = http.StatusNotFound
+		} else if errors.IsTimeout(err) {
+			code = http.StatusGatewayTimeout
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	a.recordActivity(name)
+	httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+}
+
+// functionNameFromHost returns the first label of the host name of a request
+func functionNameFromHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	return strings.SplitN(host, ".", 2)[0]
+}
+
+// target returns the URL of the pod of the Function with the given name to forward a request to,
+// scaling the Function up first if it has no ready pods
+func (a *Activator) target(name string) (*url.URL, error) {
+	a.lock.Lock()
+	if b := a.backends[name]; b != nil && time.Since(b.found) < backendInterval {
+		target := b.next()
+		a.lock.Unlock()
+		return target, nil
+	}
+	a.lock.Unlock()
+
+	targets, err := a.activate(name)
+	if err != nil {
+		return nil, err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	b := &backend{targets: targets, found: time.Now()}
+	a.backends[name] = b
+	return b.next(), nil
+}
+
+// activate scales up the Function with the given name if need be and returns the URLs of its
+// ready pods. Concurrent requests share the same activation.
+func (a *Activator) activate(name string) ([]*url.URL, error) {
+	a.lock.Lock()
+	act, ok := a.activations[name]
+	if !ok {
+		act = &activation{done: make(chan struct{})}
+		a.activations[name] = act
+		go func() {
+			act.targets, act.err = a.scaleUp(name)
+			close(act.done)
+
+			a.lock.Lock()
+			delete(a.activations, name)
+			a.lock.Unlock()
+		}()
+	}
+	a.lock.Unlock()
+
+	<-act.done
+	return act.targets, act.err
+}
+
+// scaleUp scales the Deployment of the Function up if it is at zero pods and waits for its pods
+// to be ready
+func (a *Activator) scaleUp(name string) ([]*url.URL, error) {
+	function, err := NewClient(a.kclient, nil).Functions(a.namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	replicas := int32(1)
+	if function.Spec.Autoscaling != nil && function.Spec.Autoscaling.MinReplicas > 0 {
+		replicas = function.Spec.Autoscaling.MinReplicas
+	}
+
+	deadline := time.Now().Add(a.timeout)
+	for {
+		// the operator may write back a Deployment at zero pods from its cache so lets check each time
+		if err := a.ensureReplicas(name, replicas); err != nil && !errors.IsConflict(err) {
+			return nil, err
+		}
+		targets, err := a.podURLs(name)
+		if err != nil || len(targets) > 0 {
+			return targets, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.NewTimeoutError(fmt.Sprintf("Function %s was not available after %s", name, a.timeout), 0)
+		}
+		time.Sleep(activationPollInterval)
+	}
+}
+
+// ensureReplicas scales the Deployment of the Function up if it is at zero pods
+func (a *Activator) ensureReplicas(name string, replicas int32) error {
+	deployments := a.kclient.Extensions().Deployments(a.namespace)
+	deployment, err := deployments.Get(name)
+	if err != nil {
+		return err
+	}
+	if !isScaledToZero(deployment) {
+		return nil
+	}
+	deployment.Spec.Replicas = &replicas
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[LastActivityAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if _, err := deployments.Update(deployment); err != nil {
+		return err
+	}
+	a.logger.Log("msg", "activated function", "name", name, "namespace", a.namespace, "replicas", replicas)
+	return nil
+}
+
+// podURLs returns the URLs of the ready pods of the Function on the target port recorded on its
+// Service
+func (a *Activator) podURLs(name string) ([]*url.URL, error) {
+	svc, err := a.kclient.Core().Services(a.namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	port := svc.Annotations[TargetPortAnnotation]
+	if len(port) == 0 {
+		return nil, fmt.Errorf("Service %s has no annotation %s as the Function has no idle timeout", name, TargetPortAnnotation)
+	}
+	selector, err := labels.Parse(FunctionLabel + "=" + name)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := a.kclient.Core().Pods(a.namespace).List(api.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	answer := []*url.URL{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if ready, _ := k8sutil.PodRunningAndReady(*pod); !ready || len(pod.Status.PodIP) == 0 {
+			continue
+		}
+		if p := podPort(pod, port); p > 0 {
+			answer = append(answer, &url.URL{
+				Scheme: "http",
+				Host:   net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(p)),
+			})
+		}
+	}
+	return answer, nil
+}
+
+// podPort resolves the number or name of a target port against the container ports of the pod
+func podPort(pod *v1.Pod, port string) int {
+	if p, err := strconv.Atoi(port); err == nil {
+		return p
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, cp := range container.Ports {
+			if cp.Name == port {
+				return int(cp.ContainerPort)
+			}
+		}
+	}
+	return 0
+}
+
+// recordActivity records on the Deployment of the Function that the activator forwards requests
+// to it, at most every activityInterval, so that the operator does not scale it to zero while
+// it is in use
+func (a *Activator) recordActivity(name string) {
+	now := time.Now()
+	a.lock.Lock()
+	if now.Sub(a.activity[name]) < activityInterval {
+		a.lock.Unlock()
+		return
+	}
+	a.activity[name] = now
+	a.lock.Unlock()
+
+	deployments := a.kclient.Extensions().Deployments(a.namespace)
+	deployment, err := deployments.Get(name)
+	if err == nil {
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		deployment.Annotations[LastActivityAnnotation] = now.UTC().Format(time.RFC3339)
+		_, err = deployments.Update(deployment)
+	}
+	if err != nil {
+		a.logger.Log("msg", "failed to record the activity of function", "name", name, "namespace", a.namespace, "err", err)
+		// lets try again on the next request
+		a.lock.Lock()
+		delete(a.activity, name)
+		a.lock.Unlock()
+	}
+}
@@ -0,0 +1,246 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+func TestFunctionNameFromHost(t *testing.T) {
+	assertEquals(t, functionNameFromHost("hello"), "hello")
+	assertEquals(t, functionNameFromHost("hello.default.svc.cluster.local:8080"), "hello")
+	assertEquals(t, functionNameFromHost("10.0.0.1:80"), "")
+}
+
+func TestScaleToZero(t *testing.T) {
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "nodejs", Namespace: testNamespace},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty:      "module.exports = 1",
+			IdleTimeoutProperty: "10m",
+		},
+	}
+
+	d, err := makeFunctionDeployment(function, nodejs, nil)
+	if err != nil {
+		t.Fatalf("Failed to make the deployment: %v", err)
+	}
+	if len(d.Annotations[LastActivityAnnotation]) == 0 {
+		t.Errorf("Expected the activity of the Function to be recorded on its Deployment")
+	}
+	zero := int32(0)
+	d.Spec.Replicas = &zero
+	d2, err := makeFunctionDeployment(function, nodejs, d)
+	if err != nil {
+		t.Fatalf("Failed to make the deployment: %v", err)
+	}
+	if !isScaledToZero(d2) {
+		t.Errorf("Expected an idle Function to be left at zero pods")
+	}
+
+	svc, err := makeFunctionService(function, nodejs, nil, d2)
+	if err != nil {
+		t.Fatalf("Failed to make the service: %v", err)
+	}
+	assertEquals(t, svc.Spec.Selector[ActivatorLabel], "true")
+	assertEquals(t, svc.Spec.Ports[0].TargetPort.String(), ActivatorPortName)
+	assertEquals(t, svc.Annotations[TargetPortAnnotation], "8888")
+
+	// the requests keep going through the activator once the pods are available
+	one := int32(1)
+	d2.Spec.Replicas = &one
+	d2.Status.AvailableReplicas = 1
+	svc, err = makeFunctionService(function, nodejs, nil, d2)
+	if err != nil {
+		t.Fatalf("Failed to make the service: %v", err)
+	}
+	assertEquals(t, svc.Spec.Selector[ActivatorLabel], "true")
+}
+
+func TestActivator(t *testing.T) {
+	zero := int32(0)
+	kclient := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "hello",
+				Namespace: testNamespace,
+				Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+			},
+			Data: map[string]string{SourceProperty: "module.exports = 1", IdleTimeoutProperty: "10m"},
+		},
+		&v1beta1.Deployment{
+			ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+			Spec:       v1beta1.DeploymentSpec{Replicas: &zero},
+		},
+		&v1.Service{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        "hello",
+				Namespace:   testNamespace,
+				Annotations: map[string]string{TargetPortAnnotation: "http"},
+			},
+		},
+		// lets pretend the pod has already been started
+		readyPod("hello-1", "hello", "172.17.0.5", 8888),
+	)
+	a := NewActivator(kclient, log.NewNopLogger(), testNamespace, time.Second)
+
+	target, err := a.target("hello")
+	if err != nil {
+		t.Fatalf("Failed to activate the Function: %v", err)
+	}
+	assertEquals(t, target.String(), "http://172.17.0.5:8888")
+	d, err := kclient.Extensions().Deployments(testNamespace).Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Deployment: %v", err)
+	}
+	if d.Spec.Replicas == nil || *d.Spec.Replicas != 1 {
+		t.Errorf("Expected the Deployment to be scaled to 1 pod but got %v", d.Spec.Replicas)
+	}
+	if len(d.Annotations[LastActivityAnnotation]) == 0 {
+		t.Errorf("Expected the activation to be recorded on the Deployment")
+	}
+
+	if _, err := a.target("missing"); err == nil {
+		t.Errorf("Expected activating a missing Function to fail")
+	}
+}
+
+func TestActivatorKeepsFunctionWithTrafficRunning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello")
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse the URL %s: %v", server.URL, err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatalf("Failed to split the host %s: %v", u.Host, err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatalf("Failed to parse the port %s: %v", port, err)
+	}
+
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{SourceProperty: "module.exports = 1", IdleTimeoutProperty: "10m"},
+	}
+	// the Function was activated longer ago than its idle timeout but has had requests since
+	one := int32(1)
+	deployment := &v1beta1.Deployment{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "hello",
+			Namespace:   testNamespace,
+			Annotations: map[string]string{LastActivityAnnotation: time.Now().Add(-15 * time.Minute).UTC().Format(time.RFC3339)},
+		},
+		Spec: v1beta1.DeploymentSpec{Replicas: &one},
+	}
+	kclient := fake.NewSimpleClientset(
+		function,
+		deployment,
+		&v1.Service{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        "hello",
+				Namespace:   testNamespace,
+				Annotations: map[string]string{TargetPortAnnotation: port},
+			},
+		},
+		readyPod("hello-1", "hello", host, portNumber),
+	)
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	if idle, err := c.scaleToZero(testNamespace+"/hello", function, deployment); err != nil || !idle {
+		t.Fatalf("Expected a Function without recorded requests to be scaled to zero but got %v %v", idle, err)
+	}
+	deployment.Spec.Replicas = &one
+
+	a := NewActivator(kclient, log.NewNopLogger(), testNamespace, time.Second)
+	for i := 0; i < 3; i++ {
+		r, err := http.NewRequest("GET", "http://hello."+testNamespace+"/", nil)
+		if err != nil {
+			t.Fatalf("Failed to create the request: %v", err)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected the request to be forwarded to the pod but got %d: %s", w.Code, w.Body.String())
+		}
+		assertEquals(t, w.Body.String(), "Hello")
+	}
+
+	deployment, err = kclient.Extensions().Deployments(testNamespace).Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Deployment: %v", err)
+	}
+	if idle := time.Since(lastActivity(deployment)); idle > time.Minute {
+		t.Errorf("Expected the requests to be recorded on the Deployment but the Function was idle for %s", idle)
+	}
+	if idle, err := c.scaleToZero(testNamespace+"/hello", function, deployment); err != nil || idle {
+		t.Errorf("Expected a Function with requests to keep running but got %v %v", idle, err)
+	}
+}
+
+// readyPod returns a ready pod of the Function with the given name serving on the given port
+func readyPod(name string, function string, ip string, port int) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{FunctionLabel: function},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:  "function",
+				Ports: []v1.ContainerPort{{Name: "http", ContainerPort: int32(port)}},
+			}},
+		},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			PodIP:      ip,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
@@ -0,0 +1,127 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+// ValidatePath is the path on which the AdmissionHandler serves the validating admission webhook
+const ValidatePath = "/validate"
+
+// admissionReview is the admission.k8s.io/v1beta1 AdmissionReview sent to webhooks by the API server
+type admissionReview struct {
+	unversioned.TypeMeta `json:",inline"`
+	Request              *admissionRequest  `json:"request,omitempty"`
+	Response             *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID       string           `json:"uid"`
+	Kind      groupVersionKind `json:"kind"`
+	Namespace string           `json:"namespace,omitempty"`
+	Operation string           `json:"operation"`
+	Object    json.RawMessage  `json:"object,omitempty"`
+	OldObject json.RawMessage  `json:"oldObject,omitempty"`
+}
+
+type groupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+type admissionResponse struct {
+	UID     string              `json:"uid"`
+	Allowed bool                `json:"allowed"`
+	Result  *unversioned.Status `json:"status,omitempty"`
+}
+
+// AdmissionHandler returns the validating admission webhook which rejects Functions, Flows,
+// Runtimes and Connectors the operator would fail to reconcile
+func (c *Operator) AdmissionHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ValidatePath, c.serveValidate)
+	return mux
+}
+
+func (c *Operator) serveValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review := admissionReview{}
+	if err := json.Unmarshal(data, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("expected an AdmissionReview request: %v", err), http.StatusBadRequest)
+		return
+	}
+	review.Response = c.admit(review.Request)
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		c.logger.Log("msg", "failed to write admission response", "err", err)
+	}
+}
+
+// admit validates the ConfigMap in an admission request
+func (c *Operator) admit(req *admissionRequest) *admissionResponse {
+	response := &admissionResponse{UID: req.UID, Allowed: true}
+	if req.Kind.Kind != "ConfigMap" || (req.Operation != "CREATE" && req.Operation != "UPDATE") {
+		return response
+	}
+	cm := &v1.ConfigMap{}
+	if err := json.Unmarshal(req.Object, cm); err != nil {
+		return denied(response, fmt.Errorf("Failed to decode ConfigMap: %v", err))
+	}
+	if len(cm.Namespace) == 0 {
+		cm.Namespace = req.Namespace
+	}
+	if len(req.OldObject) > 0 {
+		// lets not reject the status written by the operator on a resource which
+		// became invalid due to a change elsewhere such as its Runtime being deleted
+		old := &v1.ConfigMap{}
+		if err := json.Unmarshal(req.OldObject, old); err == nil && specHash(old) == specHash(cm) {
+			return response
+		}
+	}
+	if err := c.validate(cm); err != nil {
+		c.logger.Log("msg", "rejected invalid resource", "kind", cm.Labels[KindLabel], "name", cm.Name, "namespace", cm.Namespace, "err", err)
+		return denied(response, err)
+	}
+	return response
+}
+
+func denied(response *admissionResponse, err error) *admissionResponse {
+	response.Allowed = false
+	response.Result = &unversioned.Status{
+		Status:  unversioned.StatusFailure,
+		Message: err.Error(),
+		Reason:  unversioned.StatusReason(errorReason(err)),
+		Code:    http.StatusUnprocessableEntity,
+	}
+	return response
+}
@@ -0,0 +1,108 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestAdmissionHandler(t *testing.T) {
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	c, err := newOperator(fake.NewSimpleClientset(nodejs), log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	handler := c.AdmissionHandler()
+
+	function := func(runtime string, source string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   "hello",
+				Labels: map[string]string{KindLabel: FunctionKind, RuntimeLabel: runtime},
+			},
+			Data: map[string]string{SourceProperty: source},
+		}
+	}
+	flow := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   "timer",
+			Labels: map[string]string{KindLabel: FlowKind, ConnectorLabel: "timer"},
+		},
+		Data: map[string]string{FunktionYmlProperty: "flows: ["},
+	}
+
+	tests := []struct {
+		name    string
+		object  *v1.ConfigMap
+		old     *v1.ConfigMap
+		allowed bool
+	}{
+		{"valid function", function("nodejs", "module.exports = 1"), nil, true},
+		{"function without source", function("nodejs", ""), nil, false},
+		{"function with a missing runtime", function("python", "print 1"), nil, false},
+		{"status update of an invalid function", function("python", "print 1"), function("python", "print 1"), true},
+		{"flow with invalid funktion.yml", flow, nil, false},
+		{"other ConfigMap", &v1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "other"}}, nil, true},
+	}
+	for _, test := range tests {
+		req := &admissionRequest{
+			UID:       "1234",
+			Kind:      groupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			Namespace: testNamespace,
+			Operation: "CREATE",
+		}
+		req.Object, _ = json.Marshal(test.object)
+		if test.old != nil {
+			req.Operation = "UPDATE"
+			req.OldObject, _ = json.Marshal(test.old)
+		}
+		body, _ := json.Marshal(&admissionReview{Request: req})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", ValidatePath, bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200 but got %d: %s", test.name, w.Code, w.Body.String())
+		}
+		review := admissionReview{}
+		if err := json.Unmarshal(w.Body.Bytes(), &review); err != nil || review.Response == nil {
+			t.Fatalf("%s: failed to parse the response %s: %v", test.name, w.Body.String(), err)
+		}
+		assertEquals(t, review.Response.UID, "1234")
+		if review.Response.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed to be %v but got %v", test.name, test.allowed, review.Response.Allowed)
+		}
+		if !test.allowed && (review.Response.Result == nil || len(review.Response.Result.Message) == 0) {
+			t.Errorf("%s: expected a message explaining why the resource was rejected", test.name)
+		}
+	}
+}
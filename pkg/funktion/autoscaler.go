@@ -0,0 +1,198 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"k8s.io/client-go/1.5/pkg/api/resource"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	autoscaling "k8s.io/client-go/1.5/pkg/apis/autoscaling/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+const (
+	// MinReplicasProperty is the data key for the minimum number of pods of an autoscaled Function
+	MinReplicasProperty = "minReplicas"
+	// MaxReplicasProperty is the data key for the maximum number of pods of a Function. Setting it
+	// enables autoscaling of the Function with a HorizontalPodAutoscaler.
+	MaxReplicasProperty = "maxReplicas"
+	// TargetCPUProperty is the data key for the average CPU utilization percentage of the pods of
+	// an autoscaled Function
+	TargetCPUProperty = "targetCPUUtilizationPercentage"
+	// TargetConcurrencyProperty is the data key for the average number of concurrent requests per
+	// pod of an autoscaled Function. The Runtime must expose the concurrency custom metric.
+	TargetConcurrencyProperty = "targetConcurrency"
+
+	// ConcurrencyMetric is the name of the custom metric used to scale on concurrency
+	ConcurrencyMetric = "concurrency"
+	// CustomMetricsTargetAnnotation is the annotation on a HorizontalPodAutoscaler which holds
+	// the targets of the custom metrics
+	CustomMetricsTargetAnnotation = "alpha/target.custom-metrics.podautoscaler.kubernetes.io"
+)
+
+// autoscalingSettings are the autoscaling settings of a Function
+type autoscalingSettings struct {
+	minReplicas       int32
+	maxReplicas       int32
+	targetCPU         int32
+	targetConcurrency int32
+}
+
+// getAutoscalingSettings returns the autoscaling settings of the given Function or nil
+// if the Function is not autoscaled
+func getAutoscalingSettings(function *v1.ConfigMap) (*autoscalingSettings, error) {
+	data := function.Data
+	if len(data[MaxReplicasProperty]) == 0 {
+		for _, key := range []string{MinReplicasProperty, TargetCPUProperty, TargetConcurrencyProperty} {
+			if len(data[key]) > 0 {
+				return nil, reasonErrorf(InvalidAutoscalerReason, "Property `%s` on the Function ConfigMap %s requires property `%s`", key, function.Name, MaxReplicasProperty)
+			}
+		}
+		return nil, nil
+	}
+	settings := &autoscalingSettings{minReplicas: 1}
+	for key, value := range map[string]*int32{
+		MinReplicasProperty:       &settings.minReplicas,
+		MaxReplicasProperty:       &settings.maxReplicas,
+		TargetCPUProperty:         &settings.targetCPU,
+		TargetConcurrencyProperty: &settings.targetConcurrency,
+	} {
+		text := data[key]
+		if len(text) == 0 {
+			continue
+		}
+		i, err := strconv.ParseInt(text, 10, 32)
+		if err != nil || i < 1 {
+			return nil, reasonErrorf(InvalidAutoscalerReason, "Property `%s` on the Function ConfigMap %s must be a positive number but was `%s`", key, function.Name, text)
+		}
+		*value = int32(i)
+	}
+	if settings.minReplicas > settings.maxReplicas {
+		return nil, reasonErrorf(InvalidAutoscalerReason, "Property `%s` on the Function ConfigMap %s is greater than `%s`", MinReplicasProperty, function.Name, MaxReplicasProperty)
+	}
+	return settings, nil
+}
+
+// replicas returns the number of pods of the autoscaled Deployment keeping the number chosen
+// by the HorizontalPodAutoscaler within the range of the settings
+func (s *autoscalingSettings) replicas(old *v1beta1.Deployment) *int32 {
+	answer := s.minReplicas
+	if old != nil && old.Spec.Replicas != nil {
+		answer = *old.Spec.Replicas
+	}
+	if answer < s.minReplicas {
+		answer = s.minReplicas
+	}
+	if answer > s.maxReplicas {
+		answer = s.maxReplicas
+	}
+	return &answer
+}
+
+func makeFunctionAutoscaler(function *v1.ConfigMap, settings *autoscalingSettings, deployment *v1beta1.Deployment) (*autoscaling.HorizontalPodAutoscaler, error) {
+	minReplicas := settings.minReplicas
+	hpa := &autoscaling.HorizontalPodAutoscaler{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        deployment.Name,
+			Namespace:   function.Namespace,
+			Annotations: map[string]string{},
+		},
+		Spec: autoscaling.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{
+				APIVersion: "extensions/v1beta1",
+				Kind:       DeploymentKind,
+				Name:       deployment.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: settings.maxReplicas,
+		},
+	}
+	if settings.targetCPU > 0 {
+		targetCPU := settings.targetCPU
+		hpa.Spec.TargetCPUUtilizationPercentage = &targetCPU
+	}
+	if settings.targetConcurrency > 0 {
+		targets := map[string][]map[string]interface{}{
+			"items": {
+				{
+					"name":  ConcurrencyMetric,
+					"value": resource.NewQuantity(int64(settings.targetConcurrency), resource.DecimalSI),
+				},
+			},
+		}
+		data, err := json.Marshal(targets)
+		if err != nil {
+			return nil, err
+		}
+		hpa.Annotations[CustomMetricsTargetAnnotation] = string(data)
+	}
+	setOwner(&hpa.ObjectMeta, function, FunctionKind)
+	return hpa, nil
+}
+
+// syncAutoscaler creates or updates the HorizontalPodAutoscaler of the given Function or
+// removes it if the Function is no longer autoscaled
+func (c *Operator) syncAutoscaler(key string, function *v1.ConfigMap, deployment *v1beta1.Deployment) error {
+	settings, err := getAutoscalingSettings(function)
+	if err != nil {
+		return err
+	}
+	hpas := c.kclient.Autoscaling().HorizontalPodAutoscalers(function.Namespace)
+	obj, exists, err := c.autoscalerInf.GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	var old *autoscaling.HorizontalPodAutoscaler
+	if exists {
+		old = obj.(*autoscaling.HorizontalPodAutoscaler)
+		if _, _, ok := managedBy(&old.ObjectMeta); !ok {
+			return reasonErrorf(InvalidAutoscalerReason, "HorizontalPodAutoscaler %s was not created for Function %s", old.Name, function.Name)
+		}
+	}
+
+	if settings == nil {
+		if old == nil {
+			return nil
+		}
+		if err := hpas.Delete(old.Name, deleteOptions()); err != nil {
+			return fmt.Errorf("delete autoscaler: %s", err)
+		}
+		c.recorder.Normal(function, DeletedReason, "Deleted HorizontalPodAutoscaler %s", old.Name)
+		return nil
+	}
+
+	hpa, err := makeFunctionAutoscaler(function, settings, deployment)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		if _, err := hpas.Create(hpa); err != nil {
+			return fmt.Errorf("create autoscaler: %s", err)
+		}
+		c.recorder.Normal(function, CreatedReason, "Created HorizontalPodAutoscaler %s", hpa.Name)
+		return nil
+	}
+	if reflect.DeepEqual(hpa.Spec, old.Spec) && reflect.DeepEqual(hpa.Annotations, old.Annotations) &&
+		reflect.DeepEqual(hpa.Labels, old.Labels) && reflect.DeepEqual(hpa.OwnerReferences, old.OwnerReferences) {
+		return nil
+	}
+	hpa.ResourceVersion = old.ResourceVersion
+	_, err = hpas.Update(hpa)
+	return err
+}
@@ -0,0 +1,134 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+func TestAutoscalingSettings(t *testing.T) {
+	function := func(data map[string]string) *v1.ConfigMap {
+		data[SourceProperty] = "module.exports = 1"
+		return &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "hello",
+				Namespace: testNamespace,
+				Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+			},
+			Data: data,
+		}
+	}
+
+	settings, err := getAutoscalingSettings(function(map[string]string{}))
+	if err != nil || settings != nil {
+		t.Errorf("Expected no autoscaling without settings but got %v %v", settings, err)
+	}
+	for _, data := range []map[string]string{
+		{MinReplicasProperty: "2"},
+		{MaxReplicasProperty: "two"},
+		{MaxReplicasProperty: "0"},
+		{MinReplicasProperty: "3", MaxReplicasProperty: "2"},
+	} {
+		if _, err := getAutoscalingSettings(function(data)); errorReason(err) != InvalidAutoscalerReason {
+			t.Errorf("Expected the settings %v to be invalid but got %v", data, err)
+		}
+	}
+
+	autoscaled := function(map[string]string{
+		MinReplicasProperty:       "2",
+		MaxReplicasProperty:       "5",
+		TargetConcurrencyProperty: "10",
+	})
+	settings, err = getAutoscalingSettings(autoscaled)
+	if err != nil || settings == nil {
+		t.Fatalf("Expected autoscaling settings but got %v %v", settings, err)
+	}
+	hpa, err := makeFunctionAutoscaler(autoscaled, settings, &v1beta1.Deployment{ObjectMeta: v1.ObjectMeta{Name: "hello"}})
+	if err != nil {
+		t.Fatalf("Failed to make the autoscaler: %v", err)
+	}
+	assertEquals(t, hpa.Spec.ScaleTargetRef.Name, "hello")
+	if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 5 || hpa.Spec.TargetCPUUtilizationPercentage != nil {
+		t.Errorf("Unexpected autoscaler spec %#v", hpa.Spec)
+	}
+	assertEquals(t, hpa.Annotations[CustomMetricsTargetAnnotation], `{"items":[{"name":"concurrency","value":"10"}]}`)
+	if _, _, ok := managedBy(&hpa.ObjectMeta); !ok {
+		t.Errorf("Expected the autoscaler to be managed by the Function")
+	}
+
+	// the number of pods chosen by the autoscaler is kept within the settings
+	replicas := int32(7)
+	old := &v1beta1.Deployment{Spec: v1beta1.DeploymentSpec{Replicas: &replicas}}
+	if r := *settings.replicas(old); r != 5 {
+		t.Errorf("Expected 5 replicas but got %d", r)
+	}
+	replicas = 3
+	if r := *settings.replicas(old); r != 3 {
+		t.Errorf("Expected 3 replicas but got %d", r)
+	}
+	if r := *settings.replicas(nil); r != 2 {
+		t.Errorf("Expected 2 replicas but got %d", r)
+	}
+}
+
+func TestAutoscalingIsRejectedWithIdleTimeout(t *testing.T) {
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	hello := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty:      "module.exports = 1",
+			MaxReplicasProperty: "5",
+			IdleTimeoutProperty: "10m",
+		},
+	}
+	kclient := fake.NewSimpleClientset(nodejs, hello)
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	c.runtimeInf.GetStore().Add(nodejs)
+	c.functionInf.GetStore().Add(hello)
+
+	if err := c.validate(hello); errorReason(err) != InvalidIdleTimeoutReason {
+		t.Errorf("Expected autoscaling with an idle timeout to be invalid but got %v", err)
+	}
+	if err := c.syncFunction(testNamespace + "/hello"); errorReason(err) != InvalidIdleTimeoutReason {
+		t.Errorf("Expected syncing an autoscaled Function with an idle timeout to fail but got %v", err)
+	}
+	for _, action := range kclient.Actions() {
+		if action.GetVerb() == "create" && action.GetResource().Resource == "horizontalpodautoscalers" {
+			t.Errorf("Expected no HorizontalPodAutoscaler for a Function with an idle timeout")
+		}
+	}
+}
@@ -24,8 +24,8 @@ import (
 )
 
 // NewConfigMapListWatch returns a new ListWatch for ConfigMaps with the given listOptions
-func NewConfigMapListWatch(client *kubernetes.Clientset, listOpts api.ListOptions, namespace string) *cache.ListWatch {
-	configMaps := client.ConfigMaps(namespace)
+func NewConfigMapListWatch(client kubernetes.Interface, listOpts api.ListOptions, namespace string) *cache.ListWatch {
+	configMaps := client.Core().ConfigMaps(namespace)
 
 	return &cache.ListWatch{
 		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
@@ -38,9 +38,9 @@ func NewConfigMapListWatch(client *kubernetes.Clientset, listOpts api.ListOption
 }
 
 // NewServiceListWatch creates a watch on services
-func NewServiceListWatch(client *kubernetes.Clientset, namespace string) *cache.ListWatch {
+func NewServiceListWatch(client kubernetes.Interface, namespace string) *cache.ListWatch {
 	listOpts := api.ListOptions{}
-	services := client.Services(namespace)
+	services := client.Core().Services(namespace)
 	return &cache.ListWatch{
 		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
 			return services.List(listOpts)
@@ -51,6 +51,34 @@ func NewServiceListWatch(client *kubernetes.Clientset, namespace string) *cache.
 	}
 }
 
+// NewAutoscalerListWatch creates a watch on horizontal pod autoscalers
+func NewAutoscalerListWatch(client kubernetes.Interface, namespace string) *cache.ListWatch {
+	listOpts := api.ListOptions{}
+	autoscalers := client.Autoscaling().HorizontalPodAutoscalers(namespace)
+	return &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return autoscalers.List(listOpts)
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return autoscalers.Watch(listOpts)
+		},
+	}
+}
+
+// NewDeploymentListWatch creates a watch on deployments
+func NewDeploymentListWatch(client kubernetes.Interface, namespace string) *cache.ListWatch {
+	listOpts := api.ListOptions{}
+	deployments := client.Extensions().Deployments(namespace)
+	return &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return deployments.List(listOpts)
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return deployments.Watch(listOpts)
+		},
+	}
+}
+
 // CreateFlowListOptions returns the default selector for Flow resources
 func CreateFlowListOptions() (*api.ListOptions, error) {
 	return createKindListOptions(FlowKind)
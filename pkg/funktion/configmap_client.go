@@ -0,0 +1,788 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	funktionclient "github.com/funktionio/funktion/pkg/client"
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/labels"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/watch"
+)
+
+// Client manages the Functions, Flows, Runtimes and Connectors reconciled by the operator as
+// typed resources so that callers need not know the ConfigMap conventions. It implements the
+// same interface as the client of the typed ThirdPartyResources.
+//
+// Resources are read from the ConfigMaps the operator reconciles which also hold their status.
+// Once the typed resources are registered new resources are created as typed resources and the
+// ConfigMaps which the operator renders from typed resources are changed and deleted through
+// them, as the operator would otherwise revert the change.
+type Client struct {
+	kclient kubernetes.Interface
+	tclient funktionclient.Interface
+}
+
+var _ funktionclient.Interface = &Client{}
+
+// NewClient creates a client for the funktion resources. The typed client may be nil if only
+// ConfigMaps are used.
+func NewClient(kclient kubernetes.Interface, tclient funktionclient.Interface) *Client {
+	return &Client{kclient: kclient, tclient: tclient}
+}
+
+// Functions returns the Functions in the given namespace
+func (c *Client) Functions(namespace string) funktionclient.FunctionInterface {
+	return &configMapFunctions{c.resource(namespace, FunctionKind, spec.FunctionResource)}
+}
+
+// Flows returns the Flows in the given namespace
+func (c *Client) Flows(namespace string) funktionclient.FlowInterface {
+	return &configMapFlows{c.resource(namespace, FlowKind, spec.FlowResource)}
+}
+
+// Runtimes returns the Runtimes in the given namespace
+func (c *Client) Runtimes(namespace string) funktionclient.RuntimeInterface {
+	return &configMapRuntimes{c.resource(namespace, RuntimeKind, spec.RuntimeResource)}
+}
+
+// Connectors returns the Connectors in the given namespace
+func (c *Client) Connectors(namespace string) funktionclient.ConnectorInterface {
+	return &configMapConnectors{c.resource(namespace, ConnectorKind, spec.ConnectorResource)}
+}
+
+func (c *Client) resource(namespace string, kind string, r spec.Resource) *configMapResource {
+	return &configMapResource{
+		kclient:   c.kclient,
+		tclient:   c.tclient,
+		namespace: namespace,
+		kind:      kind,
+		resource:  r,
+	}
+}
+
+// configMapResource performs the ConfigMap calls for one kind of funktion resource
+type configMapResource struct {
+	kclient   kubernetes.Interface
+	tclient   funktionclient.Interface
+	namespace string
+	kind      string
+	resource  spec.Resource
+}
+
+// listOptions restricts the given options to the ConfigMaps of this kind
+func (r *configMapResource) listOptions(opts api.ListOptions) (api.ListOptions, error) {
+	text := KindLabel + "=" + r.kind
+	if opts.LabelSelector != nil && !opts.LabelSelector.Empty() {
+		text += "," + opts.LabelSelector.String()
+	}
+	selector, err := labels.Parse(text)
+	if err != nil {
+		return opts, err
+	}
+	opts.LabelSelector = selector
+	return opts, nil
+}
+
+func (r *configMapResource) list(opts api.ListOptions) (*v1.ConfigMapList, error) {
+	opts, err := r.listOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.kclient.Core().ConfigMaps(r.namespace).List(opts)
+}
+
+func (r *configMapResource) get(name string) (*v1.ConfigMap, error) {
+	cm, err := r.kclient.Core().ConfigMaps(r.namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if cm.Labels[KindLabel] != r.kind {
+		return nil, errors.NewNotFound(r.groupResource(), name)
+	}
+	return cm, nil
+}
+
+func (r *configMapResource) create(cm *v1.ConfigMap) (*v1.ConfigMap, error) {
+	cm.Namespace = r.namespace
+	return r.kclient.Core().ConfigMaps(r.namespace).Create(cm)
+}
+
+// createTyped returns true if a new resource with the given name is to be created as a typed
+// resource as the typed resources are registered
+func (r *configMapResource) createTyped(name string) (bool, error) {
+	if r.tclient == nil {
+		return false, nil
+	}
+	_, err := r.kclient.Extensions().ThirdPartyResources().Get(r.resource.TPRName())
+	if err != nil {
+		if errors.IsNotFound(err) || errors.IsForbidden(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// the operator would take over a ConfigMap with the same name so lets fail as for a ConfigMap
+	_, err = r.kclient.Core().ConfigMaps(r.namespace).Get(name)
+	if err == nil {
+		return false, errors.NewAlreadyExists(r.groupResource(), name)
+	}
+	if !errors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// isTyped returns true if the ConfigMap is rendered from a typed resource by the operator so
+// that it has to be changed through the typed resource
+func (r *configMapResource) isTyped(cm *v1.ConfigMap) (bool, error) {
+	if cm.Annotations[TypedResourceAnnotation] != "true" {
+		return false, nil
+	}
+	if r.tclient == nil {
+		return false, fmt.Errorf("%s %s is managed through the typed %s resource", r.resource.Kind, cm.Name, r.resource.Plural)
+	}
+	return true, nil
+}
+
+// update replaces the ConfigMap for a typed resource keeping the status written by the operator
+// and the data which is not part of the spec
+func (r *configMapResource) update(meta *v1.ObjectMeta, cm *v1.ConfigMap, old *v1.ConfigMap) (*v1.ConfigMap, error) {
+	mergeConfigMapData(old, cm)
+	// the status is owned by the operator so lets not overwrite it with a stale copy
+	delete(cm.Annotations, StatusAnnotation)
+	if status, ok := old.Annotations[StatusAnnotation]; ok {
+		cm.Annotations[StatusAnnotation] = status
+	}
+	cm.Namespace = r.namespace
+	cm.ResourceVersion = meta.ResourceVersion
+	if len(cm.ResourceVersion) == 0 {
+		cm.ResourceVersion = old.ResourceVersion
+	}
+	return r.kclient.Core().ConfigMaps(r.namespace).Update(cm)
+}
+
+// delete deletes the ConfigMap with the given name or, if it is rendered from a typed resource,
+// the typed resource using the given function
+func (r *configMapResource) delete(name string, deleteTyped func(name string) error) error {
+	cm, err := r.get(name)
+	if err != nil {
+		return err
+	}
+	typed, err := r.isTyped(cm)
+	if err != nil {
+		return err
+	}
+	if typed {
+		// the operator deletes the ConfigMap along with the typed resource
+		return deleteTyped(name)
+	}
+	return r.kclient.Core().ConfigMaps(r.namespace).Delete(name, nil)
+}
+
+// watch watches the ConfigMaps of this kind converting them into typed resources
+func (r *configMapResource) watch(opts api.ListOptions, convert func(cm *v1.ConfigMap) (runtime.Object, error)) (watch.Interface, error) {
+	opts, err := r.listOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	w, err := r.kclient.Core().ConfigMaps(r.namespace).Watch(opts)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		cm, ok := in.Object.(*v1.ConfigMap)
+		if !ok {
+			return in, true
+		}
+		obj, err := convert(cm)
+		if err != nil {
+			return watch.Event{
+				Type: watch.Error,
+				Object: &unversioned.Status{
+					Status:  unversioned.StatusFailure,
+					Message: err.Error(),
+				},
+			}, true
+		}
+		return watch.Event{Type: in.Type, Object: obj}, true
+	}), nil
+}
+
+func (r *configMapResource) groupResource() unversioned.GroupResource {
+	return unversioned.GroupResource{Group: spec.Group, Resource: r.resource.Plural}
+}
+
+// withConfigMapMeta copies the server populated metadata and the status of the ConfigMap
+// onto the typed resource converted from it
+func withConfigMapMeta(meta *v1.ObjectMeta, cm *v1.ConfigMap) {
+	meta.ResourceVersion = cm.ResourceVersion
+	meta.UID = cm.UID
+	meta.CreationTimestamp = cm.CreationTimestamp
+	if status, ok := cm.Annotations[StatusAnnotation]; ok {
+		meta.Annotations[StatusAnnotation] = status
+	}
+}
+
+// typedObjectMeta returns the metadata of a typed resource changed by the client leaving out the
+// annotations written by the operator on the ConfigMap
+func typedObjectMeta(meta *v1.ObjectMeta, current *v1.ObjectMeta) v1.ObjectMeta {
+	answer := v1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+	if current != nil {
+		answer.Namespace = current.Namespace
+		answer.ResourceVersion = current.ResourceVersion
+		answer.UID = current.UID
+		answer.CreationTimestamp = current.CreationTimestamp
+	}
+	for k, v := range meta.Labels {
+		answer.Labels[k] = v
+	}
+	for k, v := range meta.Annotations {
+		answer.Annotations[k] = v
+	}
+	delete(answer.Annotations, StatusAnnotation)
+	delete(answer.Annotations, TypedResourceAnnotation)
+	return answer
+}
+
+// checkResourceVersion returns a conflict if the resource changed by the client was read before the
+// latest change of the ConfigMap or the typed resource it is rendered from. Resources read from the
+// ConfigMap have its version while those returned by the typed calls have the typed version.
+func (r *configMapResource) checkResourceVersion(meta *v1.ObjectMeta, cm *v1.ConfigMap, current *v1.ObjectMeta) error {
+	version := meta.ResourceVersion
+	if len(version) == 0 || version == cm.ResourceVersion || version == current.ResourceVersion {
+		return nil
+	}
+	return errors.NewConflict(r.groupResource(), meta.Name, fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+}
+
+// withStatus copies the status recorded on a ConfigMap onto the typed resource rendered into it
+func withStatus(meta *v1.ObjectMeta, cm *v1.ConfigMap) {
+	if status, ok := cm.Annotations[StatusAnnotation]; ok {
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		meta.Annotations[StatusAnnotation] = status
+	}
+}
+
+// GetObjectStatus returns the Status recorded on the metadata of a Function or Flow
+// returned by a Client or nil if the operator has not written one yet
+func GetObjectStatus(meta *v1.ObjectMeta) (*Status, error) {
+	return GetStatus(&v1.ConfigMap{ObjectMeta: *meta})
+}
+
+// ConversionError is returned by the List calls of a Client along with the resources which could
+// be converted when some of the ConfigMaps could not be converted
+type ConversionError struct {
+	Kind string
+	// Failed holds the reason each ConfigMap could not be converted by its name
+	Failed map[string]error
+}
+
+func (e *ConversionError) Error() string {
+	names := []string{}
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := []string{}
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("Failed to convert %s %s: %v", e.Kind, name, e.Failed[name]))
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ListFailures returns the reason each ConfigMap could not be converted by its name if the error
+// was returned by a List call along with the resources which could be converted, otherwise nil
+func ListFailures(err error) map[string]error {
+	if ce, ok := err.(*ConversionError); ok {
+		return ce.Failed
+	}
+	return nil
+}
+
+// conversionError returns the error for the ConfigMaps which could not be converted if any
+func (r *configMapResource) conversionError(failed map[string]error) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return &ConversionError{Kind: r.resource.Kind, Failed: failed}
+}
+
+func listMeta(list *v1.ConfigMapList) unversioned.ListMeta {
+	return unversioned.ListMeta{ResourceVersion: list.ResourceVersion}
+}
+
+type configMapFunctions struct {
+	*configMapResource
+}
+
+func toFunction(cm *v1.ConfigMap) (*spec.Function, error) {
+	function, err := ConfigMapToFunction(cm)
+	if err != nil {
+		return nil, err
+	}
+	withConfigMapMeta(&function.ObjectMeta, cm)
+	return function, nil
+}
+
+func (c *configMapFunctions) List(opts api.ListOptions) (*spec.FunctionList, error) {
+	cms, err := c.list(opts)
+	if err != nil {
+		return nil, err
+	}
+	answer := &spec.FunctionList{TypeMeta: typeMeta(FunctionKind + "List"), ListMeta: listMeta(cms)}
+	failed := map[string]error{}
+	for i := range cms.Items {
+		function, err := toFunction(&cms.Items[i])
+		if err != nil {
+			// lets not hide the other resources when one of them is broken
+			failed[cms.Items[i].Name] = err
+			continue
+		}
+		answer.Items = append(answer.Items, function)
+	}
+	return answer, c.conversionError(failed)
+}
+
+func (c *configMapFunctions) Get(name string) (*spec.Function, error) {
+	cm, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return toFunction(cm)
+}
+
+func (c *configMapFunctions) Create(function *spec.Function) (*spec.Function, error) {
+	cm, err := FunctionToConfigMap(function)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.createTyped(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		return c.tclient.Functions(c.namespace).Create(&spec.Function{
+			TypeMeta:   typeMeta(FunctionKind),
+			ObjectMeta: typedObjectMeta(&function.ObjectMeta, nil),
+			Spec:       function.Spec,
+		})
+	}
+	cm, err = c.create(cm)
+	if err != nil {
+		return nil, err
+	}
+	return toFunction(cm)
+}
+
+func (c *configMapFunctions) Update(function *spec.Function) (*spec.Function, error) {
+	cm, err := FunctionToConfigMap(function)
+	if err != nil {
+		return nil, err
+	}
+	old, err := c.get(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.isTyped(old)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		current, err := c.tclient.Functions(c.namespace).Get(cm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkResourceVersion(&function.ObjectMeta, old, &current.ObjectMeta); err != nil {
+			return nil, err
+		}
+		current.ObjectMeta = typedObjectMeta(&function.ObjectMeta, &current.ObjectMeta)
+		current.Spec = function.Spec
+		updated, err := c.tclient.Functions(c.namespace).Update(current)
+		if err != nil {
+			return nil, err
+		}
+		withStatus(&updated.ObjectMeta, old)
+		return updated, nil
+	}
+	cm, err = c.update(&function.ObjectMeta, cm, old)
+	if err != nil {
+		return nil, err
+	}
+	return toFunction(cm)
+}
+
+func (c *configMapFunctions) Delete(name string) error {
+	return c.delete(name, func(name string) error {
+		return c.tclient.Functions(c.namespace).Delete(name)
+	})
+}
+
+func (c *configMapFunctions) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func(cm *v1.ConfigMap) (runtime.Object, error) {
+		return toFunction(cm)
+	})
+}
+
+type configMapFlows struct {
+	*configMapResource
+}
+
+func toFlow(cm *v1.ConfigMap) (*spec.Flow, error) {
+	flow, err := ConfigMapToFlow(cm)
+	if err != nil {
+		return nil, err
+	}
+	withConfigMapMeta(&flow.ObjectMeta, cm)
+	return flow, nil
+}
+
+func (c *configMapFlows) List(opts api.ListOptions) (*spec.FlowList, error) {
+	cms, err := c.list(opts)
+	if err != nil {
+		return nil, err
+	}
+	answer := &spec.FlowList{TypeMeta: typeMeta(FlowKind + "List"), ListMeta: listMeta(cms)}
+	failed := map[string]error{}
+	for i := range cms.Items {
+		flow, err := toFlow(&cms.Items[i])
+		if err != nil {
+			// lets not hide the other resources when one of them is broken
+			failed[cms.Items[i].Name] = err
+			continue
+		}
+		answer.Items = append(answer.Items, flow)
+	}
+	return answer, c.conversionError(failed)
+}
+
+func (c *configMapFlows) Get(name string) (*spec.Flow, error) {
+	cm, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return toFlow(cm)
+}
+
+func (c *configMapFlows) Create(flow *spec.Flow) (*spec.Flow, error) {
+	cm, err := FlowToConfigMap(flow)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.createTyped(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		return c.tclient.Flows(c.namespace).Create(&spec.Flow{
+			TypeMeta:   typeMeta(FlowKind),
+			ObjectMeta: typedObjectMeta(&flow.ObjectMeta, nil),
+			Spec:       flow.Spec,
+		})
+	}
+	cm, err = c.create(cm)
+	if err != nil {
+		return nil, err
+	}
+	return toFlow(cm)
+}
+
+func (c *configMapFlows) Update(flow *spec.Flow) (*spec.Flow, error) {
+	cm, err := FlowToConfigMap(flow)
+	if err != nil {
+		return nil, err
+	}
+	old, err := c.get(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.isTyped(old)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		current, err := c.tclient.Flows(c.namespace).Get(cm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkResourceVersion(&flow.ObjectMeta, old, &current.ObjectMeta); err != nil {
+			return nil, err
+		}
+		current.ObjectMeta = typedObjectMeta(&flow.ObjectMeta, &current.ObjectMeta)
+		current.Spec = flow.Spec
+		updated, err := c.tclient.Flows(c.namespace).Update(current)
+		if err != nil {
+			return nil, err
+		}
+		withStatus(&updated.ObjectMeta, old)
+		return updated, nil
+	}
+	cm, err = c.update(&flow.ObjectMeta, cm, old)
+	if err != nil {
+		return nil, err
+	}
+	return toFlow(cm)
+}
+
+func (c *configMapFlows) Delete(name string) error {
+	return c.delete(name, func(name string) error {
+		return c.tclient.Flows(c.namespace).Delete(name)
+	})
+}
+
+func (c *configMapFlows) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func(cm *v1.ConfigMap) (runtime.Object, error) {
+		return toFlow(cm)
+	})
+}
+
+type configMapRuntimes struct {
+	*configMapResource
+}
+
+func toRuntime(cm *v1.ConfigMap) (*spec.Runtime, error) {
+	runtime, err := ConfigMapToRuntime(cm)
+	if err != nil {
+		return nil, err
+	}
+	withConfigMapMeta(&runtime.ObjectMeta, cm)
+	return runtime, nil
+}
+
+func (c *configMapRuntimes) List(opts api.ListOptions) (*spec.RuntimeList, error) {
+	cms, err := c.list(opts)
+	if err != nil {
+		return nil, err
+	}
+	answer := &spec.RuntimeList{TypeMeta: typeMeta(RuntimeKind + "List"), ListMeta: listMeta(cms)}
+	failed := map[string]error{}
+	for i := range cms.Items {
+		runtime, err := toRuntime(&cms.Items[i])
+		if err != nil {
+			// lets not hide the other resources when one of them is broken
+			failed[cms.Items[i].Name] = err
+			continue
+		}
+		answer.Items = append(answer.Items, runtime)
+	}
+	return answer, c.conversionError(failed)
+}
+
+func (c *configMapRuntimes) Get(name string) (*spec.Runtime, error) {
+	cm, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return toRuntime(cm)
+}
+
+func (c *configMapRuntimes) Create(runtime *spec.Runtime) (*spec.Runtime, error) {
+	cm, err := RuntimeToConfigMap(runtime)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.createTyped(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		return c.tclient.Runtimes(c.namespace).Create(&spec.Runtime{
+			TypeMeta:   typeMeta(RuntimeKind),
+			ObjectMeta: typedObjectMeta(&runtime.ObjectMeta, nil),
+			Spec:       runtime.Spec,
+		})
+	}
+	cm, err = c.create(cm)
+	if err != nil {
+		return nil, err
+	}
+	return toRuntime(cm)
+}
+
+func (c *configMapRuntimes) Update(runtime *spec.Runtime) (*spec.Runtime, error) {
+	cm, err := RuntimeToConfigMap(runtime)
+	if err != nil {
+		return nil, err
+	}
+	old, err := c.get(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.isTyped(old)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		current, err := c.tclient.Runtimes(c.namespace).Get(cm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkResourceVersion(&runtime.ObjectMeta, old, &current.ObjectMeta); err != nil {
+			return nil, err
+		}
+		current.ObjectMeta = typedObjectMeta(&runtime.ObjectMeta, &current.ObjectMeta)
+		current.Spec = runtime.Spec
+		updated, err := c.tclient.Runtimes(c.namespace).Update(current)
+		if err != nil {
+			return nil, err
+		}
+		withStatus(&updated.ObjectMeta, old)
+		return updated, nil
+	}
+	cm, err = c.update(&runtime.ObjectMeta, cm, old)
+	if err != nil {
+		return nil, err
+	}
+	return toRuntime(cm)
+}
+
+func (c *configMapRuntimes) Delete(name string) error {
+	return c.delete(name, func(name string) error {
+		return c.tclient.Runtimes(c.namespace).Delete(name)
+	})
+}
+
+func (c *configMapRuntimes) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func(cm *v1.ConfigMap) (runtime.Object, error) {
+		return toRuntime(cm)
+	})
+}
+
+type configMapConnectors struct {
+	*configMapResource
+}
+
+func toConnector(cm *v1.ConfigMap) (*spec.Connector, error) {
+	connector, err := ConfigMapToConnector(cm)
+	if err != nil {
+		return nil, err
+	}
+	withConfigMapMeta(&connector.ObjectMeta, cm)
+	return connector, nil
+}
+
+func (c *configMapConnectors) List(opts api.ListOptions) (*spec.ConnectorList, error) {
+	cms, err := c.list(opts)
+	if err != nil {
+		return nil, err
+	}
+	answer := &spec.ConnectorList{TypeMeta: typeMeta(ConnectorKind + "List"), ListMeta: listMeta(cms)}
+	failed := map[string]error{}
+	for i := range cms.Items {
+		connector, err := toConnector(&cms.Items[i])
+		if err != nil {
+			// lets not hide the other resources when one of them is broken
+			failed[cms.Items[i].Name] = err
+			continue
+		}
+		answer.Items = append(answer.Items, connector)
+	}
+	return answer, c.conversionError(failed)
+}
+
+func (c *configMapConnectors) Get(name string) (*spec.Connector, error) {
+	cm, err := c.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return toConnector(cm)
+}
+
+func (c *configMapConnectors) Create(connector *spec.Connector) (*spec.Connector, error) {
+	cm, err := ConnectorToConfigMap(connector)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.createTyped(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		return c.tclient.Connectors(c.namespace).Create(&spec.Connector{
+			TypeMeta:   typeMeta(ConnectorKind),
+			ObjectMeta: typedObjectMeta(&connector.ObjectMeta, nil),
+			Spec:       connector.Spec,
+		})
+	}
+	cm, err = c.create(cm)
+	if err != nil {
+		return nil, err
+	}
+	return toConnector(cm)
+}
+
+func (c *configMapConnectors) Update(connector *spec.Connector) (*spec.Connector, error) {
+	cm, err := ConnectorToConfigMap(connector)
+	if err != nil {
+		return nil, err
+	}
+	old, err := c.get(cm.Name)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := c.isTyped(old)
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		current, err := c.tclient.Connectors(c.namespace).Get(cm.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkResourceVersion(&connector.ObjectMeta, old, &current.ObjectMeta); err != nil {
+			return nil, err
+		}
+		current.ObjectMeta = typedObjectMeta(&connector.ObjectMeta, &current.ObjectMeta)
+		current.Spec = connector.Spec
+		updated, err := c.tclient.Connectors(c.namespace).Update(current)
+		if err != nil {
+			return nil, err
+		}
+		withStatus(&updated.ObjectMeta, old)
+		return updated, nil
+	}
+	cm, err = c.update(&connector.ObjectMeta, cm, old)
+	if err != nil {
+		return nil, err
+	}
+	return toConnector(cm)
+}
+
+func (c *configMapConnectors) Delete(name string) error {
+	return c.delete(name, func(name string) error {
+		return c.tclient.Connectors(c.namespace).Delete(name)
+	})
+}
+
+func (c *configMapConnectors) Watch(opts api.ListOptions) (watch.Interface, error) {
+	return c.watch(opts, func(cm *v1.ConfigMap) (runtime.Object, error) {
+		return toConnector(cm)
+	})
+}
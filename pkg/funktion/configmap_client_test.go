@@ -0,0 +1,371 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+func TestClient(t *testing.T) {
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+	}
+	kclient := fake.NewSimpleClientset(nodejs)
+	functions := NewClient(kclient, nil).Functions(testNamespace)
+
+	_, err := functions.Create(&spec.Function{
+		ObjectMeta: v1.ObjectMeta{Name: "hello"},
+		Spec: spec.FunctionSpec{
+			Runtime: "nodejs",
+			Source:  "module.exports = function(context, callback) {}",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the Function: %v", err)
+	}
+	cm, err := kclient.Core().ConfigMaps(testNamespace).Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Labels[KindLabel], FunctionKind)
+	assertEquals(t, cm.Labels[RuntimeLabel], "nodejs")
+
+	// lets pretend the operator has reconciled the Function
+	cm.Annotations[StatusAnnotation] = `{"phase":"Running"}`
+	if _, err := kclient.Core().ConfigMaps(testNamespace).Update(cm); err != nil {
+		t.Fatalf("Failed to update the Function ConfigMap: %v", err)
+	}
+
+	function, err := functions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function: %v", err)
+	}
+	status, err := GetObjectStatus(&function.ObjectMeta)
+	if err != nil || status == nil {
+		t.Fatalf("Expected the status of the Function but got %v %v", status, err)
+	}
+	assertEquals(t, status.Phase, RunningPhase)
+
+	function.Spec.Debug = true
+	function.Annotations[StatusAnnotation] = `{"phase":"Pending"}`
+	if _, err := functions.Update(function); err != nil {
+		t.Fatalf("Failed to update the Function: %v", err)
+	}
+	cm, err = kclient.Core().ConfigMaps(testNamespace).Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Data[DebugProperty], "true")
+	assertEquals(t, cm.Annotations[StatusAnnotation], `{"phase":"Running"}`)
+
+	if _, err := functions.Get("nodejs"); !errors.IsNotFound(err) {
+		t.Errorf("Expected a Runtime to not be found as a Function but got %v", err)
+	}
+	if err := functions.Delete("nodejs"); !errors.IsNotFound(err) {
+		t.Errorf("Expected deleting a Runtime as a Function to fail but got %v", err)
+	}
+}
+
+func TestClientChangesMigratedResourcesThroughTypedResources(t *testing.T) {
+	// lets use the ConfigMaps of another fake cluster as the store of the typed resources
+	typedClient := NewClient(fake.NewSimpleClientset(), nil)
+	typedFunctions := typedClient.Functions(testNamespace)
+	if _, err := typedFunctions.Create(&spec.Function{
+		ObjectMeta: v1.ObjectMeta{Name: "hello"},
+		Spec: spec.FunctionSpec{
+			Runtime: "nodejs",
+			Source:  "module.exports = function(context, callback) { callback(200, 'Hello'); };",
+		},
+	}); err != nil {
+		t.Fatalf("Failed to create the typed Function: %v", err)
+	}
+
+	tpr := &v1beta1.ThirdPartyResource{ObjectMeta: v1.ObjectMeta{Name: spec.FunctionResource.TPRName()}}
+	kclient := fake.NewSimpleClientset(tpr)
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	inf := cache.NewSharedIndexInformer(NewTypedListWatch(typedClient, FunctionKind, testNamespace), typedObject(FunctionKind), resyncPeriod, cache.Indexers{})
+	c.typedInfs[FunctionKind] = inf
+	key := testNamespace + "/hello"
+	syncTyped := func() {
+		inf.GetStore().Replace([]interface{}{}, "")
+		if typed, err := typedFunctions.Get("hello"); err == nil {
+			inf.GetStore().Add(typed)
+		} else if !errors.IsNotFound(err) {
+			t.Fatalf("Failed to get the typed Function: %v", err)
+		}
+		if err := c.syncTyped(FunctionKind, key); err != nil {
+			t.Fatalf("Failed to sync the typed Function: %v", err)
+		}
+	}
+	syncTyped()
+
+	configMaps := kclient.Core().ConfigMaps(testNamespace)
+	cm, err := configMaps.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Annotations[TypedResourceAnnotation], "true")
+
+	// an edit is made to the typed resource so that the operator does not revert it
+	functions := NewClient(kclient, typedClient).Functions(testNamespace)
+	function, err := functions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function: %v", err)
+	}
+	function.Spec.Source = "module.exports = function(context, callback) { callback(200, 'Bye'); };"
+	if _, err := functions.Update(function); err != nil {
+		t.Fatalf("Failed to update the Function: %v", err)
+	}
+	typed, err := typedFunctions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the typed Function: %v", err)
+	}
+	assertEquals(t, typed.Spec.Source, function.Spec.Source)
+	syncTyped()
+	cm, err = configMaps.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Data[SourceProperty], function.Spec.Source)
+
+	// as is a delete
+	if err := functions.Delete("hello"); err != nil {
+		t.Fatalf("Failed to delete the Function: %v", err)
+	}
+	if _, err := typedFunctions.Get("hello"); !errors.IsNotFound(err) {
+		t.Fatalf("Expected the typed Function to be deleted but got %v", err)
+	}
+	syncTyped()
+	if _, err := configMaps.Get("hello"); !errors.IsNotFound(err) {
+		t.Errorf("Expected the Function ConfigMap to be deleted but got %v", err)
+	}
+
+	// new resources are created as typed resources once they are registered
+	if _, err := functions.Create(&spec.Function{
+		ObjectMeta: v1.ObjectMeta{Name: "other"},
+		Spec:       spec.FunctionSpec{Runtime: "nodejs", Source: "module.exports = function(context, callback) {};"},
+	}); err != nil {
+		t.Fatalf("Failed to create the Function: %v", err)
+	}
+	if _, err := typedFunctions.Get("other"); err != nil {
+		t.Errorf("Expected a typed Function but got %v", err)
+	}
+	if _, err := configMaps.Get("other"); !errors.IsNotFound(err) {
+		t.Errorf("Expected no ConfigMap before the operator renders the Function but got %v", err)
+	}
+}
+
+func TestClientUpdateKeepsDataWhichIsNotPartOfTheSpec(t *testing.T) {
+	deploymentYaml := `# the deployment of each Function
+apiVersion: extensions/v1beta1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nodejs
+        image: funktion/nodejs-runtime
+`
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+		Data: map[string]string{
+			DeploymentProperty: deploymentYaml,
+			DebugPortProperty:  "5858",
+			"custom":           "kept",
+		},
+	}
+	hello := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty: "module.exports = function(context, callback) {}",
+			DebugProperty:  "true",
+			"custom":       "kept",
+		},
+	}
+	kclient := fake.NewSimpleClientset(nodejs, hello)
+	client := NewClient(kclient, nil)
+	configMaps := kclient.Core().ConfigMaps(testNamespace)
+
+	runtimes := client.Runtimes(testNamespace)
+	runtime, err := runtimes.Get("nodejs")
+	if err != nil {
+		t.Fatalf("Failed to get the Runtime: %v", err)
+	}
+	runtime.Spec.DebugPort = 9229
+	runtime, err = runtimes.Update(runtime)
+	if err != nil {
+		t.Fatalf("Failed to update the Runtime: %v", err)
+	}
+	cm, err := configMaps.Get("nodejs")
+	if err != nil {
+		t.Fatalf("Failed to get the Runtime ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Data[DebugPortProperty], "9229")
+	assertEquals(t, cm.Data[DeploymentProperty], deploymentYaml)
+	assertEquals(t, cm.Data["custom"], "kept")
+
+	// changing a YAML property replaces its text
+	runtime.Spec.Deployment.Spec.Template.Spec.Containers[0].Image = "funktion/nodejs-runtime:2.0"
+	if _, err := runtimes.Update(runtime); err != nil {
+		t.Fatalf("Failed to update the Runtime: %v", err)
+	}
+	cm, err = configMaps.Get("nodejs")
+	if err != nil {
+		t.Fatalf("Failed to get the Runtime ConfigMap: %v", err)
+	}
+	updated, err := ConfigMapToRuntime(cm)
+	if err != nil {
+		t.Fatalf("Failed to convert the Runtime ConfigMap: %v", err)
+	}
+	assertEquals(t, updated.Spec.Deployment.Spec.Template.Spec.Containers[0].Image, "funktion/nodejs-runtime:2.0")
+	assertEquals(t, cm.Data["custom"], "kept")
+
+	// keys removed from the spec are removed from the ConfigMap
+	functions := client.Functions(testNamespace)
+	function, err := functions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function: %v", err)
+	}
+	function.Spec.Debug = false
+	if _, err := functions.Update(function); err != nil {
+		t.Fatalf("Failed to update the Function: %v", err)
+	}
+	cm, err = configMaps.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function ConfigMap: %v", err)
+	}
+	if _, ok := cm.Data[DebugProperty]; ok {
+		t.Errorf("Expected the %s property to be removed but got %s", DebugProperty, cm.Data[DebugProperty])
+	}
+	assertEquals(t, cm.Data["custom"], "kept")
+}
+
+func TestClientListReturnsTheResourcesWhichCanBeConverted(t *testing.T) {
+	hello := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty: "module.exports = function(context, callback) {}",
+		},
+	}
+	broken := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "broken",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty:      "module.exports = function(context, callback) {}",
+			TrafficProperty:     "1=100",
+			MaxReplicasProperty: "3",
+		},
+	}
+	functions := NewClient(fake.NewSimpleClientset(hello, broken), nil).Functions(testNamespace)
+
+	list, err := functions.List(api.ListOptions{})
+	if list == nil || len(list.Items) != 1 {
+		t.Fatalf("Expected the Function which can be converted but got %v %v", list, err)
+	}
+	assertEquals(t, list.Items[0].Name, "hello")
+	failed := ListFailures(err)
+	if len(failed) != 1 || failed["broken"] == nil {
+		t.Fatalf("Expected the Function broken to fail to convert but got %v", err)
+	}
+	assertEquals(t, errorReason(failed["broken"]), InvalidTrafficReason)
+	if ListFailures(fmt.Errorf("boom")) != nil {
+		t.Errorf("Expected no failures for other errors")
+	}
+}
+
+func TestClientUpdateOfStaleTypedResourceConflicts(t *testing.T) {
+	typedClient := NewClient(fake.NewSimpleClientset(), nil)
+	typedFunctions := typedClient.Functions(testNamespace)
+	if _, err := typedFunctions.Create(&spec.Function{
+		ObjectMeta: v1.ObjectMeta{Name: "hello"},
+		Spec:       spec.FunctionSpec{Runtime: "nodejs", Source: "module.exports = function(context, callback) {};"},
+	}); err != nil {
+		t.Fatalf("Failed to create the typed Function: %v", err)
+	}
+	hello := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            "hello",
+			Namespace:       testNamespace,
+			ResourceVersion: "2",
+			Labels:          map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+			Annotations:     map[string]string{TypedResourceAnnotation: "true"},
+		},
+		Data: map[string]string{
+			SourceProperty: "module.exports = function(context, callback) {};",
+		},
+	}
+	functions := NewClient(fake.NewSimpleClientset(hello), typedClient).Functions(testNamespace)
+
+	function, err := functions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function: %v", err)
+	}
+	assertEquals(t, function.ResourceVersion, "2")
+
+	// an edit based on an older version of the Function is rejected
+	stale := *function
+	stale.ResourceVersion = "1"
+	stale.Spec.Source = "module.exports = function(context, callback) { callback(200, 'Stale'); };"
+	if _, err := functions.Update(&stale); !errors.IsConflict(err) {
+		t.Fatalf("Expected a conflict updating a stale Function but got %v", err)
+	}
+	typed, err := typedFunctions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the typed Function: %v", err)
+	}
+	assertEquals(t, typed.Spec.Source, "module.exports = function(context, callback) {};")
+
+	function.Spec.Source = "module.exports = function(context, callback) { callback(200, 'Latest'); };"
+	if _, err := functions.Update(function); err != nil {
+		t.Fatalf("Failed to update the Function: %v", err)
+	}
+	typed, err = typedFunctions.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the typed Function: %v", err)
+	}
+	assertEquals(t, typed.Spec.Source, function.Spec.Source)
+}
@@ -19,6 +19,9 @@ import "time"
 const (
 	// KindLabel is the label key used on ConfigMaps to indicate the kind of resource
 	KindLabel = "funktion.fabric8.io/kind"
+	// ManagedByLabel is the label key used on generated Deployments and Services to refer to the
+	// Function or Flow they were generated for. The KindLabel holds the kind of that resource.
+	ManagedByLabel = "funktion.fabric8.io/managed-by"
 
 	// Flow
 
@@ -40,10 +43,17 @@ const (
 	RuntimeKind = "Runtime"
 	// FunctionKind is the value of a Function fo the KindLabel
 	FunctionKind = "Function"
+	// RevisionKind is the value of the KindLabel of the ConfigMaps recording the revisions of a Function
+	RevisionKind = "FunctionRevision"
 	// DeploymentKind is the value of a Deployment fo the KindLabel
 	DeploymentKind = "Deployment"
 	// ServiceKind is the value of a ConneServicector fo the KindLabel
 	ServiceKind = "Service"
+	// AutoscalerKind is the kind of the HorizontalPodAutoscaler generated for an autoscaled Function
+	AutoscalerKind = "HorizontalPodAutoscaler"
+
+	// ExposeURLAnnotation is the annotation added to a Service once it has been exposed
+	ExposeURLAnnotation = "fabric8.io/exposeUrl"
 
 	// Runtime
 
@@ -61,4 +71,16 @@ const (
 	SourceMountPathProperty = "sourceMountPath"
 
 	resyncPeriod = 30 * time.Second
+
+	// maxLabelValueLength is the longest value Kubernetes accepts for a label
+	maxLabelValueLength = 63
+
+	// maxSyncRetries is the number of times a failing resource is retried before it is dropped
+	// until it or one of the resources it depends on changes again
+	maxSyncRetries = 15
+
+	// connectorIndex indexes Flows by the key of their Connector
+	connectorIndex = "connector"
+	// runtimeIndex indexes Functions by the key of their Runtime
+	runtimeIndex = "runtime"
 )
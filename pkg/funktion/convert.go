@@ -0,0 +1,524 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/ghodss/yaml"
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+// The conversions below map between the typed resources in pkg/spec and the labelled
+// ConfigMaps which the operator reconciles.
+
+// ConfigMapToFunction converts a Function ConfigMap into a typed Function
+func ConfigMapToFunction(cm *v1.ConfigMap) (*spec.Function, error) {
+	if err := checkKind(cm, FunctionKind); err != nil {
+		return nil, err
+	}
+	answer := &spec.Function{
+		TypeMeta:   typeMeta(FunctionKind),
+		ObjectMeta: specObjectMeta(cm, RuntimeLabel),
+		Spec: spec.FunctionSpec{
+			Runtime: cm.Labels[RuntimeLabel],
+			Source:  cm.Data[SourceProperty],
+			Debug:   strings.ToLower(cm.Data[DebugProperty]) == "true",
+		},
+	}
+	if len(cm.Data[EnvVarsProperty]) > 0 {
+		answer.Spec.Env = parseEnvVars(cm.Data[EnvVarsProperty])
+	}
+	if len(cm.Data[SecretEnvVarsProperty]) > 0 {
+		envs, err := getSecretEnvVars(cm)
+		if err != nil {
+			return nil, err
+		}
+		answer.Spec.SecretEnv = envs
+	}
+	if len(cm.Data[SecretMountsProperty]) > 0 {
+		mounts, err := getSecretMounts(cm)
+		if err != nil {
+			return nil, err
+		}
+		answer.Spec.SecretMounts = mounts
+	}
+	answer.Spec.IdleTimeout = cm.Data[IdleTimeoutProperty]
+	if len(cm.Data[ScheduleProperty]) > 0 {
+		answer.Spec.Schedule = &spec.ScheduleSpec{
+			Cron:    cm.Data[ScheduleProperty],
+			Payload: cm.Data[SchedulePayloadProperty],
+		}
+	}
+	traffic, err := getTraffic(cm)
+	if err != nil {
+		return nil, err
+	}
+	answer.Spec.Traffic = traffic
+	pod, err := getPodSettings(cm, FunctionKind)
+	if err != nil {
+		return nil, err
+	}
+	answer.Spec.Pod = pod
+	gateway, err := getGatewaySpec(cm)
+	if err != nil {
+		return nil, err
+	}
+	answer.Spec.Gateway = gateway
+	settings, err := getAutoscalingSettings(cm)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil {
+		answer.Spec.Autoscaling = &spec.AutoscalingSpec{
+			MinReplicas:                    settings.minReplicas,
+			MaxReplicas:                    settings.maxReplicas,
+			TargetCPUUtilizationPercentage: settings.targetCPU,
+			TargetConcurrency:              settings.targetConcurrency,
+		}
+	}
+	return answer, nil
+}
+
+// FunctionToConfigMap converts a typed Function into the ConfigMap reconciled by the operator
+func FunctionToConfigMap(function *spec.Function) (*v1.ConfigMap, error) {
+	cm := newConfigMap(&function.ObjectMeta, FunctionKind)
+	cm.Labels[RuntimeLabel] = function.Spec.Runtime
+	cm.Data[SourceProperty] = function.Spec.Source
+	if function.Spec.Debug {
+		cm.Data[DebugProperty] = "true"
+	}
+	if len(function.Spec.Env) > 0 {
+		lines := []string{}
+		for _, env := range function.Spec.Env {
+			if env.ValueFrom != nil {
+				return nil, fmt.Errorf("Environment variable %s of Function %s uses valueFrom which is not supported. Use secretEnv to read it from a Secret", env.Name, function.Name)
+			}
+			lines = append(lines, env.Name+"="+env.Value)
+		}
+		cm.Data[EnvVarsProperty] = strings.Join(lines, "\n")
+	}
+	if len(function.Spec.SecretEnv) > 0 {
+		cm.Data[SecretEnvVarsProperty] = formatSecretEnvVars(function.Spec.SecretEnv)
+	}
+	if len(function.Spec.SecretMounts) > 0 {
+		cm.Data[SecretMountsProperty] = formatSecretMounts(function.Spec.SecretMounts)
+	}
+	if len(function.Spec.IdleTimeout) > 0 {
+		cm.Data[IdleTimeoutProperty] = function.Spec.IdleTimeout
+	}
+	if s := function.Spec.Schedule; s != nil && len(s.Cron) > 0 {
+		cm.Data[ScheduleProperty] = s.Cron
+		if len(s.Payload) > 0 {
+			cm.Data[SchedulePayloadProperty] = s.Payload
+		}
+	}
+	if len(function.Spec.Traffic) > 0 {
+		cm.Data[TrafficProperty] = FormatTraffic(function.Spec.Traffic)
+	}
+	if err := setPodSettings(cm, function.Spec.Pod); err != nil {
+		return nil, err
+	}
+	setGatewaySpec(cm, function.Spec.Gateway)
+	if a := function.Spec.Autoscaling; a != nil {
+		for key, value := range map[string]int32{
+			MinReplicasProperty:       a.MinReplicas,
+			MaxReplicasProperty:       a.MaxReplicas,
+			TargetCPUProperty:         a.TargetCPUUtilizationPercentage,
+			TargetConcurrencyProperty: a.TargetConcurrency,
+		} {
+			if value > 0 {
+				cm.Data[key] = strconv.Itoa(int(value))
+			}
+		}
+	}
+	return cm, nil
+}
+
+// ConfigMapToFlow converts a Flow ConfigMap into a typed Flow
+func ConfigMapToFlow(cm *v1.ConfigMap) (*spec.Flow, error) {
+	if err := checkKind(cm, FlowKind); err != nil {
+		return nil, err
+	}
+	answer := &spec.Flow{
+		TypeMeta:   typeMeta(FlowKind),
+		ObjectMeta: specObjectMeta(cm, ConnectorLabel),
+		Spec: spec.FlowSpec{
+			Connector:             cm.Labels[ConnectorLabel],
+			ApplicationProperties: cm.Data[ApplicationPropertiesProperty],
+			ApplicationYml:        cm.Data[ApplicationYmlProperty],
+		},
+	}
+	if text := cm.Data[FunktionYmlProperty]; len(text) > 0 {
+		config := &spec.FunkionConfig{}
+		if err := yaml.Unmarshal([]byte(text), config); err != nil {
+			return nil, fmt.Errorf("Failed to parse property `%s` on the Flow ConfigMap %s: %v", FunktionYmlProperty, cm.Name, err)
+		}
+		answer.Spec.Funktion = config
+	}
+	pod, err := getPodSettings(cm, FlowKind)
+	if err != nil {
+		return nil, err
+	}
+	answer.Spec.Pod = pod
+	return answer, nil
+}
+
+// FlowToConfigMap converts a typed Flow into the ConfigMap reconciled by the operator
+func FlowToConfigMap(flow *spec.Flow) (*v1.ConfigMap, error) {
+	cm := newConfigMap(&flow.ObjectMeta, FlowKind)
+	cm.Labels[ConnectorLabel] = flow.Spec.Connector
+	setDataText(cm, ApplicationPropertiesProperty, flow.Spec.ApplicationProperties)
+	setDataText(cm, ApplicationYmlProperty, flow.Spec.ApplicationYml)
+	if flow.Spec.Funktion != nil {
+		if err := setDataYaml(cm, FunktionYmlProperty, flow.Spec.Funktion); err != nil {
+			return nil, err
+		}
+	}
+	if err := setPodSettings(cm, flow.Spec.Pod); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// ConfigMapToRuntime converts a Runtime ConfigMap into a typed Runtime
+func ConfigMapToRuntime(cm *v1.ConfigMap) (*spec.Runtime, error) {
+	if err := checkKind(cm, RuntimeKind); err != nil {
+		return nil, err
+	}
+	answer := &spec.Runtime{
+		TypeMeta:   typeMeta(RuntimeKind),
+		ObjectMeta: specObjectMeta(cm),
+		Spec: spec.RuntimeSpec{
+			SourceMountPath: cm.Data[SourceMountPathProperty],
+		},
+	}
+	if err := getDataYaml(cm, DeploymentProperty, &answer.Spec.Deployment); err != nil {
+		return nil, err
+	}
+	if err := getDataYaml(cm, DeploymentDebugProperty, &answer.Spec.DeploymentDebug); err != nil {
+		return nil, err
+	}
+	if err := getDataYaml(cm, ServiceProperty, &answer.Spec.Service); err != nil {
+		return nil, err
+	}
+	if text := cm.Data[DebugPortProperty]; len(text) > 0 {
+		port, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse property `%s` on the Runtime ConfigMap %s: %v", DebugPortProperty, cm.Name, err)
+		}
+		answer.Spec.DebugPort = port
+	}
+	if text := cm.Data[FileExtensionsProperty]; len(text) > 0 {
+		answer.Spec.FileExtensions = strings.Split(text, ",")
+	}
+	return answer, nil
+}
+
+// RuntimeToConfigMap converts a typed Runtime into the ConfigMap reconciled by the operator
+func RuntimeToConfigMap(runtime *spec.Runtime) (*v1.ConfigMap, error) {
+	cm := newConfigMap(&runtime.ObjectMeta, RuntimeKind)
+	if err := setDataYaml(cm, DeploymentProperty, runtime.Spec.Deployment); err != nil {
+		return nil, err
+	}
+	if err := setDataYaml(cm, DeploymentDebugProperty, runtime.Spec.DeploymentDebug); err != nil {
+		return nil, err
+	}
+	if err := setDataYaml(cm, ServiceProperty, runtime.Spec.Service); err != nil {
+		return nil, err
+	}
+	if runtime.Spec.DebugPort > 0 {
+		cm.Data[DebugPortProperty] = strconv.Itoa(runtime.Spec.DebugPort)
+	}
+	setDataText(cm, FileExtensionsProperty, strings.Join(runtime.Spec.FileExtensions, ","))
+	setDataText(cm, SourceMountPathProperty, runtime.Spec.SourceMountPath)
+	return cm, nil
+}
+
+// ConfigMapToConnector converts a Connector ConfigMap into a typed Connector
+func ConfigMapToConnector(cm *v1.ConfigMap) (*spec.Connector, error) {
+	if err := checkKind(cm, ConnectorKind); err != nil {
+		return nil, err
+	}
+	answer := &spec.Connector{
+		TypeMeta:   typeMeta(ConnectorKind),
+		ObjectMeta: specObjectMeta(cm),
+		Spec: spec.ConnectorSpec{
+			ApplicationProperties: cm.Data[ApplicationPropertiesProperty],
+		},
+	}
+	var deployment *v1beta1.Deployment
+	if err := getDataYaml(cm, DeploymentYmlProperty, &deployment); err != nil {
+		return nil, err
+	}
+	if deployment != nil {
+		answer.Spec.DeploymentSpec = &deployment.Spec
+		answer.Spec.DeploymentLabels = deployment.Labels
+		answer.Spec.DeploymentAnnotations = deployment.Annotations
+	}
+	if text := cm.Data[SchemaYmlProperty]; len(text) > 0 {
+		schema, err := LoadConnectorSchema([]byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse property `%s` on the Connector ConfigMap %s: %v", SchemaYmlProperty, cm.Name, err)
+		}
+		answer.Spec.Schema = schema
+	}
+	if text := cm.Data[DebugPortProperty]; len(text) > 0 {
+		port, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse property `%s` on the Connector ConfigMap %s: %v", DebugPortProperty, cm.Name, err)
+		}
+		answer.Spec.DebugPort = port
+	}
+	return answer, nil
+}
+
+// ConnectorToConfigMap converts a typed Connector into the ConfigMap reconciled by the operator
+func ConnectorToConfigMap(connector *spec.Connector) (*v1.ConfigMap, error) {
+	cm := newConfigMap(&connector.ObjectMeta, ConnectorKind)
+	if connector.Spec.DeploymentSpec != nil {
+		deployment := &v1beta1.Deployment{
+			TypeMeta: unversioned.TypeMeta{
+				Kind:       "Deployment",
+				APIVersion: "extensions/v1beta1",
+			},
+			ObjectMeta: v1.ObjectMeta{
+				Labels:      connector.Spec.DeploymentLabels,
+				Annotations: connector.Spec.DeploymentAnnotations,
+			},
+			Spec: *connector.Spec.DeploymentSpec,
+		}
+		if err := setDataYaml(cm, DeploymentYmlProperty, deployment); err != nil {
+			return nil, err
+		}
+	}
+	if connector.Spec.Schema != nil {
+		if err := setDataYaml(cm, SchemaYmlProperty, connector.Spec.Schema); err != nil {
+			return nil, err
+		}
+	}
+	setDataText(cm, ApplicationPropertiesProperty, connector.Spec.ApplicationProperties)
+	if connector.Spec.DebugPort > 0 {
+		cm.Data[DebugPortProperty] = strconv.Itoa(connector.Spec.DebugPort)
+	}
+	return cm, nil
+}
+
+// podDataKeys are the data keys of the pod settings of a Function or Flow ConfigMap
+var podDataKeys = []string{
+	CPURequestProperty,
+	MemoryRequestProperty,
+	CPULimitProperty,
+	MemoryLimitProperty,
+	NodeSelectorProperty,
+	TolerationsProperty,
+	AffinityProperty,
+	ServiceAccountProperty,
+}
+
+// specDataKeys are the data keys of the ConfigMaps of each kind which hold the spec of the
+// typed resource. Any other keys are left alone when a typed resource is written.
+var specDataKeys = map[string][]string{
+	FunctionKind: append([]string{
+		SourceProperty,
+		DebugProperty,
+		EnvVarsProperty,
+		SecretEnvVarsProperty,
+		SecretMountsProperty,
+		IdleTimeoutProperty,
+		ScheduleProperty,
+		SchedulePayloadProperty,
+		TrafficProperty,
+		GatewayAuthProperty,
+		GatewayAuthSecretProperty,
+		GatewayCORSOriginsProperty,
+		GatewayMaxRequestSizeProperty,
+		GatewayTimeoutProperty,
+		MinReplicasProperty,
+		MaxReplicasProperty,
+		TargetCPUProperty,
+		TargetConcurrencyProperty,
+	}, podDataKeys...),
+	FlowKind: append([]string{
+		ApplicationPropertiesProperty,
+		ApplicationYmlProperty,
+		FunktionYmlProperty,
+	}, podDataKeys...),
+	RuntimeKind: {
+		DeploymentProperty,
+		DeploymentDebugProperty,
+		ServiceProperty,
+		DebugPortProperty,
+		FileExtensionsProperty,
+		SourceMountPathProperty,
+	},
+	ConnectorKind: {
+		DeploymentYmlProperty,
+		SchemaYmlProperty,
+		ApplicationPropertiesProperty,
+		DebugPortProperty,
+	},
+}
+
+// yamlDataValues create the values which the YAML data keys are parsed into
+var yamlDataValues = map[string]func() interface{}{
+	DeploymentProperty:      func() interface{} { return &v1beta1.Deployment{} },
+	DeploymentDebugProperty: func() interface{} { return &v1beta1.Deployment{} },
+	ServiceProperty:         func() interface{} { return &v1.Service{} },
+	DeploymentYmlProperty:   func() interface{} { return &v1beta1.Deployment{} },
+	SchemaYmlProperty:       func() interface{} { return &spec.ConnectorSchema{} },
+	FunktionYmlProperty:     func() interface{} { return &spec.FunkionConfig{} },
+	TolerationsProperty:     func() interface{} { return &[]v1.Toleration{} },
+	AffinityProperty:        func() interface{} { return &v1.Affinity{} },
+}
+
+// mergeConfigMapData puts the data of a ConfigMap converted from a typed resource on top of the
+// data of the existing ConfigMap. Keys which are not part of the spec are kept as is the
+// original text of YAML properties whose value has not changed.
+func mergeConfigMapData(old *v1.ConfigMap, cm *v1.ConfigMap) {
+	data := map[string]string{}
+	for k, v := range old.Data {
+		data[k] = v
+	}
+	owned := map[string]bool{}
+	for _, key := range specDataKeys[cm.Labels[KindLabel]] {
+		owned[key] = true
+		value, ok := cm.Data[key]
+		if !ok {
+			delete(data, key)
+			continue
+		}
+		if oldValue, ok := data[key]; ok && sameDataValue(key, oldValue, value) {
+			continue
+		}
+		data[key] = value
+	}
+	for k, v := range cm.Data {
+		if !owned[k] {
+			data[k] = v
+		}
+	}
+	cm.Data = data
+}
+
+// sameDataValue returns true if the old text of a data key has the same value as the new text
+// rendered from a typed resource
+func sameDataValue(key string, oldText string, newText string) bool {
+	if oldText == newText {
+		return true
+	}
+	newValue, ok := yamlDataValues[key]
+	if !ok {
+		return false
+	}
+	value := newValue()
+	if err := yaml.Unmarshal([]byte(oldText), value); err != nil {
+		return false
+	}
+	data, err := yaml.Marshal(value)
+	return err == nil && string(data) == newText
+}
+
+func checkKind(cm *v1.ConfigMap, kind string) error {
+	if actual := cm.Labels[KindLabel]; actual != kind {
+		return fmt.Errorf("ConfigMap %s is a %s rather than a %s", cm.Name, actual, kind)
+	}
+	return nil
+}
+
+func typeMeta(kind string) unversioned.TypeMeta {
+	return unversioned.TypeMeta{
+		Kind:       kind,
+		APIVersion: spec.GroupVersion.String(),
+	}
+}
+
+// specObjectMeta copies the user facing metadata of a ConfigMap leaving out the
+// labels which become part of the spec and the status written by the operator
+func specObjectMeta(cm *v1.ConfigMap, specLabels ...string) v1.ObjectMeta {
+	labels := map[string]string{}
+	for k, v := range cm.Labels {
+		labels[k] = v
+	}
+	delete(labels, KindLabel)
+	for _, label := range specLabels {
+		delete(labels, label)
+	}
+	annotations := map[string]string{}
+	for k, v := range cm.Annotations {
+		annotations[k] = v
+	}
+	delete(annotations, StatusAnnotation)
+	return v1.ObjectMeta{
+		Name:        cm.Name,
+		Namespace:   cm.Namespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+func newConfigMap(meta *v1.ObjectMeta, kind string) *v1.ConfigMap {
+	labels := map[string]string{}
+	for k, v := range meta.Labels {
+		labels[k] = v
+	}
+	labels[KindLabel] = kind
+	annotations := map[string]string{}
+	for k, v := range meta.Annotations {
+		annotations[k] = v
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        meta.Name,
+			Namespace:   meta.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Data: map[string]string{},
+	}
+}
+
+func setDataText(cm *v1.ConfigMap, key string, value string) {
+	if len(value) > 0 {
+		cm.Data[key] = value
+	}
+}
+
+func setDataYaml(cm *v1.ConfigMap, key string, value interface{}) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal property `%s` of %s as YAML: %v", key, cm.Name, err)
+	}
+	if text := strings.TrimSpace(string(data)); text != "null" && text != "{}" {
+		cm.Data[key] = string(data)
+	}
+	return nil
+}
+
+func getDataYaml(cm *v1.ConfigMap, key string, into interface{}) error {
+	text := cm.Data[key]
+	if len(text) == 0 {
+		return nil
+	}
+	if err := yaml.Unmarshal([]byte(text), into); err != nil {
+		return fmt.Errorf("Failed to parse property `%s` on the ConfigMap %s: %v", key, cm.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestFunctionConversion(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				KindLabel:    FunctionKind,
+				RuntimeLabel: "nodejs",
+				"team":       "funky",
+			},
+			Annotations: map[string]string{
+				StatusAnnotation: "Running",
+			},
+		},
+		Data: map[string]string{
+			SourceProperty:        "module.exports = function(context, callback) {}",
+			DebugProperty:         "true",
+			EnvVarsProperty:       "FOO=bar",
+			SecretEnvVarsProperty: "API_KEY=credentials:apiKey",
+			SecretMountsProperty:  "tls:/etc/tls",
+		},
+	}
+	function, err := ConfigMapToFunction(cm)
+	if err != nil {
+		t.Fatalf("Failed to convert the ConfigMap: %v", err)
+	}
+	assertEquals(t, function.Spec.Runtime, "nodejs")
+	assertEquals(t, function.Labels["team"], "funky")
+	if _, ok := function.Labels[KindLabel]; ok {
+		t.Errorf("Expected the kind label to be removed from the Function")
+	}
+	if _, ok := function.Annotations[StatusAnnotation]; ok {
+		t.Errorf("Expected the status annotation to be removed from the Function")
+	}
+	if !function.Spec.Debug || len(function.Spec.Env) != 1 {
+		t.Errorf("Expected debug and one env var but got %#v", function.Spec)
+	}
+	if len(function.Spec.SecretEnv) != 1 || function.Spec.SecretEnv[0].Secret != "credentials" || len(function.Spec.SecretMounts) != 1 {
+		t.Errorf("Expected one secret env var and one secret mount but got %#v", function.Spec)
+	}
+
+	answer, err := FunctionToConfigMap(function)
+	if err != nil {
+		t.Fatalf("Failed to convert the Function: %v", err)
+	}
+	if !reflect.DeepEqual(answer.Data, cm.Data) {
+		t.Errorf("Expected data %v but got %v", cm.Data, answer.Data)
+	}
+	if !reflect.DeepEqual(answer.Labels, cm.Labels) {
+		t.Errorf("Expected labels %v but got %v", cm.Labels, answer.Labels)
+	}
+
+	cm.Labels[KindLabel] = FlowKind
+	if _, err := ConfigMapToFunction(cm); err == nil {
+		t.Errorf("Expected an error converting a Flow ConfigMap into a Function")
+	}
+}
+
+func TestConnectorConversion(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "timer",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: ConnectorKind},
+		},
+		Data: map[string]string{
+			DeploymentYmlProperty: `apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  labels:
+    group: io.fabric8.funktion
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - image: fabric8/connector-timer
+`,
+		},
+	}
+	connector, err := ConfigMapToConnector(cm)
+	if err != nil {
+		t.Fatalf("Failed to convert the ConfigMap: %v", err)
+	}
+	if connector.Spec.DeploymentSpec == nil || len(connector.Spec.DeploymentSpec.Template.Spec.Containers) != 1 {
+		t.Fatalf("Expected the deployment spec of the Connector but got %#v", connector.Spec)
+	}
+	assertEquals(t, connector.Spec.DeploymentLabels["group"], "io.fabric8.funktion")
+
+	// Connectors stored before the typed resources were added use the deploymentSpec key
+	data, err := json.Marshal(connector)
+	if err != nil {
+		t.Fatalf("Failed to marshal the Connector: %v", err)
+	}
+	if !strings.Contains(string(data), `"deploymentSpec":{`) {
+		t.Errorf("Expected the Connector to be serialized with a deploymentSpec but got %s", data)
+	}
+
+	answer, err := ConnectorToConfigMap(connector)
+	if err != nil {
+		t.Fatalf("Failed to convert the Connector: %v", err)
+	}
+	roundTrip, err := ConfigMapToConnector(answer)
+	if err != nil {
+		t.Fatalf("Failed to convert the generated ConfigMap: %v", err)
+	}
+	if !reflect.DeepEqual(roundTrip.Spec, connector.Spec) {
+		t.Errorf("Expected spec %#v but got %#v", connector.Spec, roundTrip.Spec)
+	}
+}
+
+func TestFunctionEnvValueFromIsRejected(t *testing.T) {
+	function := &spec.Function{
+		ObjectMeta: v1.ObjectMeta{Name: "hello"},
+		Spec: spec.FunctionSpec{
+			Runtime: "nodejs",
+			Env: []v1.EnvVar{
+				{
+					Name: "NODE_NAME",
+					ValueFrom: &v1.EnvVarSource{
+						FieldRef: &v1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+					},
+				},
+			},
+		},
+	}
+	if _, err := FunctionToConfigMap(function); err == nil {
+		t.Errorf("Expected an error converting an environment variable with valueFrom")
+	}
+}
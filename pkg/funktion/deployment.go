@@ -17,6 +17,7 @@ package funktion
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/client-go/1.5/pkg/api/v1"
@@ -55,6 +56,11 @@ const (
 	// ExposeLabel is the label key to expose services
 	ExposeLabel = "expose"
 
+	// SourceFileName is the name of the file holding the source of a Function in its pods
+	SourceFileName = "source.js"
+	// DefaultSourceMountPath is where the source of a Function is mounted if the Runtime does not say
+	DefaultSourceMountPath = "/funktion"
+
 	// for Runtime
 
 	// DeploymentProperty is the data key for a Runtime's Deployment
@@ -77,13 +83,16 @@ const (
 func makeFlowDeployment(flow *v1.ConfigMap, connector *v1.ConfigMap, old *v1beta1.Deployment) (*v1beta1.Deployment, error) {
 	deployYaml := connector.Data[DeploymentYmlProperty]
 	if len(deployYaml) == 0 {
-		return nil, fmt.Errorf("No property `%s` on the Flow ConfigMap %s", DeploymentYmlProperty, flow.Name)
+		return nil, reasonErrorf(InvalidDeploymentReason, "No property `%s` on the Flow ConfigMap %s", DeploymentYmlProperty, flow.Name)
 	}
 
 	deployment := v1beta1.Deployment{}
 	err := yaml.Unmarshal([]byte(deployYaml), &deployment)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse Deployment YAML from property `%s` on the Flow ConfigMap %s. Error: %s", DeploymentYmlProperty, flow.Name, err)
+		return nil, reasonErrorf(InvalidDeploymentReason, "Failed to parse Deployment YAML from property `%s` on the Flow ConfigMap %s. Error: %s", DeploymentYmlProperty, flow.Name, err)
+	}
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return nil, reasonErrorf(InvalidDeploymentReason, "No containers in the Deployment YAML from property `%s` on the Connector ConfigMap %s", DeploymentYmlProperty, connector.Name)
 	}
 
 	name := flow.Name
@@ -162,7 +171,15 @@ func makeFlowDeployment(flow *v1.ConfigMap, connector *v1.ConfigMap, old *v1beta
 	if len(deployment.Spec.Template.Spec.Containers[0].Name) == 0 {
 		deployment.Spec.Template.Spec.Containers[0].Name = "connector"
 	}
+	podSettings, err := getPodSettings(flow, FlowKind)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPodSettings(&deployment.Spec.Template, podSettings); err != nil {
+		return nil, err
+	}
 	setDeploymentLabel(&deployment, NameLabel, name)
+	setOwner(&deployment.ObjectMeta, flow, FlowKind)
 	return &deployment, nil
 }
 
@@ -172,17 +189,20 @@ func makeFunctionDeployment(function *v1.ConfigMap, runtime *v1.ConfigMap, old *
 	if strings.ToLower(debugFlag) == "true" {
 		deployYaml = runtime.Data[DeploymentDebugProperty]
 		if len(deployYaml) == 0 {
-			return nil, fmt.Errorf("No property `%s` on the Runtime ConfigMap %s", DeploymentDebugProperty, runtime.Name)
+			return nil, reasonErrorf(InvalidDeploymentReason, "No property `%s` on the Runtime ConfigMap %s", DeploymentDebugProperty, runtime.Name)
 		}
 	}
 	if len(deployYaml) == 0 {
-		return nil, fmt.Errorf("No property `%s` on the Runtime ConfigMap %s", DeploymentProperty, runtime.Name)
+		return nil, reasonErrorf(InvalidDeploymentReason, "No property `%s` on the Runtime ConfigMap %s", DeploymentProperty, runtime.Name)
 	}
 
 	deployment := v1beta1.Deployment{}
 	err := yaml.Unmarshal([]byte(deployYaml), &deployment)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to parse Deployment YAML from property `%s` on the Runtime ConfigMap %s. Error: %s", DeploymentYmlProperty, runtime.Name, err)
+		return nil, reasonErrorf(InvalidDeploymentReason, "Failed to parse Deployment YAML from property `%s` on the Runtime ConfigMap %s. Error: %s", DeploymentYmlProperty, runtime.Name, err)
+	}
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return nil, reasonErrorf(InvalidDeploymentReason, "No containers in the Deployment YAML on the Runtime ConfigMap %s", runtime.Name)
 	}
 
 	name := function.Name
@@ -216,14 +236,14 @@ func makeFunctionDeployment(function *v1.ConfigMap, runtime *v1.ConfigMap, old *
 	}
 
 	if len(function.Data[SourceProperty]) == 0 {
-		return nil, fmt.Errorf("No property `%s` on the Function ConfigMap %s", SourceProperty, function.Name)
+		return nil, reasonErrorf(MissingSourceReason, "No property `%s` on the Function ConfigMap %s", SourceProperty, function.Name)
 	}
 
 	volumeName := "config"
 	items := []v1.KeyToPath{
 		v1.KeyToPath{
 			Key:  SourceProperty,
-			Path: "source.js",
+			Path: SourceFileName,
 		},
 	}
 
@@ -250,10 +270,19 @@ func makeFunctionDeployment(function *v1.ConfigMap, runtime *v1.ConfigMap, old *
 	}
 
 	envVars := parseEnvVars(function.Data[EnvVarsProperty])
+	secretEnvVars, err := getSecretEnvVars(function)
+	if err != nil {
+		return nil, err
+	}
+	envVars = append(envVars, secretEnvVarsToEnvVars(secretEnvVars)...)
+	secretMounts, err := getSecretMounts(function)
+	if err != nil {
+		return nil, err
+	}
 
 	mountPath := runtime.Data[SourceMountPathProperty]
 	if len(mountPath) == 0 {
-		mountPath = "/funktion"
+		mountPath = DefaultSourceMountPath
 	}
 	for i, container := range podSpec.Containers {
 		foundVolumeMount := false
@@ -273,10 +302,44 @@ func makeFunctionDeployment(function *v1.ConfigMap, runtime *v1.ConfigMap, old *
 			applyEnvVars(&podSpec.Containers[i].Env, &envVars)
 		}
 	}
+	applySecretMounts(podSpec, secretMounts)
 	if len(deployment.Spec.Template.Spec.Containers[0].Name) == 0 {
 		deployment.Spec.Template.Spec.Containers[0].Name = "function"
 	}
+	podSettings, err := getPodSettings(function, FunctionKind)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPodSettings(&deployment.Spec.Template, podSettings); err != nil {
+		return nil, err
+	}
+
+	// lets not fight the HorizontalPodAutoscaler over the number of pods
+	autoscaling, err := getAutoscalingSettings(function)
+	if err != nil {
+		return nil, err
+	}
+	if autoscaling != nil {
+		deployment.Spec.Replicas = autoscaling.replicas(old)
+	}
+
+	// lets leave an idle Function at zero pods until the activator scales it up
+	idleTimeout, err := getIdleTimeout(function)
+	if err != nil {
+		return nil, err
+	}
+	if idleTimeout > 0 {
+		if isScaledToZero(old) {
+			zero := int32(0)
+			deployment.Spec.Replicas = &zero
+		}
+		if len(deployment.Annotations[LastActivityAnnotation]) == 0 {
+			deployment.Annotations[LastActivityAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		}
+	}
 	setDeploymentLabel(&deployment, NameLabel, name)
+	deployment.Spec.Template.Labels[FunctionLabel] = name
+	setOwner(&deployment.ObjectMeta, function, FunctionKind)
 	return &deployment, nil
 }
 
@@ -311,10 +374,11 @@ func applyEnvVars(envVar *[]v1.EnvVar, overrides *[]v1.EnvVar) {
 	}
 	for _, o := range *overrides {
 		found := false
-		for _, v := range *envVar {
+		for i := range *envVar {
+			v := &(*envVar)[i]
 			if v.Name == o.Name {
 				v.Value = o.Value
-				v.ValueFrom = nil
+				v.ValueFrom = o.ValueFrom
 				found = true
 			}
 		}
@@ -359,6 +423,20 @@ func makeFunctionService(function *v1.ConfigMap, runtime *v1.ConfigMap, old *v1.
 	}
 
 	svc.Spec.Selector = deployment.Spec.Selector.MatchLabels
+	if len(function.Data[TrafficProperty]) > 0 {
+		// lets select the pods of every revision in the traffic split
+		svc.Spec.Selector = map[string]string{FunctionLabel: function.Name}
+	}
+
+	// requests to a Function with an idle timeout go through the activator which records that
+	// the Function is in use and holds the requests while the Function is scaled up from zero
+	idleTimeout, err := getIdleTimeout(function)
+	if err != nil {
+		return nil, err
+	}
+	if idleTimeout > 0 {
+		pointAtActivator(svc)
+	}
 
 	// lets copy across any old missing dependencies
 	if old != nil {
@@ -380,5 +458,6 @@ func makeFunctionService(function *v1.ConfigMap, runtime *v1.ConfigMap, old *v1.
 	if len(svc.Labels[ExposeLabel]) == 0 {
 		svc.Labels[ExposeLabel] = "true"
 	}
+	setOwner(&svc.ObjectMeta, function, FunctionKind)
 	return svc, nil
 }
@@ -0,0 +1,212 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+const (
+	// EventComponent is the source component of the Events posted by the operator
+	EventComponent = "funktion-operator"
+
+	// CreatedReason is the reason of the Event posted when a Deployment is created for a resource
+	CreatedReason = "Created"
+	// UpdatedReason is the reason of the Event posted when a changed resource has been rolled out
+	UpdatedReason = "Updated"
+	// DeletedReason is the reason of the Event posted when the resources generated for a Function or Flow are removed
+	DeletedReason = "Deleted"
+	// FailedSyncReason is the reason of the Event posted when a resource could not be reconciled
+	FailedSyncReason = "FailedSync"
+	// MissingRuntimeReason is the reason of the Event posted when a Function refers to a Runtime which does not exist
+	MissingRuntimeReason = "MissingRuntime"
+	// MissingConnectorReason is the reason of the Event posted when a Flow refers to a Connector which does not exist
+	MissingConnectorReason = "MissingConnector"
+	// MissingSourceReason is the reason of the Event posted when a Function has no source code
+	MissingSourceReason = "MissingSource"
+	// InvalidDeploymentReason is the reason of the Event posted when a Deployment could not be generated
+	InvalidDeploymentReason = "InvalidDeployment"
+	// InvalidAutoscalerReason is the reason of the Event posted when a HorizontalPodAutoscaler could not be generated
+	InvalidAutoscalerReason = "InvalidAutoscaler"
+	// InvalidIdleTimeoutReason is the reason of the Event posted when the idle timeout of a Function is invalid
+	InvalidIdleTimeoutReason = "InvalidIdleTimeout"
+	// ScaledToZeroReason is the reason of the Event posted when an idle Function is scaled to zero pods
+	ScaledToZeroReason = "ScaledToZero"
+	// InvalidScheduleReason is the reason of the Event posted when the schedule of a Function can not be parsed
+	InvalidScheduleReason = "InvalidSchedule"
+	// FailedInvocationReason is the reason of the Event posted when a scheduled invocation of a Function fails
+	FailedInvocationReason = "FailedInvocation"
+	// InvalidTrafficReason is the reason of the Event posted when the traffic split of a Function is invalid
+	InvalidTrafficReason = "InvalidTraffic"
+	// InvalidExposeReason is the reason of the Event posted when a Function could not be exposed with an Ingress or Route
+	InvalidExposeReason = "InvalidExpose"
+	// InvalidGatewayReason is the reason of the Event posted when the gateway settings of a Function are invalid
+	InvalidGatewayReason = "InvalidGateway"
+	// ConflictingConfigMapReason is the reason of the Event posted when a typed resource has the name of a ConfigMap which was not created for it
+	ConflictingConfigMapReason = "ConflictingConfigMap"
+
+	// maxCachedEvents is the number of Events remembered so that repeated Events are aggregated
+	maxCachedEvents = 4096
+)
+
+// reasonError is a reconciliation error along with the reason of the Warning Event to post for it
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func reasonErrorf(reason string, format string, args ...interface{}) error {
+	return &reasonError{
+		reason: reason,
+		err:    fmt.Errorf(format, args...),
+	}
+}
+
+// wrapError prefixes the message of the given error while keeping its reason
+func wrapError(prefix string, err error) error {
+	return &reasonError{
+		reason: errorReason(err),
+		err:    fmt.Errorf("%s: %s", prefix, err),
+	}
+}
+
+// errorReason returns the reason of the Warning Event to post for the given error
+func errorReason(err error) string {
+	if re, ok := err.(*reasonError); ok {
+		return re.reason
+	}
+	return FailedSyncReason
+}
+
+// eventRecorder posts Events against Function and Flow ConfigMaps. Repeated Events
+// are aggregated by incrementing the count of the previously posted Event.
+type eventRecorder struct {
+	kclient kubernetes.Interface
+	logger  log.Logger
+
+	lock   sync.Mutex
+	events map[string]*v1.Event
+}
+
+func newEventRecorder(kclient kubernetes.Interface, logger log.Logger) *eventRecorder {
+	return &eventRecorder{
+		kclient: kclient,
+		logger:  logger,
+		events:  map[string]*v1.Event{},
+	}
+}
+
+// Normal posts an informational Event about the given ConfigMap
+func (r *eventRecorder) Normal(cm *v1.ConfigMap, reason, messageFmt string, args ...interface{}) {
+	r.record(configMapReference(cm), v1.EventTypeNormal, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// Warning posts an Event about the given ConfigMap describing why it could not be reconciled
+func (r *eventRecorder) Warning(cm *v1.ConfigMap, err error) {
+	r.record(configMapReference(cm), v1.EventTypeWarning, errorReason(err), err.Error())
+}
+
+// Deleted posts an Event about a Function or Flow which has been removed
+func (r *eventRecorder) Deleted(namespace, name, kind string) {
+	ref := v1.ObjectReference{
+		Kind:       "ConfigMap",
+		APIVersion: "v1",
+		Namespace:  namespace,
+		Name:       name,
+	}
+	r.record(ref, v1.EventTypeNormal, DeletedReason, fmt.Sprintf("Removed the resources generated for %s %s", kind, name))
+}
+
+func (r *eventRecorder) record(ref v1.ObjectReference, eventType, reason, message string) {
+	events := r.kclient.Core().Events(ref.Namespace)
+	now := unversioned.Now()
+	cacheKey := fmt.Sprintf("%s/%s/%s/%s/%s", ref.Namespace, ref.Name, ref.UID, reason, message)
+
+	// lets only hold the lock while counting so that a slow API server does not block the
+	// other workers posting Events
+	r.lock.Lock()
+	var updated *v1.Event
+	if old := r.events[cacheKey]; old != nil {
+		copy := *old
+		copy.Count++
+		copy.LastTimestamp = now
+		updated = &copy
+		r.events[cacheKey] = updated
+	}
+	r.lock.Unlock()
+
+	if updated != nil {
+		if e, err := events.Update(updated); err == nil {
+			r.lock.Lock()
+			// a concurrent repeat of the Event may already have counted past this one
+			if cached := r.events[cacheKey]; cached == nil || cached.Count <= e.Count {
+				r.events[cacheKey] = e
+			}
+			r.lock.Unlock()
+			return
+		}
+		// the Event may have expired so lets post a new one
+	}
+
+	event := &v1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ref.Name, time.Now().UnixNano()),
+			Namespace: ref.Namespace,
+		},
+		InvolvedObject: ref,
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Source: v1.EventSource{
+			Component: EventComponent,
+		},
+	}
+	e, err := events.Create(event)
+	if err != nil {
+		r.logger.Log("msg", "failed to post event", "name", ref.Name, "namespace", ref.Namespace, "reason", reason, "err", err)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.events) >= maxCachedEvents {
+		r.events = map[string]*v1.Event{}
+	}
+	r.events[cacheKey] = e
+}
+
+func configMapReference(cm *v1.ConfigMap) v1.ObjectReference {
+	return v1.ObjectReference{
+		Kind:            "ConfigMap",
+		APIVersion:      "v1",
+		Namespace:       cm.Namespace,
+		Name:            cm.Name,
+		UID:             cm.UID,
+		ResourceVersion: cm.ResourceVersion,
+	}
+}
@@ -0,0 +1,178 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestEventRecorderAggregatesRepeatedEvents(t *testing.T) {
+	kclient := fake.NewSimpleClientset()
+	recorder := newEventRecorder(kclient, log.NewNopLogger())
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			UID:       "1234",
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		recorder.Warning(function, reasonErrorf(MissingRuntimeReason, "Runtime nodejs does not exist"))
+	}
+	recorder.Normal(function, CreatedReason, "Created Deployment %s", "hello")
+
+	events := listEvents(t, kclient)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events but got %d: %v", len(events), events)
+	}
+	warning := findEvent(events, MissingRuntimeReason)
+	if warning == nil {
+		t.Fatalf("No %s event in %v", MissingRuntimeReason, events)
+	}
+	assertEquals(t, warning.Type, v1.EventTypeWarning)
+	if warning.Count != 3 {
+		t.Errorf("Expected a count of 3 but got %d for the %s event", warning.Count, warning.Reason)
+	}
+	assertEquals(t, warning.Message, "Runtime nodejs does not exist")
+	assertEquals(t, warning.InvolvedObject.Kind, "ConfigMap")
+	assertEquals(t, warning.InvolvedObject.Name, "hello")
+	assertEquals(t, warning.Source.Component, EventComponent)
+
+	normal := findEvent(events, CreatedReason)
+	if normal == nil {
+		t.Fatalf("No %s event in %v", CreatedReason, events)
+	}
+	assertEquals(t, normal.Type, v1.EventTypeNormal)
+	if normal.Count != 1 {
+		t.Errorf("Expected a count of 1 but got %d for the %s event", normal.Count, normal.Reason)
+	}
+
+	// a different message for the same reason is a separate event
+	recorder.Warning(function, reasonErrorf(MissingRuntimeReason, "Runtime java does not exist"))
+	if events := listEvents(t, kclient); len(events) != 3 {
+		t.Errorf("Expected 3 events but got %d: %v", len(events), events)
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	err := reasonErrorf(MissingSourceReason, "No source for Function %s", "hello")
+	assertEquals(t, errorReason(err), MissingSourceReason)
+	assertEquals(t, err.Error(), "No source for Function hello")
+
+	wrapped := wrapError("Failed to sync", err)
+	assertEquals(t, errorReason(wrapped), MissingSourceReason)
+	assertEquals(t, wrapped.Error(), "Failed to sync: No source for Function hello")
+
+	assertEquals(t, errorReason(fmt.Errorf("boom")), FailedSyncReason)
+}
+
+func TestSyncFunctionPostsEvents(t *testing.T) {
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	hello := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				KindLabel:    FunctionKind,
+				RuntimeLabel: "nodejs",
+			},
+		},
+		Data: map[string]string{
+			SourceProperty: "module.exports = function(context, callback) { callback(200, 'Hello'); };",
+		},
+	}
+	broken := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "broken",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				KindLabel:    FunctionKind,
+				RuntimeLabel: "doesnotexist",
+			},
+		},
+		Data: map[string]string{
+			SourceProperty: "module.exports = function(context, callback) {};",
+		},
+	}
+	kclient := fake.NewSimpleClientset(nodejs, hello, broken)
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	c.runtimeInf.GetStore().Add(nodejs)
+	c.functionInf.GetStore().Add(hello)
+	c.functionInf.GetStore().Add(broken)
+
+	if err := c.syncFunction(testNamespace + "/hello"); err != nil {
+		t.Fatalf("Failed to sync Function hello: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.syncFunction(testNamespace + "/broken"); err == nil {
+			t.Fatalf("Expected an error syncing a Function with a missing Runtime")
+		}
+	}
+
+	events := listEvents(t, kclient)
+	created := findEvent(events, CreatedReason)
+	if created == nil {
+		t.Fatalf("No %s event in %v", CreatedReason, events)
+	}
+	assertEquals(t, created.Type, v1.EventTypeNormal)
+	assertEquals(t, created.InvolvedObject.Name, "hello")
+
+	missing := findEvent(events, MissingRuntimeReason)
+	if missing == nil {
+		t.Fatalf("No %s event in %v", MissingRuntimeReason, events)
+	}
+	assertEquals(t, missing.Type, v1.EventTypeWarning)
+	assertEquals(t, missing.InvolvedObject.Name, "broken")
+	if missing.Count != 2 {
+		t.Errorf("Expected a count of 2 but got %d for the %s event", missing.Count, missing.Reason)
+	}
+}
+
+func listEvents(t *testing.T, kclient *fake.Clientset) []v1.Event {
+	list, err := kclient.Core().Events(testNamespace).List(api.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list events: %v", err)
+	}
+	return list.Items
+}
+
+func findEvent(events []v1.Event, reason string) *v1.Event {
+	for i := range events {
+		if events[i].Reason == reason {
+			return &events[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,406 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/funktionio/funktion/pkg/k8sutil"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/util/intstr"
+)
+
+const (
+	// ExposeNone leaves exposing the Services of Functions to the exposecontroller
+	ExposeNone = "none"
+	// ExposeAuto exposes the Services of Functions with Routes on OpenShift and Ingresses otherwise
+	ExposeAuto = "auto"
+	// ExposeIngress exposes the Services of Functions with Ingresses
+	ExposeIngress = "ingress"
+	// ExposeRoute exposes the Services of Functions with OpenShift Routes
+	ExposeRoute = "route"
+
+	// DefaultExposeHostTemplate is the default template of the host of an exposed Function
+	DefaultExposeHostTemplate = "{{.Name}}.{{.Namespace}}.{{.Domain}}"
+	// DefaultExposePathTemplate is the default template of the path of an exposed Function
+	DefaultExposePathTemplate = "/"
+
+	// RouteKind is the kind of an OpenShift Route
+	RouteKind = "Route"
+)
+
+// ExposeModes are the ways the operator can expose the Services of Functions
+var ExposeModes = []string{ExposeNone, ExposeAuto, ExposeIngress, ExposeRoute}
+
+// ExposeOptions configure how the operator exposes the Services of Functions outside of the cluster
+type ExposeOptions struct {
+	// Mode is one of ExposeModes
+	Mode string
+	// Domain is the DNS domain which resolves to the Ingress controller or OpenShift router
+	Domain string
+	// HostTemplate is the template of the host of a Function given its Name, Namespace and the Domain
+	HostTemplate string
+	// PathTemplate is the template of the path of a Function given its Name, Namespace and the Domain
+	PathTemplate string
+}
+
+// exposeValues are the values of the host and path templates
+type exposeValues struct {
+	Name      string
+	Namespace string
+	Domain    string
+}
+
+// exposer creates the Ingress or Route of each exposed Function
+type exposer struct {
+	mode   string
+	domain string
+	// needsDomain is true if the host can not be rendered without a domain
+	needsDomain bool
+	host        *template.Template
+	path        *template.Template
+}
+
+// SetExposeOptions makes the operator expose the Services of Functions with Ingresses or Routes
+// and write their URLs back to the Services itself rather than relying on the exposecontroller
+func (c *Operator) SetExposeOptions(opts ExposeOptions) error {
+	mode := opts.Mode
+	switch mode {
+	case "", ExposeNone:
+		c.exposer = nil
+		return nil
+	case ExposeAuto:
+		mode = ExposeIngress
+		if k8sutil.IsOpenShiftCluster(c.kclient) {
+			mode = ExposeRoute
+		}
+	case ExposeIngress, ExposeRoute:
+	default:
+		return fmt.Errorf("Unknown expose mode `%s`. Expected one of %s", opts.Mode, strings.Join(ExposeModes, ", "))
+	}
+	hostText := opts.HostTemplate
+	if len(hostText) == 0 {
+		hostText = DefaultExposeHostTemplate
+	}
+	pathText := opts.PathTemplate
+	if len(pathText) == 0 {
+		pathText = DefaultExposePathTemplate
+	}
+	host, err := template.New("host").Parse(hostText)
+	if err != nil {
+		return fmt.Errorf("Invalid expose host template: %v", err)
+	}
+	path, err := template.New("path").Parse(pathText)
+	if err != nil {
+		return fmt.Errorf("Invalid expose path template: %v", err)
+	}
+	e := &exposer{
+		mode:        mode,
+		domain:      opts.Domain,
+		needsDomain: strings.Contains(hostText, ".Domain"),
+		host:        host,
+		path:        path,
+	}
+	if mode == ExposeIngress && e.needsDomain && len(e.domain) == 0 {
+		return fmt.Errorf("Ingresses need a host. Please specify a domain or a host template without the domain")
+	}
+	c.exposer = e
+	c.logger.Log("msg", "exposing functions", "mode", mode, "domain", opts.Domain)
+	return nil
+}
+
+// hostAndPath renders the host and path of the given Function. The host is empty if there is
+// no domain so that OpenShift generates the host of the Route.
+func (e *exposer) hostAndPath(function *v1.ConfigMap) (string, string, error) {
+	values := exposeValues{
+		Name:      function.Name,
+		Namespace: function.Namespace,
+		Domain:    e.domain,
+	}
+	host := ""
+	if !e.needsDomain || len(e.domain) > 0 {
+		var buffer bytes.Buffer
+		if err := e.host.Execute(&buffer, values); err != nil {
+			return "", "", reasonErrorf(InvalidExposeReason, "Failed to render the host of Function %s: %v", function.Name, err)
+		}
+		host = strings.TrimSpace(buffer.String())
+	}
+	var buffer bytes.Buffer
+	if err := e.path.Execute(&buffer, values); err != nil {
+		return "", "", reasonErrorf(InvalidExposeReason, "Failed to render the path of Function %s: %v", function.Name, err)
+	}
+	path := strings.TrimSpace(buffer.String())
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return host, path, nil
+}
+
+// exposedURL returns the URL of a Function exposed on the given host and path
+func exposedURL(host string, path string) string {
+	if len(host) == 0 {
+		return ""
+	}
+	return "http://" + host + strings.TrimSuffix(path, "/")
+}
+
+// isExposed returns true if the Service of a Function should be reachable from outside the cluster
+func isExposed(svc *v1.Service) bool {
+	return svc.Labels[ExposeLabel] == "true"
+}
+
+// syncExpose creates, updates or deletes the Ingress or Route of the Function with the given
+// Service, returning the URL the Function is exposed on or an empty string if it is not exposed
+// by the operator
+func (c *Operator) syncExpose(function *v1.ConfigMap, svc *v1.Service) (string, error) {
+	e := c.exposer
+	if e == nil {
+		return "", nil
+	}
+	if e.mode == ExposeRoute {
+		return c.syncRoute(function, svc)
+	}
+	return c.syncIngress(function, svc)
+}
+
+func makeFunctionIngress(function *v1.ConfigMap, svc *v1.Service, host string, path string) *v1beta1.Ingress {
+	port := intstr.FromInt(80)
+	if len(svc.Spec.Ports) > 0 {
+		port = intstr.FromInt(int(svc.Spec.Ports[0].Port))
+	}
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      function.Name,
+			Namespace: function.Namespace,
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{
+									Path: path,
+									Backend: v1beta1.IngressBackend{
+										ServiceName: svc.Name,
+										ServicePort: port,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	setOwner(&ingress.ObjectMeta, function, FunctionKind)
+	return ingress
+}
+
+func (c *Operator) syncIngress(function *v1.ConfigMap, svc *v1.Service) (string, error) {
+	ingresses := c.kclient.Extensions().Ingresses(function.Namespace)
+	old, err := ingresses.Get(function.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+		old = nil
+	}
+	if old != nil {
+		if _, _, ok := managedBy(&old.ObjectMeta); !ok {
+			return "", reasonErrorf(InvalidExposeReason, "Ingress %s was not created for Function %s", old.Name, function.Name)
+		}
+	}
+
+	if !isExposed(svc) {
+		if old == nil {
+			return "", nil
+		}
+		if err := ingresses.Delete(old.Name, deleteOptions()); err != nil {
+			return "", fmt.Errorf("delete ingress: %s", err)
+		}
+		c.recorder.Normal(function, DeletedReason, "Deleted Ingress %s", old.Name)
+		return "", nil
+	}
+
+	host, path, err := c.exposer.hostAndPath(function)
+	if err != nil {
+		return "", err
+	}
+	if len(host) == 0 {
+		return "", reasonErrorf(InvalidExposeReason, "No host to expose Function %s on", function.Name)
+	}
+	ingress := makeFunctionIngress(function, svc, host, path)
+	if old == nil {
+		if _, err := ingresses.Create(ingress); err != nil {
+			return "", fmt.Errorf("create ingress: %s", err)
+		}
+		c.recorder.Normal(function, CreatedReason, "Created Ingress %s", ingress.Name)
+	} else if !reflect.DeepEqual(ingress.Spec, old.Spec) || !reflect.DeepEqual(ingress.Labels, old.Labels) ||
+		!reflect.DeepEqual(ingress.OwnerReferences, old.OwnerReferences) {
+		ingress.ResourceVersion = old.ResourceVersion
+		if _, err := ingresses.Update(ingress); err != nil {
+			return "", fmt.Errorf("update ingress: %s", err)
+		}
+	}
+	return exposedURL(host, path), nil
+}
+
+// route is the part of an OpenShift Route which the operator manages
+type route struct {
+	unversioned.TypeMeta `json:",inline"`
+	v1.ObjectMeta        `json:"metadata,omitempty"`
+	Spec                 routeSpec `json:"spec"`
+}
+
+type routeSpec struct {
+	Host string           `json:"host,omitempty"`
+	Path string           `json:"path,omitempty"`
+	To   routeTarget      `json:"to"`
+	Port *routeTargetPort `json:"port,omitempty"`
+}
+
+type routeTarget struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type routeTargetPort struct {
+	TargetPort intstr.IntOrString `json:"targetPort"`
+}
+
+func makeFunctionRoute(function *v1.ConfigMap, svc *v1.Service, host string, path string) *route {
+	r := &route{
+		TypeMeta: unversioned.TypeMeta{
+			APIVersion: "v1",
+			Kind:       RouteKind,
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      function.Name,
+			Namespace: function.Namespace,
+		},
+		Spec: routeSpec{
+			Host: host,
+			To: routeTarget{
+				Kind: "Service",
+				Name: svc.Name,
+			},
+		},
+	}
+	if path != "/" {
+		r.Spec.Path = path
+	}
+	if len(svc.Spec.Ports) > 0 {
+		targetPort := svc.Spec.Ports[0].TargetPort
+		if targetPort.Type == intstr.String || targetPort.IntVal != 0 {
+			r.Spec.Port = &routeTargetPort{TargetPort: targetPort}
+		}
+	}
+	setOwner(&r.ObjectMeta, function, FunctionKind)
+	return r
+}
+
+func (c *Operator) syncRoute(function *v1.ConfigMap, svc *v1.Service) (string, error) {
+	restClient := c.kclient.Core().GetRESTClient()
+	routePath := func(parts ...string) []string {
+		return append([]string{"/oapi/v1/namespaces", function.Namespace, "routes"}, parts...)
+	}
+	var old *route
+	data, err := restClient.Get().AbsPath(routePath(function.Name)...).DoRaw()
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+	} else {
+		old = &route{}
+		if err := json.Unmarshal(data, old); err != nil {
+			return "", fmt.Errorf("decode route: %s", err)
+		}
+		if _, _, ok := managedBy(&old.ObjectMeta); !ok {
+			return "", reasonErrorf(InvalidExposeReason, "Route %s was not created for Function %s", old.Name, function.Name)
+		}
+	}
+
+	if !isExposed(svc) {
+		if old == nil {
+			return "", nil
+		}
+		if err := restClient.Delete().AbsPath(routePath(old.Name)...).Do().Error(); err != nil {
+			return "", fmt.Errorf("delete route: %s", err)
+		}
+		c.recorder.Normal(function, DeletedReason, "Deleted Route %s", old.Name)
+		return "", nil
+	}
+
+	host, path, err := c.exposer.hostAndPath(function)
+	if err != nil {
+		return "", err
+	}
+	r := makeFunctionRoute(function, svc, host, path)
+	if old == nil {
+		body, err := json.Marshal(r)
+		if err != nil {
+			return "", err
+		}
+		data, err := restClient.Post().AbsPath(routePath()...).Body(body).DoRaw()
+		if err != nil {
+			return "", fmt.Errorf("create route: %s", err)
+		}
+		created := &route{}
+		if err := json.Unmarshal(data, created); err != nil {
+			return "", fmt.Errorf("decode route: %s", err)
+		}
+		c.recorder.Normal(function, CreatedReason, "Created Route %s", r.Name)
+		return exposedURL(created.Spec.Host, path), nil
+	}
+
+	if len(host) == 0 {
+		// lets keep the host OpenShift generated
+		r.Spec.Host = old.Spec.Host
+	}
+	if !reflect.DeepEqual(r.Spec, old.Spec) || !reflect.DeepEqual(r.Labels, old.Labels) ||
+		!reflect.DeepEqual(r.OwnerReferences, old.OwnerReferences) {
+		// lets patch the Route so that the fields the operator does not manage such as TLS are kept
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":          r.Labels,
+				"ownerReferences": r.OwnerReferences,
+			},
+			"spec": map[string]interface{}{
+				"host": r.Spec.Host,
+				"path": r.Spec.Path,
+				"to":   r.Spec.To,
+				"port": r.Spec.Port,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := restClient.Patch(api.MergePatchType).AbsPath(routePath(r.Name)...).Body(patch).Do().Error(); err != nil {
+			return "", fmt.Errorf("update route: %s", err)
+		}
+	}
+	return exposedURL(r.Spec.Host, path), nil
+}
@@ -0,0 +1,116 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestExposeIngress(t *testing.T) {
+	kclient := fake.NewSimpleClientset()
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	if err := c.SetExposeOptions(ExposeOptions{Mode: ExposeIngress}); err == nil {
+		t.Errorf("Expected exposing with Ingresses without a domain to fail")
+	}
+	if err := c.SetExposeOptions(ExposeOptions{Mode: "magic"}); err == nil {
+		t.Errorf("Expected an unknown expose mode to fail")
+	}
+	err = c.SetExposeOptions(ExposeOptions{
+		Mode:         ExposeIngress,
+		Domain:       "example.com",
+		PathTemplate: "/{{.Namespace}}/{{.Name}}",
+	})
+	if err != nil {
+		t.Fatalf("Failed to set the expose options: %v", err)
+	}
+
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			UID:       "1234",
+			Labels:    map[string]string{KindLabel: FunctionKind},
+		},
+	}
+	svc := &v1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{ExposeLabel: "true"},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 80}},
+		},
+	}
+	url, err := c.syncExpose(function, svc)
+	if err != nil {
+		t.Fatalf("Failed to expose the Function: %v", err)
+	}
+	assertEquals(t, url, "http://hello.default.example.com/default/hello")
+
+	ingress, err := kclient.Extensions().Ingresses(testNamespace).Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Ingress: %v", err)
+	}
+	rule := ingress.Spec.Rules[0]
+	assertEquals(t, rule.Host, "hello.default.example.com")
+	assertEquals(t, rule.HTTP.Paths[0].Path, "/default/hello")
+	assertEquals(t, rule.HTTP.Paths[0].Backend.ServiceName, "hello")
+	assertEquals(t, rule.HTTP.Paths[0].Backend.ServicePort.String(), "80")
+	assertEquals(t, string(ingress.OwnerReferences[0].UID), "1234")
+
+	// an unchanged Function keeps its Ingress
+	if url, err := c.syncExpose(function, svc); err != nil || url != "http://hello.default.example.com/default/hello" {
+		t.Errorf("Expected the Function to stay exposed but got %s: %v", url, err)
+	}
+
+	svc.Labels[ExposeLabel] = "false"
+	if url, err := c.syncExpose(function, svc); err != nil || len(url) > 0 {
+		t.Errorf("Expected the Function to no longer be exposed but got %s: %v", url, err)
+	}
+	if _, err := kclient.Extensions().Ingresses(testNamespace).Get("hello"); !errors.IsNotFound(err) {
+		t.Errorf("Expected the Ingress to be deleted but got %v", err)
+	}
+}
+
+func TestMakeFunctionRoute(t *testing.T) {
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace, UID: "1234"},
+	}
+	svc := &v1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 80}},
+		},
+	}
+	r := makeFunctionRoute(function, svc, "", "/")
+	assertEquals(t, r.Kind, RouteKind)
+	assertEquals(t, r.Spec.To.Name, "hello")
+	assertEquals(t, r.Spec.Path, "")
+	if r.Spec.Port != nil {
+		t.Errorf("Expected no target port for a Service without one but got %v", r.Spec.Port)
+	}
+	assertEquals(t, r.Labels[ManagedByLabel], "hello")
+	assertEquals(t, exposedURL("hello-default.apps.example.com", "/"), "http://hello-default.apps.example.com")
+	assertEquals(t, exposedURL("", "/"), "")
+}
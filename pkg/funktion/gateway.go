@@ -0,0 +1,316 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/resource"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/util/intstr"
+)
+
+const (
+	// GatewayAuthProperty is the data key for how the gateway authenticates the requests to a
+	// Function: one of GatewayAuthAPIKey or GatewayAuthJWT
+	GatewayAuthProperty = "gatewayAuth"
+	// GatewayAuthSecretProperty is the data key for the `secretName:key` of the Secret holding the
+	// API key of a Function or the HMAC key which signs its JSON Web Tokens
+	GatewayAuthSecretProperty = "gatewayAuthSecret"
+	// GatewayCORSOriginsProperty represents a newline terminated list of the origins allowed to call
+	// a Function from a browser through the gateway. `*` allows any origin.
+	GatewayCORSOriginsProperty = "gatewayCorsOrigins"
+	// GatewayMaxRequestSizeProperty is the data key for the largest request body the gateway
+	// forwards to a Function, such as `1Mi`
+	GatewayMaxRequestSizeProperty = "gatewayMaxRequestSize"
+	// GatewayTimeoutProperty is the data key for how long the gateway waits for a Function to
+	// respond, such as `30s`
+	GatewayTimeoutProperty = "gatewayTimeout"
+
+	// GatewayAuthAPIKey authenticates requests carrying the API key in the GatewayAPIKeyHeader
+	GatewayAuthAPIKey = "apiKey"
+	// GatewayAuthJWT authenticates requests carrying a JSON Web Token signed with HMAC SHA-256
+	// as a bearer token of the Authorization header
+	GatewayAuthJWT = "jwt"
+	// GatewayAPIKeyHeader is the header holding the API key of a request
+	GatewayAPIKeyHeader = "X-API-Key"
+
+	// GatewayName is the name of the Deployment and Service of the gateway managed by the operator
+	GatewayName = "funktion-gateway"
+	// GatewayLabel is the label key of the Deployment, Service and pods of the gateway
+	GatewayLabel = "funktion.fabric8.io/gateway"
+	// GatewayPathPrefix is the path under which the gateway serves each Function by name
+	GatewayPathPrefix = "/fn/"
+	// GatewayPort is the container port the gateway listens on
+	GatewayPort = 8080
+
+	// DefaultGatewayTimeout is long enough for the activator to scale up an idle Function
+	DefaultGatewayTimeout = invocationTimeout
+)
+
+// GatewayAuthModes are the ways the gateway can authenticate the requests to a Function
+var GatewayAuthModes = []string{GatewayAuthAPIKey, GatewayAuthJWT}
+
+// GatewayOptions configures the gateway Deployment the operator manages
+type GatewayOptions struct {
+	// Image is the image of the gateway. The operator does not manage a gateway if it is empty.
+	Image string
+	// ServiceAccount is the service account the gateway runs as. It must be able to watch the
+	// ConfigMaps and Services and read the Secrets of the namespace.
+	ServiceAccount string
+}
+
+// SetGatewayOptions makes the operator create and update a gateway Deployment and Service
+// in the namespace it watches
+func (c *Operator) SetGatewayOptions(opts GatewayOptions) error {
+	if len(opts.Image) == 0 {
+		c.gateway = nil
+		return nil
+	}
+	if c.namespace == api.NamespaceAll {
+		return fmt.Errorf("The gateway can only be managed by an operator which watches a single namespace")
+	}
+	c.gateway = &opts
+	c.logger.Log("msg", "managing gateway", "image", opts.Image, "namespace", c.namespace)
+	return nil
+}
+
+// ParseSecretKey parses the `secretName:key` of a key of a Secret
+func ParseSecretKey(text string) (*spec.SecretKey, error) {
+	pair := strings.SplitN(strings.TrimSpace(text), ":", 2)
+	if len(pair) != 2 || len(pair[0]) == 0 || len(pair[1]) == 0 {
+		return nil, fmt.Errorf("Expecting `secretName:key` but got: %s", text)
+	}
+	return &spec.SecretKey{Secret: pair[0], Key: pair[1]}, nil
+}
+
+// getGatewaySpec returns how the gateway serves the given Function or nil if it uses the defaults
+func getGatewaySpec(function *v1.ConfigMap) (*spec.GatewaySpec, error) {
+	data := function.Data
+	invalid := func(key string, err error) error {
+		return reasonErrorf(InvalidGatewayReason, "Invalid property `%s` on the Function ConfigMap %s: %v", key, function.Name, err)
+	}
+	answer := &spec.GatewaySpec{
+		Auth:           strings.TrimSpace(data[GatewayAuthProperty]),
+		MaxRequestSize: strings.TrimSpace(data[GatewayMaxRequestSizeProperty]),
+		Timeout:        strings.TrimSpace(data[GatewayTimeoutProperty]),
+	}
+	switch answer.Auth {
+	case "":
+		if len(data[GatewayAuthSecretProperty]) > 0 {
+			return nil, invalid(GatewayAuthSecretProperty, fmt.Errorf("No `%s` property to use the secret for", GatewayAuthProperty))
+		}
+	case GatewayAuthAPIKey, GatewayAuthJWT:
+		secret, err := ParseSecretKey(data[GatewayAuthSecretProperty])
+		if err != nil {
+			return nil, invalid(GatewayAuthSecretProperty, err)
+		}
+		answer.AuthSecret = secret
+	default:
+		return nil, invalid(GatewayAuthProperty, fmt.Errorf("Expecting one of %s but got: %s", strings.Join(GatewayAuthModes, ", "), answer.Auth))
+	}
+	for _, line := range strings.Split(data[GatewayCORSOriginsProperty], "\n") {
+		origin := strings.TrimSuffix(strings.TrimSpace(line), "/")
+		if len(origin) > 0 {
+			answer.CORSOrigins = append(answer.CORSOrigins, origin)
+		}
+	}
+	if _, err := maxRequestSize(answer); err != nil {
+		return nil, invalid(GatewayMaxRequestSizeProperty, err)
+	}
+	if _, err := gatewayTimeout(answer); err != nil {
+		return nil, invalid(GatewayTimeoutProperty, err)
+	}
+	if reflect.DeepEqual(answer, &spec.GatewaySpec{}) {
+		return nil, nil
+	}
+	return answer, nil
+}
+
+// setGatewaySpec stores how the gateway serves a Function in its ConfigMap
+func setGatewaySpec(cm *v1.ConfigMap, s *spec.GatewaySpec) {
+	if s == nil {
+		return
+	}
+	setDataText(cm, GatewayAuthProperty, s.Auth)
+	if s.AuthSecret != nil {
+		cm.Data[GatewayAuthSecretProperty] = s.AuthSecret.Secret + ":" + s.AuthSecret.Key
+	}
+	if len(s.CORSOrigins) > 0 {
+		cm.Data[GatewayCORSOriginsProperty] = strings.Join(s.CORSOrigins, "\n")
+	}
+	setDataText(cm, GatewayMaxRequestSizeProperty, s.MaxRequestSize)
+	setDataText(cm, GatewayTimeoutProperty, s.Timeout)
+}
+
+// maxRequestSize returns the largest request body in bytes or 0 if the size is not limited
+func maxRequestSize(s *spec.GatewaySpec) (int64, error) {
+	if s == nil || len(s.MaxRequestSize) == 0 {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(s.MaxRequestSize)
+	if err != nil {
+		return 0, err
+	}
+	if q.Sign() <= 0 {
+		return 0, fmt.Errorf("The size must be positive but was %s", s.MaxRequestSize)
+	}
+	return q.Value(), nil
+}
+
+func gatewayTimeout(s *spec.GatewaySpec) (time.Duration, error) {
+	if s == nil || len(s.Timeout) == 0 {
+		return DefaultGatewayTimeout, nil
+	}
+	d, err := time.ParseDuration(s.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("The timeout must be positive but was %s", s.Timeout)
+	}
+	return d, nil
+}
+
+// runGateway creates the gateway Deployment and Service if need be and then keeps them up to
+// date until the operator stops
+func (c *Operator) runGateway(stopc <-chan struct{}) {
+	if c.gateway == nil {
+		return
+	}
+	for {
+		if err := c.syncGateway(); err != nil {
+			c.logger.Log("msg", "failed to sync the gateway", "namespace", c.namespace, "err", err)
+		}
+		select {
+		case <-stopc:
+			return
+		case <-time.After(resyncPeriod):
+		}
+	}
+}
+
+func gatewayLabels() map[string]string {
+	return map[string]string{GatewayLabel: "true"}
+}
+
+func makeGatewayDeployment(namespace string, opts *GatewayOptions) *v1beta1.Deployment {
+	replicas := int32(1)
+	return &v1beta1.Deployment{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      GatewayName,
+			Namespace: namespace,
+			Labels:    gatewayLabels(),
+		},
+		Spec: v1beta1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &v1beta1.LabelSelector{MatchLabels: gatewayLabels()},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v1.ObjectMeta{Labels: gatewayLabels()},
+				Spec: v1.PodSpec{
+					ServiceAccountName: opts.ServiceAccount,
+					Containers: []v1.Container{
+						{
+							Name:  "gateway",
+							Image: opts.Image,
+							Args: []string{
+								"operate", "gateway",
+								"--namespace", namespace,
+								"--listen-address", fmt.Sprintf(":%d", GatewayPort),
+							},
+							Ports: []v1.ContainerPort{
+								{Name: "http", ContainerPort: GatewayPort},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func makeGatewayService(namespace string) *v1.Service {
+	labels := gatewayLabels()
+	labels[ExposeLabel] = "true"
+	return &v1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      GatewayName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: gatewayLabels(),
+			Ports: []v1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("http")},
+			},
+		},
+	}
+}
+
+// syncGateway creates the gateway Deployment and Service or updates the image and service
+// account of the Deployment. The replicas are left alone so that the gateway can be scaled.
+func (c *Operator) syncGateway() error {
+	opts := c.gateway
+	deployments := c.kclient.Extensions().Deployments(c.namespace)
+	d := makeGatewayDeployment(c.namespace, opts)
+	old, err := deployments.Get(GatewayName)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if _, err := deployments.Create(d); err != nil {
+			return fmt.Errorf("create gateway deployment: %s", err)
+		}
+		c.logger.Log("msg", "created gateway deployment", "namespace", c.namespace, "image", opts.Image)
+	} else {
+		if old.Labels[GatewayLabel] != "true" {
+			return fmt.Errorf("Deployment %s was not created for the gateway", GatewayName)
+		}
+		// the API server fills in defaults so lets only compare the fields the operator sets
+		containers := old.Spec.Template.Spec.Containers
+		container := d.Spec.Template.Spec.Containers[0]
+		if len(containers) != 1 || containers[0].Image != container.Image || !reflect.DeepEqual(containers[0].Args, container.Args) ||
+			old.Spec.Template.Spec.ServiceAccountName != opts.ServiceAccount {
+			old.Spec.Template.Spec.Containers = d.Spec.Template.Spec.Containers
+			old.Spec.Template.Spec.ServiceAccountName = opts.ServiceAccount
+			if _, err := deployments.Update(old); err != nil {
+				return fmt.Errorf("update gateway deployment: %s", err)
+			}
+			c.logger.Log("msg", "updated gateway deployment", "namespace", c.namespace, "image", opts.Image)
+		}
+	}
+
+	services := c.kclient.Core().Services(c.namespace)
+	svc, err := services.Get(GatewayName)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if _, err := services.Create(makeGatewayService(c.namespace)); err != nil {
+			return fmt.Errorf("create gateway service: %s", err)
+		}
+		c.logger.Log("msg", "created gateway service", "namespace", c.namespace)
+	} else if svc.Labels[GatewayLabel] != "true" {
+		return fmt.Errorf("Service %s was not created for the gateway", GatewayName)
+	}
+	return nil
+}
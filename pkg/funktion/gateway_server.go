@@ -0,0 +1,344 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+// Gateway is the HTTP server which routes the requests to GatewayPathPrefix followed by the name
+// of a Function to the Service of that Function, stripping the prefix and name from the path.
+//
+// The routing table is built from a watch on the Functions of the namespace so it follows
+// Functions as they are created, changed and removed. Each Function can require an API key or a
+// JSON Web Token, allow browsers from other origins to call it and limit the size of and the
+// time taken by its requests.
+type Gateway struct {
+	kclient   kubernetes.Interface
+	logger    log.Logger
+	namespace string
+
+	functionInf cache.SharedIndexInformer
+	serviceInf  cache.SharedIndexInformer
+
+	lock   sync.RWMutex
+	routes map[string]*gatewayRoute
+}
+
+// gatewayRoute is how the gateway serves a Function
+type gatewayRoute struct {
+	spec      *spec.GatewaySpec
+	authKey   []byte
+	maxBytes  int64
+	timeout   time.Duration
+	transport *http.Transport
+	// err is why the Function can not be served such as its auth Secret not being readable
+	err error
+}
+
+// NewGateway creates a gateway for the Functions in the given namespace
+func NewGateway(kclient kubernetes.Interface, logger log.Logger, namespace string) (*Gateway, error) {
+	functionListOpts, err := CreateFunctionListOptions()
+	if err != nil {
+		return nil, err
+	}
+	g := &Gateway{
+		kclient:   kclient,
+		logger:    logger,
+		namespace: namespace,
+		routes:    map[string]*gatewayRoute{},
+	}
+	g.functionInf = cache.NewSharedIndexInformer(
+		NewConfigMapListWatch(kclient, *functionListOpts, namespace),
+		&v1.ConfigMap{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
+	g.serviceInf = cache.NewSharedIndexInformer(
+		NewServiceListWatch(kclient, namespace),
+		&v1.Service{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
+	// the routes are also rebuilt on each resync so that rotated Secrets are picked up
+	g.functionInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			g.updateRoute(obj.(*v1.ConfigMap))
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			g.updateRoute(cur.(*v1.ConfigMap))
+		},
+		DeleteFunc: g.deleteRoute,
+	})
+	return g, nil
+}
+
+// Run watches the Functions and their Services until the given channel is closed
+func (g *Gateway) Run(stopc <-chan struct{}) {
+	go g.functionInf.Run(stopc)
+	go g.serviceInf.Run(stopc)
+	<-stopc
+}
+
+// updateRoute rebuilds the route of the given Function
+func (g *Gateway) updateRoute(function *v1.ConfigMap) {
+	route := g.makeRoute(function)
+	if route.err != nil {
+		g.logger.Log("msg", "failed to route function", "name", function.Name, "namespace", g.namespace, "err", route.err)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	old := g.routes[function.Name]
+	if old != nil && old.transport != nil {
+		if old.timeout == route.timeout {
+			// lets keep the pooled connections to the Function
+			route.transport = old.transport
+		} else {
+			old.transport.CloseIdleConnections()
+		}
+	}
+	g.routes[function.Name] = route
+}
+
+func (g *Gateway) deleteRoute(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if old := g.routes[name]; old != nil && old.transport != nil {
+		old.transport.CloseIdleConnections()
+	}
+	delete(g.routes, name)
+}
+
+func (g *Gateway) route(name string) *gatewayRoute {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.routes[name]
+}
+
+// makeRoute returns the route of the given Function reading the key of its auth Secret
+func (g *Gateway) makeRoute(function *v1.ConfigMap) *gatewayRoute {
+	route := &gatewayRoute{}
+	route.spec, route.err = getGatewaySpec(function)
+	if route.err != nil {
+		return route
+	}
+	route.maxBytes, _ = maxRequestSize(route.spec)
+	route.timeout, _ = gatewayTimeout(route.spec)
+	route.transport = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: route.timeout,
+	}
+	if route.spec == nil || route.spec.AuthSecret == nil {
+		return route
+	}
+	ref := route.spec.AuthSecret
+	secret, err := g.kclient.Core().Secrets(function.Namespace).Get(ref.Secret)
+	if err != nil {
+		route.err = fmt.Errorf("Failed to read the auth Secret %s of Function %s: %v", ref.Secret, function.Name, err)
+		return route
+	}
+	key := strings.TrimSpace(string(secret.Data[ref.Key]))
+	if len(key) == 0 {
+		route.err = fmt.Errorf("No key %s in the auth Secret %s of Function %s", ref.Key, ref.Secret, function.Name)
+		return route
+	}
+	route.authKey = []byte(key)
+	return route
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, path := gatewayFunctionPath(r.URL.Path)
+	if len(name) == 0 {
+		http.Error(w, fmt.Sprintf("Expecting a path of the form %sNAME", GatewayPathPrefix), http.StatusNotFound)
+		return
+	}
+	route := g.route(name)
+	if route == nil {
+		http.Error(w, fmt.Sprintf("No Function %s", name), http.StatusNotFound)
+		return
+	}
+	if route.err != nil {
+		http.Error(w, fmt.Sprintf("Function %s is not available", name), http.StatusServiceUnavailable)
+		return
+	}
+	if route.handleCORS(w, r) {
+		return
+	}
+	if err := route.authenticate(r, time.Now()); err != nil {
+		if route.spec.Auth == GatewayAuthJWT {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if route.maxBytes > 0 {
+		if r.ContentLength > route.maxBytes {
+			http.Error(w, fmt.Sprintf("The request body is larger than %d bytes", route.maxBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, route.maxBytes)
+	}
+	target, err := g.serviceURL(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	out := new(http.Request)
+	*out = *r
+	u := *r.URL
+	u.Path = path
+	u.RawPath = ""
+	out.URL = &u
+	// lets use the name of the Function so that the activator can tell which Function to scale up
+	out.Host = name
+	out.Header = http.Header{}
+	for k, v := range r.Header {
+		out.Header[k] = v
+	}
+	out.Header.Del(GatewayAPIKeyHeader)
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = route.transport
+	proxy.ServeHTTP(w, out)
+}
+
+// gatewayFunctionPath splits the path of a request into the name of the Function and the path
+// to forward to it
+func gatewayFunctionPath(path string) (string, string) {
+	if !strings.HasPrefix(path, GatewayPathPrefix) {
+		return "", ""
+	}
+	rest := strings.TrimPrefix(path, GatewayPathPrefix)
+	name := rest
+	path = "/"
+	if i := strings.Index(rest, "/"); i >= 0 {
+		name = rest[:i]
+		path = rest[i:]
+	}
+	return name, path
+}
+
+// serviceURL returns the URL of the Service of the Function with the given name
+func (g *Gateway) serviceURL(name string) (*url.URL, error) {
+	obj, exists, err := g.serviceInf.GetStore().GetByKey(referenceKey(g.namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("No Service for Function %s", name)
+	}
+	svc := obj.(*v1.Service)
+	if len(svc.Spec.ClusterIP) == 0 || svc.Spec.ClusterIP == v1.ClusterIPNone || len(svc.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("Service %s has no cluster IP and port", name)
+	}
+	return &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(svc.Spec.ClusterIP, strconv.Itoa(int(svc.Spec.Ports[0].Port))),
+	}, nil
+}
+
+// handleCORS adds the CORS headers for an allowed origin and returns true if the request was a
+// preflight request which has been answered
+func (r *gatewayRoute) handleCORS(w http.ResponseWriter, req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if r.spec == nil || len(r.spec.CORSOrigins) == 0 || len(origin) == 0 {
+		return false
+	}
+	preflight := req.Method == "OPTIONS" && len(req.Header.Get("Access-Control-Request-Method")) > 0
+	if !r.allowsOrigin(origin) {
+		if preflight {
+			http.Error(w, fmt.Sprintf("Origin %s is not allowed", origin), http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+	if !preflight {
+		return false
+	}
+	h.Set("Access-Control-Allow-Methods", req.Header.Get("Access-Control-Request-Method"))
+	if headers := req.Header.Get("Access-Control-Request-Headers"); len(headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", headers)
+	}
+	h.Set("Access-Control-Max-Age", "600")
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func (r *gatewayRoute) allowsOrigin(origin string) bool {
+	for _, o := range r.spec.CORSOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate checks the API key or JSON Web Token of a request
+func (r *gatewayRoute) authenticate(req *http.Request, now time.Time) error {
+	if r.spec == nil || len(r.spec.Auth) == 0 {
+		return nil
+	}
+	switch r.spec.Auth {
+	case GatewayAuthAPIKey:
+		key := req.Header.Get(GatewayAPIKeyHeader)
+		if len(key) == 0 {
+			return fmt.Errorf("No %s header", GatewayAPIKeyHeader)
+		}
+		if subtle.ConstantTimeCompare([]byte(key), r.authKey) != 1 {
+			return fmt.Errorf("Invalid API key")
+		}
+		return nil
+	case GatewayAuthJWT:
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			return fmt.Errorf("No bearer token in the Authorization header")
+		}
+		return verifyJWT(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")), r.authKey, now)
+	}
+	return fmt.Errorf("Unknown auth %s", r.spec.Auth)
+}
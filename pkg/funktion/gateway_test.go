@@ -0,0 +1,201 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func signJWT(claims string, key string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + encode(mac.Sum(nil))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	key := []byte("s3cr3t")
+	now := time.Unix(1500000000, 0)
+	valid := signJWT(`{"sub":"someone","exp":1500000060}`, "s3cr3t")
+	if err := verifyJWT(valid, key, now); err != nil {
+		t.Errorf("Expected a valid token but got %v", err)
+	}
+	if err := verifyJWT(valid, key, now.Add(time.Minute)); err == nil {
+		t.Errorf("Expected an expired token to fail")
+	}
+	if err := verifyJWT(valid, []byte("other"), now); err == nil {
+		t.Errorf("Expected a token signed with another key to fail")
+	}
+	if err := verifyJWT(signJWT(`{"nbf":1500000060}`, "s3cr3t"), key, now); err == nil {
+		t.Errorf("Expected a token which is not valid yet to fail")
+	}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + "."
+	if err := verifyJWT(none, key, now); err == nil {
+		t.Errorf("Expected an unsigned token to fail")
+	}
+}
+
+func TestGetGatewaySpec(t *testing.T) {
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Data:       map[string]string{SourceProperty: "module.exports = 1"},
+	}
+	s, err := getGatewaySpec(function)
+	if err != nil || s != nil {
+		t.Errorf("Expected no gateway settings but got %#v: %v", s, err)
+	}
+	for key, value := range map[string]string{
+		GatewayAuthProperty:           "password",
+		GatewayAuthSecretProperty:     "credentials:apiKey",
+		GatewayMaxRequestSizeProperty: "lots",
+		GatewayTimeoutProperty:        "-1s",
+	} {
+		function.Data = map[string]string{key: value}
+		if _, err := getGatewaySpec(function); errorReason(err) != InvalidGatewayReason {
+			t.Errorf("Expected `%s: %s` to be invalid but got %v", key, value, err)
+		}
+	}
+	function.Data = map[string]string{
+		GatewayAuthProperty:        GatewayAuthJWT,
+		GatewayAuthSecretProperty:  "credentials:jwtKey",
+		GatewayCORSOriginsProperty: "https://example.com/\n*",
+		GatewayTimeoutProperty:     "30s",
+	}
+	s, err = getGatewaySpec(function)
+	if err != nil {
+		t.Fatalf("Failed to get the gateway settings: %v", err)
+	}
+	assertEquals(t, s.AuthSecret.Secret, "credentials")
+	assertEquals(t, s.AuthSecret.Key, "jwtKey")
+	assertEquals(t, strings.Join(s.CORSOrigins, ","), "https://example.com,*")
+}
+
+func TestGatewayRoutes(t *testing.T) {
+	var received *http.Request
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		fmt.Fprint(w, "hello")
+	}))
+	defer backend.Close()
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(backend.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to parse the backend URL: %v", err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+
+	kclient := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "credentials", Namespace: testNamespace},
+		Data:       map[string][]byte{"apiKey": []byte("letmein\n")},
+	})
+	g, err := NewGateway(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create the gateway: %v", err)
+	}
+	g.serviceInf.GetStore().Add(&v1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Spec: v1.ServiceSpec{
+			ClusterIP: host,
+			Ports:     []v1.ServicePort{{Port: int32(portNumber)}},
+		},
+	})
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Data: map[string]string{
+			SourceProperty:                "module.exports = 1",
+			GatewayAuthProperty:           GatewayAuthAPIKey,
+			GatewayAuthSecretProperty:     "credentials:apiKey",
+			GatewayCORSOriginsProperty:    "https://example.com",
+			GatewayMaxRequestSizeProperty: "10",
+		},
+	}
+	g.updateRoute(function)
+
+	serve := func(method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest(method, "http://gateway"+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to create the request: %v", err)
+		}
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("GET", "/fn/missing", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected an unknown Function to be not found but got %d", w.Code)
+	}
+	if w := serve("GET", "/fn/hello/greet", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a request without an API key to be unauthorized but got %d", w.Code)
+	}
+	apiKey := map[string]string{GatewayAPIKeyHeader: "letmein", "Origin": "https://example.com"}
+	w := serve("GET", "/fn/hello/greet?name=world", "", apiKey)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the request to be forwarded but got %d: %s", w.Code, w.Body.String())
+	}
+	assertEquals(t, w.Body.String(), "hello")
+	assertEquals(t, w.Header().Get("Access-Control-Allow-Origin"), "https://example.com")
+	assertEquals(t, received.URL.Path, "/greet")
+	assertEquals(t, received.URL.RawQuery, "name=world")
+	assertEquals(t, received.Host, "hello")
+	assertEquals(t, received.Header.Get(GatewayAPIKeyHeader), "")
+
+	if w := serve("POST", "/fn/hello", "far too large a body", apiKey); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected a large request to be rejected but got %d", w.Code)
+	}
+	preflight := map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "POST"}
+	if w := serve("OPTIONS", "/fn/hello", "", preflight); w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") != "POST" {
+		t.Errorf("Expected the preflight request to be allowed but got %d %v", w.Code, w.Header())
+	}
+	preflight["Origin"] = "https://evil.com"
+	if w := serve("OPTIONS", "/fn/hello", "", preflight); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a preflight request from another origin to be forbidden but got %d", w.Code)
+	}
+
+	g.deleteRoute(function)
+	if w := serve("GET", "/fn/hello", "", apiKey); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a deleted Function to be not found but got %d", w.Code)
+	}
+}
+
+func TestGatewayFunctionPath(t *testing.T) {
+	for path, expected := range map[string][]string{
+		"/fn/hello":     {"hello", "/"},
+		"/fn/hello/":    {"hello", "/"},
+		"/fn/hello/a/b": {"hello", "/a/b"},
+		"/other/hello":  {"", ""},
+		"/fn/":          {"", "/"},
+	} {
+		name, rest := gatewayFunctionPath(path)
+		assertEquals(t, name, expected[0])
+		assertEquals(t, rest, expected[1])
+	}
+}
@@ -0,0 +1,114 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/util/intstr"
+)
+
+const (
+	// IdleTimeoutProperty is the data key for how long a Function may receive no requests before
+	// its Deployment is scaled to zero pods, such as `15m`
+	IdleTimeoutProperty = "idleTimeout"
+
+	// LastActivityAnnotation is the annotation on the Deployment of a Function with an idle timeout
+	// which holds the time the activator last forwarded a request to the Function
+	LastActivityAnnotation = "funktion.fabric8.io/lastActivity"
+	// TargetPortAnnotation is the annotation on the Service of a Function with an idle timeout
+	// which holds the target port of the Function pods the activator forwards requests to
+	TargetPortAnnotation = "funktion.fabric8.io/targetPort"
+
+	// ActivatorLabel is the label on the pods of the activator which the Service of a Function
+	// scaled to zero selects
+	ActivatorLabel = "funktion.fabric8.io/activator"
+	// ActivatorPortName is the name of the container port of the activator pods
+	ActivatorPortName = "activator"
+)
+
+// getIdleTimeout returns the idle timeout of the given Function or 0 if it is never scaled to zero.
+// An idle timeout can not be combined with autoscaling as the HorizontalPodAutoscaler would
+// scale the Function straight back up to its minimum number of pods.
+func getIdleTimeout(function *v1.ConfigMap) (time.Duration, error) {
+	text := function.Data[IdleTimeoutProperty]
+	if len(text) == 0 {
+		return 0, nil
+	}
+	if len(function.Data[MaxReplicasProperty]) > 0 {
+		return 0, reasonErrorf(InvalidIdleTimeoutReason, "Property `%s` on the Function ConfigMap %s can not be combined with the autoscaling property `%s`", IdleTimeoutProperty, function.Name, MaxReplicasProperty)
+	}
+	timeout, err := time.ParseDuration(text)
+	if err != nil || timeout <= 0 {
+		return 0, reasonErrorf(InvalidIdleTimeoutReason, "Property `%s` on the Function ConfigMap %s must be a positive duration such as 15m but was `%s`", IdleTimeoutProperty, function.Name, text)
+	}
+	return timeout, nil
+}
+
+// isScaledToZero returns true if the Deployment has been scaled to zero pods
+func isScaledToZero(deployment *v1beta1.Deployment) bool {
+	return deployment != nil && deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0
+}
+
+// lastActivity returns the time the Function of the Deployment last received a request
+func lastActivity(deployment *v1beta1.Deployment) time.Time {
+	if text := deployment.Annotations[LastActivityAnnotation]; len(text) > 0 {
+		if t, err := time.Parse(time.RFC3339, text); err == nil {
+			return t
+		}
+	}
+	return deployment.CreationTimestamp.Time
+}
+
+// pointAtActivator makes the Service of a Function send its requests to the activator recording
+// the target port of the Function pods which the activator forwards them to
+func pointAtActivator(svc *v1.Service) {
+	if len(svc.Spec.Ports) > 0 {
+		port := svc.Spec.Ports[0]
+		target := port.TargetPort.String()
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			target = strconv.Itoa(int(port.Port))
+		}
+		if svc.Annotations == nil {
+			svc.Annotations = map[string]string{}
+		}
+		svc.Annotations[TargetPortAnnotation] = target
+	}
+	svc.Spec.Selector = map[string]string{ActivatorLabel: "true"}
+	for i := range svc.Spec.Ports {
+		svc.Spec.Ports[i].TargetPort = intstr.FromString(ActivatorPortName)
+	}
+}
+
+// scaleToZero scales the new Deployment of a Function down to zero pods once the activator has
+// not forwarded a request to the Function for its idle timeout, returning true if it did so. A Function which is still active is
+// checked again once its timeout could have passed.
+func (c *Operator) scaleToZero(key string, function *v1.ConfigMap, deployment *v1beta1.Deployment) (bool, error) {
+	timeout, err := getIdleTimeout(function)
+	if err != nil || timeout == 0 || isScaledToZero(deployment) {
+		return false, err
+	}
+	idle := time.Since(lastActivity(deployment))
+	if idle < timeout {
+		c.queue.AddAfter(ResourceKey{Key: key, Kind: FunctionKind}, timeout-idle)
+		return false, nil
+	}
+	zero := int32(0)
+	deployment.Spec.Replicas = &zero
+	return true, nil
+}
@@ -0,0 +1,188 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/metrics"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	autoscaling "k8s.io/client-go/1.5/pkg/apis/autoscaling/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+const metricsPrefix = "funktion_operator_"
+
+// operatorMetrics are the metrics updated while reconciling resources
+type operatorMetrics struct {
+	registry *metrics.Registry
+
+	reconciles *metrics.CounterVec
+	errors     *metrics.CounterVec
+	durations  *metrics.HistogramVec
+}
+
+func (c *Operator) newMetrics() *operatorMetrics {
+	r := metrics.NewRegistry()
+	m := &operatorMetrics{
+		registry:   r,
+		reconciles: r.NewCounterVec(metricsPrefix+"reconcile_total", "The number of reconciliations by kind", "kind"),
+		errors:     r.NewCounterVec(metricsPrefix+"reconcile_errors_total", "The number of failed reconciliations by kind", "kind"),
+		durations:  r.NewHistogramVec(metricsPrefix+"reconcile_duration_seconds", "The duration of reconciliations by kind", "kind", metrics.DefaultBuckets),
+	}
+	r.NewGaugeFunc(metricsPrefix+"queue_depth", "The number of resources waiting to be reconciled", func() float64 {
+		return float64(c.queue.Len())
+	})
+	r.NewGaugeVecFunc(metricsPrefix+"cached_resources", "The number of resources in the informer caches by kind", "kind", func() map[string]float64 {
+		return map[string]float64{
+			ConnectorKind: float64(len(c.connectorInf.GetStore().ListKeys())),
+			FlowKind:      float64(len(c.flowInf.GetStore().ListKeys())),
+			RuntimeKind:   float64(len(c.runtimeInf.GetStore().ListKeys())),
+			FunctionKind:  float64(len(c.functionInf.GetStore().ListKeys())),
+		}
+	})
+	r.NewGaugeFunc(metricsPrefix+"managed_deployments", "The number of Deployments generated for Flows and Functions", func() float64 {
+		return float64(countManaged(c.deploymentInf.GetStore()))
+	})
+	r.NewGaugeFunc(metricsPrefix+"managed_services", "The number of Services generated for Functions", func() float64 {
+		return float64(countManaged(c.serviceInf.GetStore()))
+	})
+	return m
+}
+
+// MetricsRegistry returns the registry of the metrics served on /metrics
+func (c *Operator) MetricsRegistry() *metrics.Registry {
+	return c.metrics.registry
+}
+
+// observe records the outcome of reconciling a resource of the given kind
+func (m *operatorMetrics) observe(kind string, start time.Time, err error) {
+	m.reconciles.Inc(kind)
+	m.durations.Observe(kind, time.Since(start).Seconds())
+	if err != nil {
+		m.errors.Inc(kind)
+	}
+}
+
+// countManaged returns the number of objects in the store which were generated for a Flow or Function
+func countManaged(store cache.Store) int {
+	count := 0
+	for _, obj := range store.List() {
+		if isManaged(obj) {
+			count++
+		}
+	}
+	return count
+}
+
+// isManaged returns true if the Deployment, Service or HorizontalPodAutoscaler was generated for a Flow or Function
+func isManaged(obj interface{}) bool {
+	var labels map[string]string
+	switch o := obj.(type) {
+	case *v1beta1.Deployment:
+		labels = o.Labels
+	case *v1.Service:
+		labels = o.Labels
+	case *autoscaling.HorizontalPodAutoscaler:
+		labels = o.Labels
+	}
+	kind := labels[KindLabel]
+	return kind == FlowKind || kind == FunctionKind
+}
+
+// Elector reports the state of the leader election which the operator takes part in
+type Elector interface {
+	// IsLeader returns true while this replica holds the leader lease and so runs the informers
+	IsLeader() bool
+	// Healthy returns true if this replica can take part in the election
+	Healthy() bool
+}
+
+// SetElector sets the leader election whose state /readyz reports. It must be called before
+// the Handler is served.
+func (c *Operator) SetElector(elector Elector) {
+	c.elector = elector
+}
+
+// informersSynced returns whether each informer has completed its initial list
+func (c *Operator) informersSynced() map[string]bool {
+	return map[string]bool{
+		ConnectorKind:  c.connectorInf.HasSynced(),
+		FlowKind:       c.flowInf.HasSynced(),
+		RuntimeKind:    c.runtimeInf.HasSynced(),
+		FunctionKind:   c.functionInf.HasSynced(),
+		DeploymentKind: c.deploymentInf.HasSynced(),
+		ServiceKind:    c.serviceInf.HasSynced(),
+		AutoscalerKind: c.autoscalerInf.HasSynced(),
+	}
+}
+
+// Handler returns the HTTP handler serving /metrics, /healthz and /readyz.
+// /healthz always succeeds while /readyz fails until every informer has synced. With leader
+// election a standby replica does not run the informers so it is ready while it can reach the lock.
+func (c *Operator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c.metrics.registry)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		c.writeSyncState(w, false)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		c.writeSyncState(w, true)
+	})
+	return mux
+}
+
+func (c *Operator) writeSyncState(w http.ResponseWriter, requireSynced bool) {
+	synced := c.informersSynced()
+	kinds := []string{}
+	ready := true
+	for kind, ok := range synced {
+		kinds = append(kinds, kind)
+		ready = ready && ok
+	}
+	sort.Strings(kinds)
+
+	election := ""
+	if c.elector != nil {
+		if c.elector.IsLeader() {
+			election = "leader"
+		} else if c.elector.Healthy() {
+			election = "standby"
+			ready = true
+		} else {
+			election = "standby which can not reach the lock"
+			ready = false
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if requireSynced && !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if len(election) > 0 {
+		fmt.Fprintf(w, "leader election: %s\n", election)
+	}
+	for _, kind := range kinds {
+		state := "synced"
+		if !synced[kind] {
+			state = "not synced"
+		}
+		fmt.Fprintf(w, "%s informer: %s\n", kind, state)
+	}
+}
@@ -0,0 +1,103 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+)
+
+func TestHandler(t *testing.T) {
+	c, err := newOperator(fake.NewSimpleClientset(), log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	c.metrics.observe(FunctionKind, time.Now(), nil)
+	handler := c.Handler()
+
+	expectedStatus := map[string]int{
+		"/healthz": http.StatusOK,
+		// the informers have not been started so they have not synced
+		"/readyz":  http.StatusServiceUnavailable,
+		"/metrics": http.StatusOK,
+	}
+	for path, status := range expectedStatus {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("Expected status %d for %s but got %d", status, path, w.Code)
+		}
+		if path == "/metrics" {
+			body := w.Body.String()
+			for _, expected := range []string{
+				`funktion_operator_reconcile_total{kind="Function"} 1`,
+				"funktion_operator_queue_depth 0",
+				`funktion_operator_cached_resources{kind="Runtime"} 0`,
+				"funktion_operator_managed_deployments 0",
+			} {
+				if !strings.Contains(body, expected) {
+					t.Errorf("Expected the metrics to contain %s but got:\n%s", expected, body)
+				}
+			}
+		}
+	}
+}
+
+type testElector struct {
+	leader  bool
+	healthy bool
+}
+
+func (e *testElector) IsLeader() bool { return e.leader }
+func (e *testElector) Healthy() bool  { return e.healthy }
+
+func TestReadyzWithLeaderElection(t *testing.T) {
+	c, err := newOperator(fake.NewSimpleClientset(), log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	elector := &testElector{}
+	c.SetElector(elector)
+	handler := c.Handler()
+
+	expectedStatus := []struct {
+		leader  bool
+		healthy bool
+		status  int
+	}{
+		// a standby does not run the informers so it is ready as long as it can reach the lock
+		{false, true, http.StatusOK},
+		{false, false, http.StatusServiceUnavailable},
+		// the leader is not ready until its informers have synced
+		{true, true, http.StatusServiceUnavailable},
+	}
+	for _, expected := range expectedStatus {
+		elector.leader = expected.leader
+		elector.healthy = expected.healthy
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != expected.status {
+			t.Errorf("Expected status %d for leader %v healthy %v but got %d: %s", expected.status, expected.leader, expected.healthy, w.Code, w.Body.String())
+		}
+	}
+}
@@ -16,29 +16,34 @@ package funktion
 
 import (
 	"fmt"
+	"net/http"
+	"reflect"
 	"time"
 
 	"github.com/funktionio/funktion/pkg/analytics"
+	funktionclient "github.com/funktionio/funktion/pkg/client"
 	"github.com/funktionio/funktion/pkg/queue"
 
 	"strings"
+	"sync"
 
 	"github.com/go-kit/kit/log"
 	"k8s.io/client-go/1.5/kubernetes"
-	"k8s.io/client-go/1.5/pkg/api"
 	"k8s.io/client-go/1.5/pkg/api/v1"
+	autoscaling "k8s.io/client-go/1.5/pkg/apis/autoscaling/v1"
 	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 	utilruntime "k8s.io/client-go/1.5/pkg/util/runtime"
-	"k8s.io/client-go/1.5/pkg/util/wait"
 	"k8s.io/client-go/1.5/rest"
 	"k8s.io/client-go/1.5/tools/cache"
 )
 
 // Operator manages Funktion Deployments
 type Operator struct {
-	kclient *kubernetes.Clientset
-	//funktionClient *rest.RESTClient
-	logger log.Logger
+	kclient kubernetes.Interface
+	// tclient is the client of the typed resources which is nil if they are not used
+	tclient   *funktionclient.Clientset
+	logger    log.Logger
+	namespace string
 
 	connectorInf  cache.SharedIndexInformer
 	flowInf       cache.SharedIndexInformer
@@ -46,8 +51,31 @@ type Operator struct {
 	functionInf   cache.SharedIndexInformer
 	deploymentInf cache.SharedIndexInformer
 	serviceInf    cache.SharedIndexInformer
+	autoscalerInf cache.SharedIndexInformer
 
-	queue *queue.Queue
+	// typedInfs are the informers of the typed resources by kind
+	typedLock sync.Mutex
+	typedInfs map[string]cache.SharedIndexInformer
+
+	queue    *queue.RateLimitingQueue
+	recorder *eventRecorder
+	metrics  *operatorMetrics
+
+	// schedules are the Functions invoked on a schedule by key
+	scheduleLock sync.Mutex
+	schedules    map[string]*scheduledFunction
+	invokeClient *http.Client
+	// exposer creates the Ingresses or Routes of Functions. It is nil if the exposecontroller exposes them.
+	exposer *exposer
+	// gateway configures the gateway Deployment the operator manages. It is nil if there is no gateway.
+	gateway *GatewayOptions
+	// elector is the leader election the operator takes part in. It is nil without leader election.
+	elector Elector
+	// stopc is closed when the operator stops running
+	stopc <-chan struct{}
+
+	// syncHandler reconciles a resource. It is a field so that tests can observe it.
+	syncHandler func(key *ResourceKey) error
 }
 
 // ResourceKey represents a kind and a key
@@ -63,12 +91,30 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 	if err != nil {
 		return nil, err
 	}
+	c, err := newOperator(client, logger, namespace)
+	if err != nil {
+		return nil, err
+	}
+	c.tclient, err = funktionclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
+func newOperator(client kubernetes.Interface, logger log.Logger, namespace string) (*Operator, error) {
 	c := &Operator{
-		kclient: client,
-		logger:  logger,
-		queue:   queue.New(),
-	}
+		kclient:   client,
+		logger:    logger,
+		namespace: namespace,
+		typedInfs: map[string]cache.SharedIndexInformer{},
+		schedules: map[string]*scheduledFunction{},
+		queue:     queue.NewRateLimiting(queue.DefaultRateLimiter(), maxSyncRetries),
+		recorder:  newEventRecorder(client, logger),
+	}
+	c.syncHandler = c.sync
+	c.invokeClient = &http.Client{Timeout: invocationTimeout}
+	c.metrics = c.newMetrics()
 
 	logger.Log("msg", "creating ListOptions")
 	flowListOpts, err := CreateFlowListOptions()
@@ -98,7 +144,9 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 		NewConfigMapListWatch(c.kclient, *flowListOpts, namespace),
 		&v1.ConfigMap{},
 		resyncPeriod,
-		cache.Indexers{},
+		cache.Indexers{
+			connectorIndex: referenceIndexFunc(ConnectorLabel),
+		},
 	)
 	c.runtimeInf = cache.NewSharedIndexInformer(
 		NewConfigMapListWatch(c.kclient, *runtimeListOpts, namespace),
@@ -110,10 +158,12 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 		NewConfigMapListWatch(c.kclient, *functionListOpts, namespace),
 		&v1.ConfigMap{},
 		resyncPeriod,
-		cache.Indexers{},
+		cache.Indexers{
+			runtimeIndex: referenceIndexFunc(RuntimeLabel),
+		},
 	)
 	c.deploymentInf = cache.NewSharedIndexInformer(
-		cache.NewListWatchFromClient(c.kclient.Extensions().GetRESTClient(), "deployments", namespace, nil),
+		NewDeploymentListWatch(c.kclient, namespace),
 		&v1beta1.Deployment{},
 		resyncPeriod,
 		cache.Indexers{},
@@ -124,6 +174,12 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 		resyncPeriod,
 		cache.Indexers{},
 	)
+	c.autoscalerInf = cache.NewSharedIndexInformer(
+		NewAutoscalerListWatch(c.kclient, namespace),
+		&autoscaling.HorizontalPodAutoscaler{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
 
 	c.connectorInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.handleAddConnector,
@@ -146,7 +202,6 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 		UpdateFunc: c.handleUpdateFunction,
 	})
 	c.deploymentInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		// TODO only look at Funktion related Deployments?
 		AddFunc: func(d interface{}) {
 			c.handleAddDeployment(d)
 		},
@@ -158,7 +213,6 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 		},
 	})
 	c.serviceInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		// TODO only look at Funktion related Services?
 		AddFunc: func(d interface{}) {
 			c.handleAddService(d)
 		},
@@ -169,17 +223,32 @@ func New(cfg *rest.Config, logger log.Logger, namespace string) (*Operator, erro
 			c.handleUpdateService(old, cur)
 		},
 	})
+	c.autoscalerInf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(d interface{}) {
+			c.handleAddAutoscaler(d)
+		},
+		DeleteFunc: func(d interface{}) {
+			c.handleDeleteAutoscaler(d)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			c.handleUpdateAutoscaler(old, cur)
+		},
+	})
 
 	logger.Log("msg", "started up!")
 
 	return c, nil
 }
 
-// Run the controller.
-func (c *Operator) Run(stopc <-chan struct{}) error {
+// Run the controller with the given number of workers. Workers reconcile different
+// resources concurrently but the same resource is never reconciled concurrently.
+func (c *Operator) Run(workers int, stopc <-chan struct{}) error {
 	defer c.queue.ShutDown()
+	c.stopc = stopc
 
-	go c.worker()
+	for i := 0; i < workers; i++ {
+		go c.worker()
+	}
 
 	go c.connectorInf.Run(stopc)
 	go c.flowInf.Run(stopc)
@@ -187,6 +256,17 @@ func (c *Operator) Run(stopc <-chan struct{}) error {
 	go c.functionInf.Run(stopc)
 	go c.deploymentInf.Run(stopc)
 	go c.serviceInf.Run(stopc)
+	go c.autoscalerInf.Run(stopc)
+	if c.tclient != nil {
+		go c.runTypedResources(stopc)
+	}
+	go c.runGateway(stopc)
+
+	go func() {
+		if c.waitForCacheSync(stopc) {
+			c.cleanupOrphans()
+		}
+	}()
 
 	<-stopc
 	return nil
@@ -224,6 +304,11 @@ func (c *Operator) handleDeleteRuntime(obj interface{}) {
 }
 
 func (c *Operator) handleUpdateRuntime(old, cur interface{}) {
+	// Periodic resync may resend the runtime without changes in-between
+	// which would otherwise re-enqueue all of its dependents.
+	if old.(*v1.ConfigMap).ResourceVersion == cur.(*v1.ConfigMap).ResourceVersion {
+		return
+	}
 	key, ok := c.keyFunc(cur)
 	if !ok {
 		return
@@ -288,6 +373,11 @@ func (c *Operator) handleDeleteConnector(obj interface{}) {
 }
 
 func (c *Operator) handleUpdateConnector(old, cur interface{}) {
+	// Periodic resync may resend the connector without changes in-between
+	// which would otherwise re-enqueue all of its dependents.
+	if old.(*v1.ConfigMap).ResourceVersion == cur.(*v1.ConfigMap).ResourceVersion {
+		return
+	}
 	key, ok := c.keyFunc(cur)
 	if !ok {
 		return
@@ -330,61 +420,81 @@ func (c *Operator) handleUpdateFlow(old, cur interface{}) {
 }
 
 func (c *Operator) handleDeleteDeployment(obj interface{}) {
-	if d := c.flowForDeployment(obj); d != nil {
-		c.enqueue(d, DeploymentKind)
-	}
+	c.enqueueManagedBy(obj)
 }
 
 func (c *Operator) handleAddDeployment(obj interface{}) {
-	if d := c.flowForDeployment(obj); d != nil {
-		c.enqueue(d, DeploymentKind)
-	}
+	c.enqueueManagedBy(obj)
 }
 
 func (c *Operator) handleUpdateDeployment(oldo, curo interface{}) {
 	old := oldo.(*v1beta1.Deployment)
 	cur := curo.(*v1beta1.Deployment)
 
-	//c.logger.Log("msg", "update handler", "old", old.ResourceVersion, "cur", cur.ResourceVersion, "kind", "Service"))
-
 	// Periodic resync may resend the deployment without changes in-between.
 	// Also breaks loops created by updating the resource ourselves.
 	if old.ResourceVersion == cur.ResourceVersion {
 		return
 	}
 
-	// Wake up Flow resource the deployment belongs to.
-	if k := c.flowForDeployment(cur); k != nil {
-		c.enqueue(k, DeploymentKind)
-	}
+	// Wake up the Function or Flow the deployment belongs to so that drift is reverted.
+	c.enqueueManagedBy(cur)
 }
+
 func (c *Operator) handleDeleteService(obj interface{}) {
-	if d := c.functionForService(obj); d != nil {
-		c.enqueue(d, ServiceKind)
-	}
+	c.enqueueManagedBy(obj)
 }
 
 func (c *Operator) handleAddService(obj interface{}) {
-	if d := c.functionForService(obj); d != nil {
-		c.enqueue(d, ServiceKind)
-	}
+	c.enqueueManagedBy(obj)
 }
 
 func (c *Operator) handleUpdateService(oldo, curo interface{}) {
 	old := oldo.(*v1.Service)
 	cur := curo.(*v1.Service)
 
-	//c.logger.Log("msg", "update handler", "old", old.ResourceVersion, "cur", cur.ResourceVersion, "kind", "Service")
-
 	// Periodic resync may resend the service without changes in-between.
 	// Also breaks loops created by updating the resource ourselves.
 	if old.ResourceVersion == cur.ResourceVersion {
 		return
 	}
 
-	// Wake up Funktion resource the service belongs to.
-	if k := c.functionForService(cur); k != nil {
-		c.enqueue(k, ServiceKind)
+	// Wake up the Function the service belongs to so that drift is reverted.
+	c.enqueueManagedBy(cur)
+}
+
+func (c *Operator) handleDeleteAutoscaler(obj interface{}) {
+	c.enqueueManagedBy(obj)
+}
+
+func (c *Operator) handleAddAutoscaler(obj interface{}) {
+	c.enqueueManagedBy(obj)
+}
+
+func (c *Operator) handleUpdateAutoscaler(oldo, curo interface{}) {
+	old := oldo.(*autoscaling.HorizontalPodAutoscaler)
+	cur := curo.(*autoscaling.HorizontalPodAutoscaler)
+
+	// The status changes whenever the autoscaler observes the pods so only
+	// changes to the spec or metadata are of interest.
+	if old.ResourceVersion == cur.ResourceVersion ||
+		(reflect.DeepEqual(old.Spec, cur.Spec) && reflect.DeepEqual(old.Labels, cur.Labels) && reflect.DeepEqual(old.Annotations, cur.Annotations)) {
+		return
+	}
+
+	// Wake up the Function the autoscaler belongs to so that drift is reverted.
+	c.enqueueManagedBy(cur)
+}
+
+// enqueueManagedBy enqueues the Function or Flow which a generated Deployment, Service or
+// HorizontalPodAutoscaler belongs to
+func (c *Operator) enqueueManagedBy(obj interface{}) {
+	meta := objectMeta(obj)
+	if meta == nil {
+		return
+	}
+	if kind, key, ok := managedBy(meta); ok {
+		c.enqueue(key, kind)
 	}
 }
 
@@ -403,7 +513,8 @@ func (c *Operator) enqueue(obj interface{}, kind string) {
 		}
 	}
 
-	c.queue.Add(&ResourceKey{
+	// lets add the key by value so that the queue can tell when the same resource is added twice
+	c.queue.Add(ResourceKey{
 		Key:  key,
 		Kind: kind,
 	})
@@ -417,57 +528,26 @@ func (c *Operator) worker() {
 		if quit {
 			return
 		}
-		if err := c.sync(key.(*ResourceKey)); err != nil {
-			utilruntime.HandleError(fmt.Errorf("reconciliation failed, re-enqueueing: %s", err))
-			// We only mark the item as done after waiting. In the meantime
+		resourceKey := key.(ResourceKey)
+		start := time.Now()
+		err := c.syncHandler(&resourceKey)
+		c.metrics.observe(resourceKey.Kind, start, err)
+		if err != nil {
+			// The item is re-added with a per key exponential backoff. In the meantime
 			// other items can be processed but the same item won't be processed again.
-			// This is a trivial form of rate-limiting that is sufficient for our throughput
-			// and latency expectations.
-			go func() {
-				time.Sleep(3 * time.Second)
-				c.queue.Done(key)
-			}()
-			continue
+			if c.queue.AddRateLimited(key) {
+				utilruntime.HandleError(fmt.Errorf("reconciliation failed, re-enqueueing: %s", err))
+			} else {
+				utilruntime.HandleError(fmt.Errorf("reconciliation of %s %s failed %d times, giving up: %s", resourceKey.Kind, resourceKey.Key, maxSyncRetries, err))
+			}
+		} else {
+			c.queue.Forget(key)
 		}
 
 		c.queue.Done(key)
 	}
 }
 
-func (c *Operator) flowForDeployment(obj interface{}) *v1.ConfigMap {
-	key, ok := c.keyFunc(obj)
-	if !ok {
-		return nil
-	}
-	// Namespace/Name are one-to-one so the key will find the respective Funktion resource.
-	k, exists, err := c.flowInf.GetStore().GetByKey(key)
-	if err != nil {
-		c.logger.Log("msg", "Flow lookup failed", "err", err)
-		return nil
-	}
-	if !exists {
-		return nil
-	}
-	return k.(*v1.ConfigMap)
-}
-
-func (c *Operator) functionForService(obj interface{}) *v1.ConfigMap {
-	key, ok := c.keyFunc(obj)
-	if !ok {
-		return nil
-	}
-	// Namespace/Name are one-to-one so the key will find the respective Funktion resource.
-	k, exists, err := c.functionInf.GetStore().GetByKey(key)
-	if err != nil {
-		c.logger.Log("msg", "Function lookup failed", "err", err)
-		return nil
-	}
-	if !exists {
-		return nil
-	}
-	return k.(*v1.ConfigMap)
-}
-
 func (c *Operator) sync(resourceKey *ResourceKey) error {
 	kind := resourceKey.Kind
 	key := resourceKey.Key
@@ -477,50 +557,64 @@ func (c *Operator) sync(resourceKey *ResourceKey) error {
 	case FlowKind:
 		return c.syncFlow(key)
 	case ConnectorKind:
-		return nil
+		return c.syncDependents(key, c.flowInf, connectorIndex, FlowKind)
 	case RuntimeKind:
-		return nil
+		return c.syncDependents(key, c.functionInf, runtimeIndex, FunctionKind)
 	case FunctionKind:
 		return c.syncFunction(key)
-	case DeploymentKind:
-		return nil
-	case ServiceKind:
-		return nil
 	default:
+		if typed, ok := isTypedKind(kind); ok {
+			return c.syncTyped(typed, key)
+		}
 		c.logger.Log("msg", "Unknown kind funktion", "key", key, "kind", kind)
 		return fmt.Errorf("Unknown kind %s for key %s", kind, key)
 	}
 }
 
+// syncDependents re-enqueues every resource in the given informer which refers to the
+// Connector or Runtime with the given key so that changes to it get rolled out
+func (c *Operator) syncDependents(key string, inf cache.SharedIndexInformer, indexName string, kind string) error {
+	dependents, err := inf.GetIndexer().ByIndex(indexName, key)
+	if err != nil {
+		return err
+	}
+	for _, obj := range dependents {
+		c.enqueue(obj, kind)
+	}
+	return nil
+}
+
 func (c *Operator) syncFlow(key string) error {
 	obj, exists, err := c.flowInf.GetIndexer().GetByKey(key)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return c.destroyDeployment(key)
+		// the garbage collector deletes the resources generated for the Flow along with it
+		c.recordDeleted(key, FlowKind)
+		return nil
 	}
 	flow := obj.(*v1.ConfigMap)
+	status := &Status{}
+	return c.updateStatus(flow, status, c.reconcileFlow(key, flow, status))
+}
 
+func (c *Operator) reconcileFlow(key string, flow *v1.ConfigMap, status *Status) error {
 	connectorName := flow.Labels[ConnectorLabel]
 	if len(connectorName) == 0 {
 		return fmt.Errorf("Flow %s/%s does not have label %s", flow.Namespace, flow.Name, ConnectorLabel)
 	}
-	ns := flow.Namespace
-	connectorKey := connectorName
-	if len(ns) > 0 && !strings.Contains(connectorName, "/") {
-		connectorKey = ns + "/" + connectorKey
-	}
-	obj, exists, err = c.connectorInf.GetIndexer().GetByKey(connectorKey)
+	connectorKey := referenceKey(flow.Namespace, connectorName)
+	obj, exists, err := c.connectorInf.GetIndexer().GetByKey(connectorKey)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("Connector %s does not exist for Flow %s/%s current connector keys are %v", connectorKey, flow.Namespace, flow.Name, c.connectorInf.GetIndexer().ListKeys())
+		return reasonErrorf(MissingConnectorReason, "Connector %s does not exist for Flow %s/%s current connector keys are %v", connectorKey, flow.Namespace, flow.Name, c.connectorInf.GetIndexer().ListKeys())
 	}
 	connector := obj.(*v1.ConfigMap)
 	if connector == nil {
-		return fmt.Errorf("Connector %s does not exist for Flow %s/%s", connectorKey, flow.Namespace, flow.Name)
+		return reasonErrorf(MissingConnectorReason, "Connector %s does not exist for Flow %s/%s", connectorKey, flow.Namespace, flow.Name)
 	}
 
 	deploymentClient := c.kclient.Extensions().Deployments(flow.Namespace)
@@ -532,79 +626,60 @@ func (c *Operator) syncFlow(key string) error {
 	if !exists {
 		d, err := makeFlowDeployment(flow, connector, nil)
 		if err != nil {
-			return fmt.Errorf("make deployment: %s", err)
+			return wrapError("make deployment", err)
 		}
-		if _, err := deploymentClient.Create(d); err != nil {
+		d2, err := deploymentClient.Create(d)
+		if err != nil {
 			return fmt.Errorf("create deployment: %s", err)
 		}
+		c.recorder.Normal(flow, CreatedReason, "Created Deployment %s", d2.Name)
+		status.setDeploymentStatus(d2)
 		return nil
 	}
 	d, err := makeFlowDeployment(flow, connector, obj.(*v1beta1.Deployment))
 	if err != nil {
-		return fmt.Errorf("update deployment: %s", err)
+		return wrapError("update deployment", err)
 	}
-	if _, err := deploymentClient.Update(d); err != nil {
+	d2, err := deploymentClient.Update(d)
+	if err != nil {
 		return err
 	}
+	status.setDeploymentStatus(d2)
 	return nil
 }
 
-func (c *Operator) destroyDeployment(key string) error {
-	obj, exists, err := c.deploymentInf.GetStore().GetByKey(key)
+// recordDeleted posts an Event for the Function or Flow with the given key once its generated resources are removed
+func (c *Operator) recordDeleted(key string, kind string) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
-		return err
-	}
-	if !exists {
-		return nil
-	}
-	deployment := obj.(*v1beta1.Deployment)
-
-	// Only necessary until GC is properly implemented in both Kubernetes and OpenShift.
-	scaleClient := c.kclient.Extensions().Scales(deployment.Namespace)
-	if _, err := scaleClient.Update("deployment", &v1beta1.Scale{
-		ObjectMeta: v1.ObjectMeta{
-			Namespace: deployment.Namespace,
-			Name:      deployment.Name,
-		},
-		Spec: v1beta1.ScaleSpec{
-			Replicas: 0,
-		},
-	}); err != nil {
-		return err
+		c.logger.Log("msg", "invalid key", "key", key, "err", err)
+		return
 	}
+	c.recorder.Deleted(namespace, name, kind)
+}
 
-	deploymentClient := c.kclient.Extensions().Deployments(deployment.Namespace)
-	currentGeneration := deployment.Generation
-	if err := wait.PollInfinite(1*time.Second, func() (bool, error) {
-		updatedDeployment, err := deploymentClient.Get(deployment.Name)
-		if err != nil {
-			return false, err
-		}
-		return updatedDeployment.Status.ObservedGeneration >= currentGeneration &&
-			updatedDeployment.Status.Replicas == 0, nil
-	}); err != nil {
-		return err
+// referenceKey returns the store key of a Connector or Runtime referenced by name
+// from a resource in the given namespace
+func referenceKey(namespace, name string) string {
+	if len(namespace) > 0 && !strings.Contains(name, "/") {
+		return namespace + "/" + name
 	}
-
-	// Let's get ready for proper GC by ensuring orphans are not left behind.
-	orphan := false
-	return deploymentClient.Delete(deployment.ObjectMeta.Name, &api.DeleteOptions{OrphanDependents: &orphan})
+	return name
 }
 
-func (c *Operator) destroyService(key string) error {
-	obj, exists, err := c.serviceInf.GetStore().GetByKey(key)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return nil
+// referenceIndexFunc indexes ConfigMaps by the key of the resource referred to by the given label
+func referenceIndexFunc(label string) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		cm, ok := obj.(*v1.ConfigMap)
+		if !ok {
+			return nil, fmt.Errorf("expected a ConfigMap but got %T", obj)
+		}
+		name := cm.Labels[label]
+		if len(name) == 0 {
+			return []string{}, nil
+		}
+		return []string{referenceKey(cm.Namespace, name)}, nil
 	}
-	service := obj.(*v1.Service)
-
-	serviceClient := c.kclient.Services(service.Namespace)
-	// Let's get ready for proper GC by ensuring orphans are not left behind.
-	orphan := false
-	return serviceClient.Delete(service.ObjectMeta.Name, &api.DeleteOptions{OrphanDependents: &orphan})
 }
 
 func (c *Operator) syncFunction(key string) error {
@@ -613,33 +688,38 @@ func (c *Operator) syncFunction(key string) error {
 		return err
 	}
 	if !exists {
-		err = c.destroyDeployment(key)
-		if err != nil {
-			return err
-		}
-		return c.destroyService(key)
+		// every resource generated for the Function is owned by its ConfigMap so the garbage
+		// collector deletes them along with it: the Deployment and Service, the autoscaler,
+		// the revisions and their Deployments and the Ingress or Route
+		c.unschedule(key)
+		c.recordDeleted(key, FunctionKind)
+		return nil
 	}
 	function := obj.(*v1.ConfigMap)
+	status := &Status{}
+	err = c.reconcileFunction(key, function, status)
+	if err == nil {
+		err = c.syncSchedule(key, function)
+	}
+	return c.updateStatus(function, status, err)
+}
 
+func (c *Operator) reconcileFunction(key string, function *v1.ConfigMap, status *Status) error {
 	runtimeName := function.Labels[RuntimeLabel]
 	if len(runtimeName) == 0 {
 		return fmt.Errorf("Function %s/%s does not have label %s", function.Namespace, function.Name, RuntimeLabel)
 	}
-	ns := function.Namespace
-	runtimeKey := runtimeName
-	if len(ns) > 0 && !strings.Contains(runtimeName, "/") {
-		runtimeKey = ns + "/" + runtimeKey
-	}
-	obj, exists, err = c.runtimeInf.GetIndexer().GetByKey(runtimeKey)
+	runtimeKey := referenceKey(function.Namespace, runtimeName)
+	obj, exists, err := c.runtimeInf.GetIndexer().GetByKey(runtimeKey)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("Runtime %s does not exist for Function %s/%s current connector keys are %v", runtimeKey, function.Namespace, function.Name, c.connectorInf.GetIndexer().ListKeys())
+		return reasonErrorf(MissingRuntimeReason, "Runtime %s does not exist for Function %s/%s current runtime keys are %v", runtimeKey, function.Namespace, function.Name, c.runtimeInf.GetIndexer().ListKeys())
 	}
 	runtime := obj.(*v1.ConfigMap)
 	if runtime == nil {
-		return fmt.Errorf("Runtime %s does not exist for Function %s/%s", runtimeKey, function.Namespace, function.Name)
+		return reasonErrorf(MissingRuntimeReason, "Runtime %s does not exist for Function %s/%s", runtimeKey, function.Namespace, function.Name)
 	}
 
 	deploymentClient := c.kclient.Extensions().Deployments(function.Namespace)
@@ -648,25 +728,67 @@ func (c *Operator) syncFunction(key string) error {
 		return err
 	}
 
+	var oldDeployment *v1beta1.Deployment
+	if exists {
+		oldDeployment = obj.(*v1beta1.Deployment)
+	}
+	revision, hash, err := c.syncRevision(function, oldDeployment)
+	if err != nil {
+		return err
+	}
+	status.Revision = revision
+	traffic, err := getTraffic(function)
+	if err != nil {
+		return err
+	}
+
 	var d2 *v1beta1.Deployment
+	var total int32
 	if !exists {
 		d, err := makeFunctionDeployment(function, runtime, nil)
 		if err != nil {
-			return fmt.Errorf("make deployment: %s", err)
+			return wrapError("make deployment", err)
+		}
+		setRevision(d, revision, hash)
+		total = totalReplicas(d)
+		if traffic != nil {
+			applyTraffic(d, traffic, revision)
 		}
 		if d2, err = deploymentClient.Create(d); err != nil {
 			return fmt.Errorf("create deployment: %s", err)
 		}
+		c.recorder.Normal(function, CreatedReason, "Created Deployment %s", d2.Name)
 	} else {
-		d, err := makeFunctionDeployment(function, runtime, obj.(*v1beta1.Deployment))
+		d, err := makeFunctionDeployment(function, runtime, oldDeployment)
+		if err != nil {
+			return wrapError("update deployment", err)
+		}
+		setRevision(d, revision, hash)
+		total = totalReplicas(d)
+		if traffic != nil {
+			applyTraffic(d, traffic, revision)
+		}
+		idle, err := c.scaleToZero(key, function, d)
 		if err != nil {
-			return fmt.Errorf("update deployment: %s", err)
+			return wrapError("update deployment", err)
 		}
 		if d2, err = deploymentClient.Update(d); err != nil {
 			return err
 		}
+		if idle {
+			c.recorder.Normal(function, ScaledToZeroReason, "Scaled Deployment %s to zero pods as the Function was idle", d2.Name)
+		}
+	}
+	status.setDeploymentStatus(d2)
+
+	if err := c.syncAutoscaler(key, function, d2); err != nil {
+		return wrapError("sync autoscaler", err)
 	}
-	serviceClient := c.kclient.Services(function.Namespace)
+	if err := c.syncTraffic(key, function, runtime, traffic, revision, total); err != nil {
+		return wrapError("sync traffic", err)
+	}
+
+	serviceClient := c.kclient.Core().Services(function.Namespace)
 	obj, exists, err = c.serviceInf.GetIndexer().GetByKey(key)
 	if err != nil {
 		c.logger.Log("msg", "==== failed to find service", "key", key)
@@ -676,17 +798,29 @@ func (c *Operator) syncFunction(key string) error {
 	if !exists {
 		s, err := makeFunctionService(function, runtime, nil, d2)
 		if err != nil {
-			return fmt.Errorf("make service: %s", err)
+			return wrapError("make service", err)
+		}
+		url, err := c.syncExpose(function, s)
+		if err != nil {
+			return wrapError("expose service", err)
+		}
+		if len(url) > 0 {
+			s.Annotations[ExposeURLAnnotation] = url
+			status.URL = url
 		}
 		if _, err := serviceClient.Create(s); err != nil {
 			return fmt.Errorf("create service: %s", err)
 		}
+		c.recorder.Normal(function, CreatedReason, "Created Service %s", s.Name)
 		return nil
 	}
 	old := obj.(*v1.Service)
+	if old.Annotations != nil {
+		status.URL = old.Annotations[ExposeURLAnnotation]
+	}
 	s, err := makeFunctionService(function, runtime, old, d2)
 	if err != nil {
-		return fmt.Errorf("update service: %s", err)
+		return wrapError("update service", err)
 	}
 
 	// lets copy any missing annotations
@@ -697,6 +831,18 @@ func (c *Operator) syncFunction(key string) error {
 			}
 		}
 	}
+	if c.exposer != nil {
+		url, err := c.syncExpose(function, s)
+		if err != nil {
+			return wrapError("expose service", err)
+		}
+		if len(url) > 0 {
+			s.Annotations[ExposeURLAnnotation] = url
+		} else {
+			delete(s.Annotations, ExposeURLAnnotation)
+		}
+		status.URL = url
+	}
 	// lets copy across any missing NodePorts
 	s.Spec.Type = old.Spec.Type
 	oldPortCount := len(old.Spec.Ports)
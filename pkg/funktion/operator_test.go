@@ -0,0 +1,201 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/runtime"
+)
+
+const (
+	testNamespace = "default"
+
+	sampleRuntimeDeploymentYaml = `apiVersion: extensions/v1beta1
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - image: funktion/nodejs-runtime
+`
+
+	sampleRuntimeServiceYaml = `apiVersion: v1
+kind: Service
+spec:
+  ports:
+  - port: 80
+    targetPort: 8888
+`
+)
+
+func TestReferenceKey(t *testing.T) {
+	assertEquals(t, referenceKey("default", "nodejs"), "default/nodejs")
+	assertEquals(t, referenceKey("default", "other/nodejs"), "other/nodejs")
+	assertEquals(t, referenceKey("", "nodejs"), "nodejs")
+}
+
+func TestReferenceIndexFunc(t *testing.T) {
+	indexFunc := referenceIndexFunc(RuntimeLabel)
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: "default",
+			Labels: map[string]string{
+				KindLabel:    FunctionKind,
+				RuntimeLabel: "nodejs",
+			},
+		},
+	}
+	keys, err := indexFunc(function)
+	if err != nil {
+		t.Fatalf("Failed to index Function: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("Expected 1 index key but got %v", keys)
+	}
+	assertEquals(t, keys[0], "default/nodejs")
+
+	delete(function.Labels, RuntimeLabel)
+	keys, err = indexFunc(function)
+	if err != nil {
+		t.Fatalf("Failed to index Function: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("Expected no index keys for a Function without a runtime but got %v", keys)
+	}
+}
+
+// TestConcurrentWorkers runs many workers against a fake clientset with the race detector
+// (go test -race) to check that the same resource is never reconciled concurrently
+func TestConcurrentWorkers(t *testing.T) {
+	const (
+		functionCount = 50
+		workerCount   = 16
+		enqueueCount  = 20
+	)
+
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				KindLabel: RuntimeKind,
+			},
+		},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	objects := []runtime.Object{nodejs}
+	functions := []*v1.ConfigMap{}
+	for i := 0; i < functionCount; i++ {
+		function := &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      fmt.Sprintf("function%d", i),
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					KindLabel:    FunctionKind,
+					RuntimeLabel: nodejs.Name,
+				},
+			},
+			Data: map[string]string{
+				SourceProperty: "module.exports = function(context, callback) { callback(200, 'Hello'); };",
+			},
+		}
+		functions = append(functions, function)
+		objects = append(objects, function)
+	}
+
+	client := fake.NewSimpleClientset(objects...)
+	c, err := newOperator(client, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	c.runtimeInf.GetStore().Add(nodejs)
+	for _, function := range functions {
+		c.functionInf.GetStore().Add(function)
+	}
+
+	var lock sync.Mutex
+	active := map[ResourceKey]bool{}
+	synced := map[ResourceKey]int{}
+	c.syncHandler = func(key *ResourceKey) error {
+		lock.Lock()
+		if active[*key] {
+			t.Errorf("%s %s is being reconciled concurrently", key.Kind, key.Key)
+		}
+		active[*key] = true
+		lock.Unlock()
+
+		err := c.sync(key)
+
+		lock.Lock()
+		delete(active, *key)
+		synced[*key]++
+		lock.Unlock()
+		return err
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		workers.Add(1)
+		go func() {
+			c.worker()
+			workers.Done()
+		}()
+	}
+
+	var producers sync.WaitGroup
+	for i := 0; i < enqueueCount; i++ {
+		producers.Add(1)
+		go func() {
+			for _, function := range functions {
+				c.enqueue(function, FunctionKind)
+			}
+			producers.Done()
+		}()
+	}
+	producers.Wait()
+
+	// the workers exit once the queue has been drained
+	c.queue.ShutDown()
+	workers.Wait()
+
+	for _, function := range functions {
+		key := ResourceKey{
+			Kind: FunctionKind,
+			Key:  testNamespace + "/" + function.Name,
+		}
+		if synced[key] == 0 {
+			t.Errorf("Function %s was never reconciled", function.Name)
+		}
+	}
+	deployments, err := client.Extensions().Deployments(testNamespace).List(api.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list deployments: %v", err)
+	}
+	if len(deployments.Items) != functionCount {
+		t.Errorf("Expected %d deployments but found %d", functionCount, len(deployments.Items))
+	}
+}
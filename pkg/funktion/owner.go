@@ -0,0 +1,184 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"time"
+
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	autoscaling "k8s.io/client-go/1.5/pkg/apis/autoscaling/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+// setOwner labels a generated resource with the Function or Flow it was generated for and
+// makes the ConfigMap its controlling owner so that the garbage collector deletes the
+// resource along with it
+func setOwner(meta *v1.ObjectMeta, owner *v1.ConfigMap, kind string) {
+	if meta.Labels == nil {
+		meta.Labels = map[string]string{}
+	}
+	meta.Labels[KindLabel] = kind
+	if len(owner.Name) <= maxLabelValueLength {
+		meta.Labels[ManagedByLabel] = owner.Name
+	} else {
+		// the owner reference is used instead
+		delete(meta.Labels, ManagedByLabel)
+	}
+
+	controller := true
+	ref := v1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       owner.Name,
+		UID:        owner.UID,
+		Controller: &controller,
+	}
+	refs := []v1.OwnerReference{ref}
+	for _, r := range meta.OwnerReferences {
+		if r.UID != owner.UID && !(r.Controller != nil && *r.Controller) {
+			refs = append(refs, r)
+		}
+	}
+	meta.OwnerReferences = refs
+}
+
+// managedBy returns the kind and key of the Function or Flow a generated resource belongs to
+func managedBy(meta *v1.ObjectMeta) (kind string, key string, ok bool) {
+	kind = meta.Labels[KindLabel]
+	if kind != FlowKind && kind != FunctionKind {
+		return "", "", false
+	}
+	name := meta.Labels[ManagedByLabel]
+	if len(name) == 0 {
+		for _, ref := range meta.OwnerReferences {
+			if ref.Kind == "ConfigMap" && ref.Controller != nil && *ref.Controller {
+				name = ref.Name
+			}
+		}
+	}
+	if len(name) == 0 {
+		// resources generated by older versions of the operator are named after their owner
+		name = meta.Name
+	}
+	return kind, referenceKey(meta.Namespace, name), true
+}
+
+// objectMeta returns the metadata of a generated Deployment, Service or HorizontalPodAutoscaler including
+// those in the tombstone of a deleted resource
+func objectMeta(obj interface{}) *v1.ObjectMeta {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	switch o := obj.(type) {
+	case *v1beta1.Deployment:
+		return &o.ObjectMeta
+	case *v1.Service:
+		return &o.ObjectMeta
+	case *autoscaling.HorizontalPodAutoscaler:
+		return &o.ObjectMeta
+	}
+	return nil
+}
+
+// hasOwnerReference returns true if the garbage collector will delete the resource along with its owner
+func hasOwnerReference(meta *v1.ObjectMeta) bool {
+	return len(meta.OwnerReferences) > 0
+}
+
+// deleteOptions cascades the deletion of a resource to the resources it owns
+func deleteOptions() *api.DeleteOptions {
+	orphan := false
+	return &api.DeleteOptions{OrphanDependents: &orphan}
+}
+
+// waitForCacheSync waits until all the informers have completed their initial list,
+// returning false if stopc is closed first
+func (c *Operator) waitForCacheSync(stopc <-chan struct{}) bool {
+	for {
+		synced := true
+		for _, ok := range c.informersSynced() {
+			synced = synced && ok
+		}
+		if synced {
+			return true
+		}
+		select {
+		case <-stopc:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// cleanupOrphans handles the Deployments and Services generated by older versions of the
+// operator which have no owner reference. Those whose Function or Flow still exists are
+// reconciled so that the owner reference is added; the rest are deleted.
+func (c *Operator) cleanupOrphans() {
+	for _, obj := range c.deploymentInf.GetStore().List() {
+		d := obj.(*v1beta1.Deployment)
+		if !isManaged(d) || hasOwnerReference(&d.ObjectMeta) {
+			continue
+		}
+		if c.enqueueOwner(&d.ObjectMeta) {
+			continue
+		}
+		c.logger.Log("msg", "deleting orphaned deployment", "name", d.Name, "namespace", d.Namespace)
+		err := c.kclient.Extensions().Deployments(d.Namespace).Delete(d.Name, deleteOptions())
+		if err != nil && !errors.IsNotFound(err) {
+			c.logger.Log("msg", "failed to delete orphaned deployment", "name", d.Name, "namespace", d.Namespace, "err", err)
+		}
+	}
+	for _, obj := range c.serviceInf.GetStore().List() {
+		s := obj.(*v1.Service)
+		if !isManaged(s) || hasOwnerReference(&s.ObjectMeta) {
+			continue
+		}
+		if c.enqueueOwner(&s.ObjectMeta) {
+			continue
+		}
+		c.logger.Log("msg", "deleting orphaned service", "name", s.Name, "namespace", s.Namespace)
+		err := c.kclient.Core().Services(s.Namespace).Delete(s.Name, deleteOptions())
+		if err != nil && !errors.IsNotFound(err) {
+			c.logger.Log("msg", "failed to delete orphaned service", "name", s.Name, "namespace", s.Namespace, "err", err)
+		}
+	}
+}
+
+// enqueueOwner enqueues the Function or Flow a generated resource was created for,
+// returning false if it no longer exists so the resource can be deleted
+func (c *Operator) enqueueOwner(meta *v1.ObjectMeta) bool {
+	kind, key, ok := managedBy(meta)
+	if !ok {
+		return false
+	}
+	inf := c.functionInf
+	if kind == FlowKind {
+		inf = c.flowInf
+	}
+	owner, exists, err := inf.GetStore().GetByKey(key)
+	if err != nil {
+		// lets not delete anything we are unsure about
+		c.logger.Log("msg", "failed to find owner", "name", meta.Name, "namespace", meta.Namespace, "err", err)
+		return true
+	}
+	if !exists {
+		return false
+	}
+	c.enqueue(owner, kind)
+	return true
+}
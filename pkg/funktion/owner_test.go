@@ -0,0 +1,217 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/types"
+)
+
+func TestSetOwner(t *testing.T) {
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "hello",
+			UID:  types.UID("1234"),
+		},
+	}
+	meta := &v1.ObjectMeta{
+		OwnerReferences: []v1.OwnerReference{
+			{Kind: "Something", Name: "other", UID: types.UID("5678")},
+		},
+	}
+	setOwner(meta, function, FunctionKind)
+	setOwner(meta, function, FunctionKind)
+
+	if len(meta.OwnerReferences) != 2 {
+		t.Fatalf("Expected 2 owner references but got %v", meta.OwnerReferences)
+	}
+	ref := meta.OwnerReferences[0]
+	assertEquals(t, ref.Kind, "ConfigMap")
+	assertEquals(t, ref.Name, "hello")
+	assertEquals(t, string(ref.UID), "1234")
+	if ref.Controller == nil || !*ref.Controller {
+		t.Errorf("Expected the Function to be the controller of the resource")
+	}
+
+	kind, key, ok := managedBy(meta)
+	if !ok {
+		t.Fatalf("Expected the resource to be managed")
+	}
+	assertEquals(t, kind, FunctionKind)
+	assertEquals(t, key, "hello")
+}
+
+func TestManagedBy(t *testing.T) {
+	meta := &v1.ObjectMeta{
+		Name:      "hello-deployment",
+		Namespace: testNamespace,
+		Labels: map[string]string{
+			KindLabel:      FlowKind,
+			ManagedByLabel: "hello",
+		},
+	}
+	kind, key, ok := managedBy(meta)
+	if !ok {
+		t.Fatalf("Expected the resource to be managed")
+	}
+	assertEquals(t, kind, FlowKind)
+	assertEquals(t, key, testNamespace+"/hello")
+
+	delete(meta.Labels, KindLabel)
+	if _, _, ok := managedBy(meta); ok {
+		t.Errorf("Expected a resource without a kind to not be managed")
+	}
+}
+
+func TestCleanupOrphans(t *testing.T) {
+	managedLabels := func(kind string) map[string]string {
+		return map[string]string{KindLabel: kind}
+	}
+	orphan := &v1beta1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "deleted", Namespace: testNamespace, Labels: managedLabels(FunctionKind)},
+	}
+	adopted := &v1beta1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace, Labels: managedLabels(FunctionKind)},
+	}
+	unmanaged := &v1beta1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: testNamespace},
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace, Labels: managedLabels(FunctionKind)},
+	}
+
+	client := fake.NewSimpleClientset(orphan, adopted, unmanaged, function)
+	c, err := newOperator(client, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	for _, d := range []*v1beta1.Deployment{orphan, adopted, unmanaged} {
+		c.deploymentInf.GetStore().Add(d)
+	}
+	c.functionInf.GetStore().Add(function)
+
+	c.cleanupOrphans()
+
+	deployments, err := client.Extensions().Deployments(testNamespace).List(api.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list deployments: %v", err)
+	}
+	names := map[string]bool{}
+	for _, d := range deployments.Items {
+		names[d.Name] = true
+	}
+	if names["deleted"] {
+		t.Errorf("Expected the orphaned deployment to be deleted")
+	}
+	if !names["hello"] || !names["other"] {
+		t.Errorf("Expected the adopted and unmanaged deployments to be kept but got %v", names)
+	}
+	if c.queue.Len() != 1 {
+		t.Errorf("Expected the owner of the adopted deployment to be enqueued but the queue has %d items", c.queue.Len())
+	}
+}
+
+func TestDeletedFunctionIsLeftToTheGarbageCollector(t *testing.T) {
+	nodejs := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			UID:       types.UID("1234"),
+			Labels: map[string]string{
+				KindLabel:    FunctionKind,
+				RuntimeLabel: "nodejs",
+			},
+		},
+		Data: map[string]string{
+			SourceProperty:      "module.exports = function(context, callback) { callback(200, 'Hello'); };",
+			MaxReplicasProperty: "3",
+		},
+	}
+	client := fake.NewSimpleClientset(nodejs, function)
+	c, err := newOperator(client, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	c.runtimeInf.GetStore().Add(nodejs)
+	c.functionInf.GetStore().Add(function)
+	key := testNamespace + "/hello"
+	if err := c.syncFunction(key); err != nil {
+		t.Fatalf("Failed to sync the Function: %v", err)
+	}
+
+	owned := map[string]*v1.ObjectMeta{}
+	deployments, err := client.Extensions().Deployments(testNamespace).List(api.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list deployments: %v", err)
+	}
+	for i := range deployments.Items {
+		owned["Deployment "+deployments.Items[i].Name] = &deployments.Items[i].ObjectMeta
+	}
+	services, err := client.Core().Services(testNamespace).List(api.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list services: %v", err)
+	}
+	for i := range services.Items {
+		owned["Service "+services.Items[i].Name] = &services.Items[i].ObjectMeta
+	}
+	hpas, err := client.Autoscaling().HorizontalPodAutoscalers(testNamespace).List(api.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list autoscalers: %v", err)
+	}
+	for i := range hpas.Items {
+		owned["HorizontalPodAutoscaler "+hpas.Items[i].Name] = &hpas.Items[i].ObjectMeta
+	}
+	revision, err := client.Core().ConfigMaps(testNamespace).Get(RevisionName("hello", 1))
+	if err != nil {
+		t.Fatalf("Failed to get the revision: %v", err)
+	}
+	owned["revision "+revision.Name] = &revision.ObjectMeta
+	if len(owned) != 4 {
+		t.Fatalf("Expected a Deployment, Service, autoscaler and revision but got %v", owned)
+	}
+	for name, meta := range owned {
+		if len(meta.OwnerReferences) != 1 || meta.OwnerReferences[0].UID != function.UID {
+			t.Errorf("Expected the %s to be owned by the Function but got %v", name, meta.OwnerReferences)
+		}
+	}
+
+	c.functionInf.GetStore().Delete(function)
+	client.ClearActions()
+	if err := c.syncFunction(key); err != nil {
+		t.Fatalf("Failed to sync the deleted Function: %v", err)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "delete" {
+			t.Errorf("Expected the garbage collector to delete the generated resources but got %v", action)
+		}
+	}
+}
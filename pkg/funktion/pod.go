@@ -0,0 +1,245 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/ghodss/yaml"
+	"k8s.io/client-go/1.5/pkg/api/resource"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+const (
+	// CPURequestProperty is the data key for the CPU requested by the container of a Function or Flow, such as `100m`
+	CPURequestProperty = "cpuRequest"
+	// MemoryRequestProperty is the data key for the memory requested by the container of a Function or Flow, such as `128Mi`
+	MemoryRequestProperty = "memoryRequest"
+	// CPULimitProperty is the data key for the CPU limit of the container of a Function or Flow
+	CPULimitProperty = "cpuLimit"
+	// MemoryLimitProperty is the data key for the memory limit of the container of a Function or Flow
+	MemoryLimitProperty = "memoryLimit"
+	// NodeSelectorProperty represents a newline terminated list of NAME=VALUE node labels the pods
+	// of a Function or Flow are scheduled on
+	NodeSelectorProperty = "nodeSelector"
+	// TolerationsProperty is the data key for the YAML list of tolerations of the pods of a Function or Flow
+	TolerationsProperty = "tolerations"
+	// AffinityProperty is the data key for the YAML affinity of the pods of a Function or Flow
+	AffinityProperty = "affinity"
+	// ServiceAccountProperty is the data key for the service account the pods of a Function or Flow run as
+	ServiceAccountProperty = "serviceAccount"
+)
+
+// podResourceProperties are the data keys of the compute resources of a Function or Flow
+var podResourceProperties = []struct {
+	key      string
+	limit    bool
+	resource v1.ResourceName
+}{
+	{CPURequestProperty, false, v1.ResourceCPU},
+	{MemoryRequestProperty, false, v1.ResourceMemory},
+	{CPULimitProperty, true, v1.ResourceCPU},
+	{MemoryLimitProperty, true, v1.ResourceMemory},
+}
+
+// ParseToleration parses a toleration of the form key=value:Effect, key:Effect or key=value
+func ParseToleration(text string) (v1.Toleration, error) {
+	answer := v1.Toleration{Operator: v1.TolerationOpExists}
+	rest := text
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		answer.Effect = v1.TaintEffect(rest[i+1:])
+		rest = rest[:i]
+		switch answer.Effect {
+		case v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule:
+		default:
+			return answer, fmt.Errorf("Unknown effect `%s` in toleration `%s`", answer.Effect, text)
+		}
+	}
+	pair := strings.SplitN(rest, "=", 2)
+	answer.Key = pair[0]
+	if len(pair) == 2 {
+		answer.Operator = v1.TolerationOpEqual
+		answer.Value = pair[1]
+	}
+	if len(answer.Key) == 0 {
+		return answer, fmt.Errorf("Expecting `key=value:Effect` but got: %s", text)
+	}
+	return answer, nil
+}
+
+// getPodSettings returns the resources and scheduling constraints of the pods of the given
+// Function or Flow or nil if it has none
+func getPodSettings(cm *v1.ConfigMap, kind string) (*spec.PodSettings, error) {
+	data := cm.Data
+	invalid := func(key string, err error) error {
+		return reasonErrorf(InvalidDeploymentReason, "Invalid property `%s` on the %s ConfigMap %s: %v", key, kind, cm.Name, err)
+	}
+	settings := &spec.PodSettings{}
+	found := false
+	for _, p := range podResourceProperties {
+		text := data[p.key]
+		if len(text) == 0 {
+			continue
+		}
+		q, err := resource.ParseQuantity(text)
+		if err != nil {
+			return nil, invalid(p.key, err)
+		}
+		list := &settings.Resources.Requests
+		if p.limit {
+			list = &settings.Resources.Limits
+		}
+		if *list == nil {
+			*list = v1.ResourceList{}
+		}
+		(*list)[p.resource] = q
+		found = true
+	}
+	if text := data[NodeSelectorProperty]; len(text) > 0 {
+		if err := validateEnvVars(text); err != nil {
+			return nil, invalid(NodeSelectorProperty, err)
+		}
+		settings.NodeSelector = map[string]string{}
+		for _, env := range parseEnvVars(text) {
+			settings.NodeSelector[env.Name] = env.Value
+		}
+		found = true
+	}
+	if text := data[TolerationsProperty]; len(text) > 0 {
+		if err := yaml.Unmarshal([]byte(text), &settings.Tolerations); err != nil {
+			return nil, invalid(TolerationsProperty, err)
+		}
+		found = true
+	}
+	if text := data[AffinityProperty]; len(text) > 0 {
+		settings.Affinity = &v1.Affinity{}
+		if err := yaml.Unmarshal([]byte(text), settings.Affinity); err != nil {
+			return nil, invalid(AffinityProperty, err)
+		}
+		found = true
+	}
+	if text := data[ServiceAccountProperty]; len(text) > 0 {
+		settings.ServiceAccount = text
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// setPodSettings stores the resources and scheduling constraints of the pods in the ConfigMap of a Function or Flow
+func setPodSettings(cm *v1.ConfigMap, settings *spec.PodSettings) error {
+	if settings == nil {
+		return nil
+	}
+	for _, p := range podResourceProperties {
+		list := settings.Resources.Requests
+		if p.limit {
+			list = settings.Resources.Limits
+		}
+		if q, ok := list[p.resource]; ok {
+			cm.Data[p.key] = q.String()
+		}
+	}
+	if len(settings.NodeSelector) > 0 {
+		lines := []string{}
+		for k, v := range settings.NodeSelector {
+			lines = append(lines, k+"="+v)
+		}
+		sort.Strings(lines)
+		cm.Data[NodeSelectorProperty] = strings.Join(lines, "\n")
+	}
+	if len(settings.Tolerations) > 0 {
+		if err := setDataYaml(cm, TolerationsProperty, settings.Tolerations); err != nil {
+			return err
+		}
+	}
+	if settings.Affinity != nil {
+		if err := setDataYaml(cm, AffinityProperty, settings.Affinity); err != nil {
+			return err
+		}
+	}
+	setDataText(cm, ServiceAccountProperty, settings.ServiceAccount)
+	return nil
+}
+
+// applyPodSettings merges the resources and scheduling constraints of a Function or Flow into
+// the pod template of its Deployment. The resources apply to the first container which runs
+// the Function or Flow.
+func applyPodSettings(template *v1.PodTemplateSpec, settings *spec.PodSettings) error {
+	if settings == nil {
+		return nil
+	}
+	podSpec := &template.Spec
+	if len(podSpec.Containers) > 0 {
+		resources := &podSpec.Containers[0].Resources
+		resources.Requests = mergeResources(resources.Requests, settings.Resources.Requests)
+		resources.Limits = mergeResources(resources.Limits, settings.Resources.Limits)
+	}
+	if len(settings.NodeSelector) > 0 {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = map[string]string{}
+		}
+		for k, v := range settings.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	if len(settings.ServiceAccount) > 0 {
+		podSpec.ServiceAccountName = settings.ServiceAccount
+	}
+
+	// the scheduler reads the tolerations and affinity of a pod from its alpha annotations
+	if len(settings.Tolerations) > 0 {
+		if err := setPodAnnotationJSON(template, v1.TolerationsAnnotationKey, settings.Tolerations); err != nil {
+			return err
+		}
+	}
+	if settings.Affinity != nil {
+		if err := setPodAnnotationJSON(template, v1.AffinityAnnotationKey, settings.Affinity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeResources(list v1.ResourceList, overrides v1.ResourceList) v1.ResourceList {
+	if len(overrides) == 0 {
+		return list
+	}
+	if list == nil {
+		list = v1.ResourceList{}
+	}
+	for k, v := range overrides {
+		list[k] = v
+	}
+	return list
+}
+
+func setPodAnnotationJSON(template *v1.PodTemplateSpec, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal the pod annotation %s: %v", key, err)
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[key] = string(data)
+	return nil
+}
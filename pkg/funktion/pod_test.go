@@ -0,0 +1,102 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestParseToleration(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected v1.Toleration
+	}{
+		{"dedicated=functions:NoSchedule", v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "functions", Effect: v1.TaintEffectNoSchedule}},
+		{"gpu:PreferNoSchedule", v1.Toleration{Key: "gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectPreferNoSchedule}},
+		{"dedicated=functions", v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "functions"}},
+	}
+	for _, test := range tests {
+		actual, err := ParseToleration(test.text)
+		if err != nil {
+			t.Errorf("Failed to parse toleration `%s`: %v", test.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected toleration `%s` to be %#v but got %#v", test.text, test.expected, actual)
+		}
+	}
+	for _, text := range []string{"", ":NoSchedule", "dedicated=functions:Sometimes"} {
+		if _, err := ParseToleration(text); err == nil {
+			t.Errorf("Expected an error parsing toleration `%s`", text)
+		}
+	}
+}
+
+func TestFunctionDeploymentPodSettings(t *testing.T) {
+	runtime := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "nodejs", Namespace: testNamespace},
+		Data:       map[string]string{DeploymentProperty: sampleRuntimeDeploymentYaml},
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Data: map[string]string{
+			SourceProperty:         "module.exports = 1",
+			CPURequestProperty:     "100m",
+			MemoryLimitProperty:    "256Mi",
+			NodeSelectorProperty:   "disktype=ssd",
+			TolerationsProperty:    "- key: dedicated\n  operator: Equal\n  value: functions\n  effect: NoSchedule\n",
+			ServiceAccountProperty: "functions",
+		},
+	}
+	d, err := makeFunctionDeployment(function, runtime, nil)
+	if err != nil {
+		t.Fatalf("Failed to make the deployment: %v", err)
+	}
+	podSpec := d.Spec.Template.Spec
+	resources := podSpec.Containers[0].Resources
+	cpu := resources.Requests[v1.ResourceCPU]
+	memory := resources.Limits[v1.ResourceMemory]
+	assertEquals(t, cpu.String(), "100m")
+	assertEquals(t, memory.String(), "256Mi")
+	if _, ok := resources.Limits[v1.ResourceCPU]; ok {
+		t.Errorf("Expected no CPU limit but got %v", resources.Limits)
+	}
+	assertEquals(t, podSpec.NodeSelector["disktype"], "ssd")
+	assertEquals(t, podSpec.ServiceAccountName, "functions")
+	assertEquals(t, d.Spec.Template.Annotations[v1.TolerationsAnnotationKey], `[{"key":"dedicated","operator":"Equal","value":"functions","effect":"NoSchedule"}]`)
+
+	converted, err := ConfigMapToFunction(&v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Labels: map[string]string{KindLabel: FunctionKind}},
+		Data:       function.Data,
+	})
+	if err != nil {
+		t.Fatalf("Failed to convert the ConfigMap: %v", err)
+	}
+	cm, err := FunctionToConfigMap(converted)
+	if err != nil {
+		t.Fatalf("Failed to convert the Function: %v", err)
+	}
+	for _, key := range []string{CPURequestProperty, MemoryLimitProperty, NodeSelectorProperty, ServiceAccountProperty} {
+		assertEquals(t, cm.Data[key], function.Data[key])
+	}
+
+	function.Data[MemoryLimitProperty] = "lots"
+	if _, err := makeFunctionDeployment(function, runtime, nil); errorReason(err) != InvalidDeploymentReason {
+		t.Errorf("Expected an invalid memory limit to fail but got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/labels"
+)
+
+const (
+	// RevisionLabel is the label holding the number of a Function revision on the revision
+	// ConfigMap and on the Deployment and pods running that revision
+	RevisionLabel = "funktion.fabric8.io/revision"
+	// RevisionHashAnnotation is the annotation holding the hash of the Runtime and the properties
+	// recorded in a Function revision
+	RevisionHashAnnotation = "funktion.fabric8.io/revisionHash"
+	// RevisionTimestampAnnotation is the annotation holding the time a Function revision was recorded
+	RevisionTimestampAnnotation = "funktion.fabric8.io/revisionTimestamp"
+)
+
+// revisionDataKeys are the properties of a Function ConfigMap which change the Deployment of the
+// Function and are recorded in its revisions
+var revisionDataKeys = append([]string{
+	SourceProperty,
+	EnvVarsProperty,
+	SecretEnvVarsProperty,
+	SecretMountsProperty,
+}, podDataKeys...)
+
+// Revision is an immutable record of the Runtime and the properties of a Function which
+// change its Deployment
+type Revision struct {
+	Number    int
+	Hash      string
+	Timestamp time.Time
+	// Runtime is the Runtime of the revision or empty for revisions recorded without one
+	Runtime string
+	// Data holds the properties of the Function ConfigMap in revisionDataKeys
+	Data map[string]string
+}
+
+// revisionHash returns the hash of the parts of a Function recorded in its revisions
+func revisionHash(function *v1.ConfigMap) string {
+	hash := sha256.New()
+	io.WriteString(hash, function.Labels[RuntimeLabel])
+	for _, key := range revisionDataKeys {
+		io.WriteString(hash, "\x00")
+		io.WriteString(hash, function.Data[key])
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// revisionData returns the properties of a Function ConfigMap recorded in its revisions
+func revisionData(data map[string]string) map[string]string {
+	answer := map[string]string{}
+	for _, key := range revisionDataKeys {
+		if value, ok := data[key]; ok {
+			answer[key] = value
+		}
+	}
+	return answer
+}
+
+// RevisionName returns the name of the ConfigMap holding the given revision of a Function
+func RevisionName(function string, number int) string {
+	return fmt.Sprintf("%s-rev-%d", function, number)
+}
+
+// ListRevisions returns the revisions of the Function with the given name ordered by number
+func ListRevisions(kclient kubernetes.Interface, namespace string, function string) ([]*Revision, error) {
+	selector, err := labels.Parse(KindLabel + "=" + RevisionKind + "," + ManagedByLabel + "=" + function)
+	if err != nil {
+		return nil, err
+	}
+	list, err := kclient.Core().ConfigMaps(namespace).List(api.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	answer := []*Revision{}
+	for _, cm := range list.Items {
+		number, err := strconv.Atoi(cm.Labels[RevisionLabel])
+		if err != nil {
+			continue
+		}
+		timestamp, err := time.Parse(time.RFC3339, cm.Annotations[RevisionTimestampAnnotation])
+		if err != nil {
+			timestamp = cm.CreationTimestamp.Time
+		}
+		answer = append(answer, &Revision{
+			Number:    number,
+			Hash:      cm.Annotations[RevisionHashAnnotation],
+			Timestamp: timestamp,
+			Runtime:   cm.Labels[RuntimeLabel],
+			Data:      revisionData(cm.Data),
+		})
+	}
+	sort.Sort(revisionsByNumber(answer))
+	return answer, nil
+}
+
+type revisionsByNumber []*Revision
+
+func (r revisionsByNumber) Len() int           { return len(r) }
+func (r revisionsByNumber) Less(i, j int) bool { return r[i].Number < r[j].Number }
+func (r revisionsByNumber) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// ApplyRevision restores the Runtime and every part of a Function recorded in a revision
+func ApplyRevision(function *spec.Function, revision *Revision) error {
+	runtime := revision.Runtime
+	if len(runtime) == 0 {
+		runtime = function.Spec.Runtime
+	}
+	recorded, err := ConfigMapToFunction(&v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   function.Name,
+			Labels: map[string]string{KindLabel: FunctionKind, RuntimeLabel: runtime},
+		},
+		Data: revision.Data,
+	})
+	if err != nil {
+		return err
+	}
+	function.Spec.Runtime = recorded.Spec.Runtime
+	function.Spec.Source = recorded.Spec.Source
+	function.Spec.Env = recorded.Spec.Env
+	function.Spec.SecretEnv = recorded.Spec.SecretEnv
+	function.Spec.SecretMounts = recorded.Spec.SecretMounts
+	function.Spec.Pod = recorded.Spec.Pod
+	return nil
+}
+
+func makeRevision(function *v1.ConfigMap, number int, hash string) *v1.ConfigMap {
+	controller := true
+	return &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      RevisionName(function.Name, number),
+			Namespace: function.Namespace,
+			Labels: map[string]string{
+				KindLabel:      RevisionKind,
+				ManagedByLabel: function.Name,
+				RevisionLabel:  strconv.Itoa(number),
+				RuntimeLabel:   function.Labels[RuntimeLabel],
+			},
+			Annotations: map[string]string{
+				RevisionHashAnnotation:      hash,
+				RevisionTimestampAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+			// the revisions are deleted along with their Function
+			OwnerReferences: []v1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       function.Name,
+					UID:        function.UID,
+					Controller: &controller,
+				},
+			},
+		},
+		Data: revisionData(function.Data),
+	}
+}
+
+// syncRevision returns the number and hash of the revision of the Function, recording a new
+// revision if its Runtime or recorded properties have not been seen before. The number is 0
+// if the Function has no revisions.
+func (c *Operator) syncRevision(function *v1.ConfigMap, old *v1beta1.Deployment) (int, string, error) {
+	if len(function.Name) > maxLabelValueLength {
+		// the revisions could not be labelled with the name of the Function
+		return 0, "", nil
+	}
+	hash := revisionHash(function)
+	if old != nil && old.Annotations[RevisionHashAnnotation] == hash {
+		if number, err := strconv.Atoi(old.Labels[RevisionLabel]); err == nil {
+			return number, hash, nil
+		}
+	}
+	revisions, err := ListRevisions(c.kclient, function.Namespace, function.Name)
+	if err != nil {
+		return 0, "", err
+	}
+	number := 1
+	for _, r := range revisions {
+		if r.Hash == hash {
+			return r.Number, hash, nil
+		}
+		number = r.Number + 1
+	}
+	revision := makeRevision(function, number, hash)
+	if _, err := c.kclient.Core().ConfigMaps(function.Namespace).Create(revision); err != nil {
+		return 0, "", fmt.Errorf("create revision: %s", err)
+	}
+	c.recorder.Normal(function, CreatedReason, "Recorded revision %d of the Function", number)
+	return number, hash, nil
+}
+
+// setRevision labels the Deployment and its pods with the active revision of the Function
+func setRevision(deployment *v1beta1.Deployment, number int, hash string) {
+	if number == 0 {
+		return
+	}
+	value := strconv.Itoa(number)
+	if deployment.Labels == nil {
+		deployment.Labels = map[string]string{}
+	}
+	deployment.Labels[RevisionLabel] = value
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[RevisionHashAnnotation] = hash
+	if deployment.Spec.Template.Labels == nil {
+		deployment.Spec.Template.Labels = map[string]string{}
+	}
+	deployment.Spec.Template.Labels[RevisionLabel] = value
+}
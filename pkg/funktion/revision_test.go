@@ -0,0 +1,124 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+func TestRevisions(t *testing.T) {
+	kclient := fake.NewSimpleClientset()
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			UID:       "1234",
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty:  "module.exports = 1",
+			EnvVarsProperty: "GREETING=hello",
+		},
+	}
+
+	expectRevision := func(expected int) *v1beta1.Deployment {
+		number, hash, err := c.syncRevision(function, nil)
+		if err != nil {
+			t.Fatalf("Failed to sync the revision: %v", err)
+		}
+		if number != expected {
+			t.Errorf("Expected revision %d but got %d", expected, number)
+		}
+		d := &v1beta1.Deployment{}
+		setRevision(d, number, hash)
+		return d
+	}
+	expectRevision(1)
+	expectRevision(1)
+	function.Data[SourceProperty] = "module.exports = 2"
+	d := expectRevision(2)
+	assertEquals(t, d.Labels[RevisionLabel], "2")
+	assertEquals(t, d.Spec.Template.Labels[RevisionLabel], "2")
+	// changes which are not recorded in revisions do not create one
+	function.Data[DebugProperty] = "true"
+	if number, _, _ := c.syncRevision(function, d); number != 2 {
+		t.Errorf("Expected revision 2 but got %d", number)
+	}
+	function.Data[SourceProperty] = "module.exports = 1"
+	expectRevision(1)
+	// as do the secrets, pod settings and Runtime which change the Deployment
+	function.Data[SecretEnvVarsProperty] = "API_KEY=credentials:apiKey"
+	expectRevision(3)
+	function.Data[CPURequestProperty] = "100m"
+	expectRevision(4)
+	function.Labels[RuntimeLabel] = "java"
+	expectRevision(5)
+	function.Labels[RuntimeLabel] = "nodejs"
+	expectRevision(4)
+
+	revisions, err := ListRevisions(kclient, testNamespace, "hello")
+	if err != nil {
+		t.Fatalf("Failed to list the revisions: %v", err)
+	}
+	if len(revisions) != 5 || revisions[0].Number != 1 || revisions[1].Number != 2 {
+		t.Fatalf("Expected revisions 1 to 5 but got %v", revisions)
+	}
+	cm, err := kclient.Core().ConfigMaps(testNamespace).Get(RevisionName("hello", 2))
+	if err != nil {
+		t.Fatalf("Failed to get the revision ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Labels[KindLabel], RevisionKind)
+	assertEquals(t, string(cm.OwnerReferences[0].UID), "1234")
+
+	restored := &spec.Function{Spec: spec.FunctionSpec{Runtime: "nodejs"}}
+	if err := ApplyRevision(restored, revisions[1]); err != nil {
+		t.Fatalf("Failed to apply revision 2: %v", err)
+	}
+	assertEquals(t, restored.Spec.Source, "module.exports = 2")
+	if len(restored.Spec.Env) != 1 || restored.Spec.Env[0].Value != "hello" {
+		t.Errorf("Expected the environment variables of the revision but got %v", restored.Spec.Env)
+	}
+
+	// restoring a revision restores everything recorded in it
+	if err := ApplyRevision(restored, revisions[4]); err != nil {
+		t.Fatalf("Failed to apply revision 5: %v", err)
+	}
+	assertEquals(t, restored.Spec.Runtime, "java")
+	assertEquals(t, restored.Spec.Source, "module.exports = 1")
+	if len(restored.Spec.SecretEnv) != 1 || restored.Spec.SecretEnv[0].Secret != "credentials" {
+		t.Errorf("Expected the secret environment variables of the revision but got %v", restored.Spec.SecretEnv)
+	}
+	if restored.Spec.Pod == nil {
+		t.Fatalf("Expected the pod settings of the revision")
+	}
+	cpu := restored.Spec.Pod.Resources.Requests[v1.ResourceCPU]
+	assertEquals(t, cpu.String(), "100m")
+	if err := ApplyRevision(restored, revisions[1]); err != nil {
+		t.Fatalf("Failed to apply revision 2: %v", err)
+	}
+	if len(restored.Spec.SecretEnv) != 0 || restored.Spec.Pod != nil {
+		t.Errorf("Expected the secrets and pod settings to be removed but got %v %v", restored.Spec.SecretEnv, restored.Spec.Pod)
+	}
+}
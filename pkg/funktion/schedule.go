@@ -0,0 +1,178 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/funktionio/funktion/pkg/cron"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+const (
+	// ScheduleProperty is the data key for the cron expression on which the operator invokes
+	// a Function, such as `*/5 * * * *`
+	ScheduleProperty = "schedule"
+	// SchedulePayloadProperty is the data key for the body of the scheduled invocations of a Function
+	SchedulePayloadProperty = "schedulePayload"
+
+	// invocationTimeout is long enough for the activator to scale up an idle Function
+	invocationTimeout = 5 * time.Minute
+)
+
+// scheduledFunction is a Function the operator invokes on a schedule
+type scheduledFunction struct {
+	expr    string
+	payload string
+	stop    chan struct{}
+}
+
+// getSchedule returns the schedule of the given Function or nil if it is not scheduled
+func getSchedule(function *v1.ConfigMap) (*cron.Schedule, error) {
+	text := function.Data[ScheduleProperty]
+	if len(strings.TrimSpace(text)) == 0 {
+		return nil, nil
+	}
+	schedule, err := cron.Parse(text)
+	if err != nil {
+		return nil, reasonErrorf(InvalidScheduleReason, "Invalid property `%s` on the Function ConfigMap %s: %v", ScheduleProperty, function.Name, err)
+	}
+	return schedule, nil
+}
+
+// syncSchedule starts, restarts or stops invoking the Function with the given key on its schedule
+func (c *Operator) syncSchedule(key string, function *v1.ConfigMap) error {
+	schedule, err := getSchedule(function)
+	if err != nil || schedule == nil {
+		c.unschedule(key)
+		return err
+	}
+	expr := function.Data[ScheduleProperty]
+	payload := function.Data[SchedulePayloadProperty]
+
+	c.scheduleLock.Lock()
+	defer c.scheduleLock.Unlock()
+	if old := c.schedules[key]; old != nil {
+		if old.expr == expr && old.payload == payload {
+			return nil
+		}
+		close(old.stop)
+	}
+	sf := &scheduledFunction{
+		expr:    expr,
+		payload: payload,
+		stop:    make(chan struct{}),
+	}
+	c.schedules[key] = sf
+	go c.runSchedule(key, schedule, sf)
+	return nil
+}
+
+// unschedule stops invoking the Function with the given key
+func (c *Operator) unschedule(key string) {
+	c.scheduleLock.Lock()
+	defer c.scheduleLock.Unlock()
+	if old := c.schedules[key]; old != nil {
+		close(old.stop)
+		delete(c.schedules, key)
+	}
+}
+
+func (c *Operator) runSchedule(key string, schedule *cron.Schedule, sf *scheduledFunction) {
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			c.logger.Log("msg", "schedule never fires", "key", key, "schedule", sf.expr)
+			return
+		}
+		timer := time.NewTimer(next.Sub(time.Now()))
+		select {
+		case <-sf.stop:
+			timer.Stop()
+			return
+		case <-c.stopc:
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := c.invoke(key, sf.payload); err != nil {
+				c.logger.Log("msg", "failed to invoke scheduled function", "key", key, "err", err)
+				if obj, exists, _ := c.functionInf.GetStore().GetByKey(key); exists {
+					c.recorder.Warning(obj.(*v1.ConfigMap), err)
+				}
+			}
+		}
+	}
+}
+
+// invoke calls the Service of the Function with the given key. The payload is POSTed if there
+// is one; otherwise the Function is called with a GET.
+func (c *Operator) invoke(key string, payload string) error {
+	obj, exists, err := c.serviceInf.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return reasonErrorf(FailedInvocationReason, "No Service %s to invoke", key)
+	}
+	svc := obj.(*v1.Service)
+	if len(svc.Spec.ClusterIP) == 0 || svc.Spec.ClusterIP == v1.ClusterIPNone || len(svc.Spec.Ports) == 0 {
+		return reasonErrorf(FailedInvocationReason, "Service %s has no cluster IP and port to invoke", key)
+	}
+	u := url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(svc.Spec.ClusterIP, strconv.Itoa(int(svc.Spec.Ports[0].Port))),
+		Path:   "/",
+	}
+	method := "GET"
+	var body io.Reader
+	if len(payload) > 0 {
+		method = "POST"
+		body = strings.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	// lets use the name of the Service so that the activator can tell which Function to scale up
+	req.Host = svc.Name
+	if len(payload) > 0 {
+		var v interface{}
+		if json.Unmarshal([]byte(payload), &v) == nil {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "text/plain")
+		}
+	}
+
+	resp, err := c.invokeClient.Do(req)
+	if err != nil {
+		return reasonErrorf(FailedInvocationReason, "Failed to invoke Function %s: %v", key, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return reasonErrorf(FailedInvocationReason, "Function %s returned %s", key, resp.Status)
+	}
+	c.logger.Log("msg", "invoked scheduled function", "key", key, "status", resp.StatusCode)
+	return nil
+}
@@ -0,0 +1,90 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestSchedule(t *testing.T) {
+	c, err := newOperator(fake.NewSimpleClientset(), log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty:          "module.exports = 1",
+			ScheduleProperty:        "*/5 * * * *",
+			SchedulePayloadProperty: `{"greeting":"hello"}`,
+		},
+	}
+	key := testNamespace + "/hello"
+
+	if err := c.syncSchedule(key, function); err != nil {
+		t.Fatalf("Failed to schedule the Function: %v", err)
+	}
+	if c.schedules[key] == nil {
+		t.Fatalf("Expected the Function to be scheduled")
+	}
+	function.Data[ScheduleProperty] = "every 5 minutes"
+	if err := c.syncSchedule(key, function); errorReason(err) != InvalidScheduleReason {
+		t.Errorf("Expected an invalid schedule but got %v", err)
+	}
+	if c.schedules[key] != nil {
+		t.Errorf("Expected the Function with an invalid schedule to no longer be scheduled")
+	}
+
+	var method, contentType, host, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		method, contentType, host, body = r.Method, r.Header.Get("Content-Type"), r.Host, string(data)
+	}))
+	defer server.Close()
+	ip, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	c.serviceInf.GetStore().Add(&v1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Spec: v1.ServiceSpec{
+			ClusterIP: ip,
+			Ports:     []v1.ServicePort{{Port: int32(portNumber)}},
+		},
+	})
+
+	if err := c.invoke(key, function.Data[SchedulePayloadProperty]); err != nil {
+		t.Fatalf("Failed to invoke the Function: %v", err)
+	}
+	assertEquals(t, method, "POST")
+	assertEquals(t, contentType, "application/json")
+	assertEquals(t, host, "hello")
+	assertEquals(t, body, `{"greeting":"hello"}`)
+
+	if err := c.invoke(testNamespace+"/missing", ""); errorReason(err) != FailedInvocationReason {
+		t.Errorf("Expected invoking a Function without a Service to fail but got %v", err)
+	}
+}
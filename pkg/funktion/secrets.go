@@ -0,0 +1,162 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+const (
+	// SecretEnvVarsProperty represents a newline terminated list of NAME=secretName:key expressions
+	// for environment variables whose values are read from a Secret
+	SecretEnvVarsProperty = "secretEnvVars"
+	// SecretMountsProperty represents a newline terminated list of secretName:/path expressions for
+	// Secrets mounted into the pods of a Function
+	SecretMountsProperty = "secretMounts"
+)
+
+// ParseSecretEnvVar parses an environment variable read from a Secret of the form NAME=secretName:key
+func ParseSecretEnvVar(text string) (spec.SecretEnvVar, error) {
+	pair := strings.SplitN(text, "=", 2)
+	if len(pair) != 2 || len(pair[0]) == 0 {
+		return spec.SecretEnvVar{}, fmt.Errorf("Expecting `NAME=secretName:key` but got: %s", text)
+	}
+	ref := strings.SplitN(pair[1], ":", 2)
+	if len(ref) != 2 || len(ref[0]) == 0 || len(ref[1]) == 0 {
+		return spec.SecretEnvVar{}, fmt.Errorf("Expecting `NAME=secretName:key` but got: %s", text)
+	}
+	return spec.SecretEnvVar{Name: pair[0], Secret: ref[0], Key: ref[1]}, nil
+}
+
+// ParseSecretMount parses a Secret mounted into the pods of a Function of the form secretName:/path
+func ParseSecretMount(text string) (spec.SecretMount, error) {
+	pair := strings.SplitN(text, ":", 2)
+	if len(pair) != 2 || len(pair[0]) == 0 || !path.IsAbs(pair[1]) {
+		return spec.SecretMount{}, fmt.Errorf("Expecting `secretName:/path` but got: %s", text)
+	}
+	return spec.SecretMount{Secret: pair[0], Path: path.Clean(pair[1])}, nil
+}
+
+func parseSecretEnvVars(text string) ([]spec.SecretEnvVar, error) {
+	answer := []spec.SecretEnvVar{}
+	for _, line := range strings.Split(text, "\n") {
+		l := strings.TrimSpace(line)
+		if len(l) == 0 {
+			continue
+		}
+		env, err := ParseSecretEnvVar(l)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, env)
+	}
+	return answer, nil
+}
+
+func parseSecretMounts(text string) ([]spec.SecretMount, error) {
+	answer := []spec.SecretMount{}
+	for _, line := range strings.Split(text, "\n") {
+		l := strings.TrimSpace(line)
+		if len(l) == 0 {
+			continue
+		}
+		mount, err := ParseSecretMount(l)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, mount)
+	}
+	return answer, nil
+}
+
+// getSecretEnvVars returns the environment variables of the given Function which are read from Secrets
+func getSecretEnvVars(function *v1.ConfigMap) ([]spec.SecretEnvVar, error) {
+	answer, err := parseSecretEnvVars(function.Data[SecretEnvVarsProperty])
+	if err != nil {
+		return nil, reasonErrorf(InvalidDeploymentReason, "Invalid property `%s` on the Function ConfigMap %s: %v", SecretEnvVarsProperty, function.Name, err)
+	}
+	return answer, nil
+}
+
+// getSecretMounts returns the Secrets mounted into the pods of the given Function
+func getSecretMounts(function *v1.ConfigMap) ([]spec.SecretMount, error) {
+	answer, err := parseSecretMounts(function.Data[SecretMountsProperty])
+	if err != nil {
+		return nil, reasonErrorf(InvalidDeploymentReason, "Invalid property `%s` on the Function ConfigMap %s: %v", SecretMountsProperty, function.Name, err)
+	}
+	return answer, nil
+}
+
+func formatSecretEnvVars(envs []spec.SecretEnvVar) string {
+	lines := []string{}
+	for _, env := range envs {
+		lines = append(lines, env.Name+"="+env.Secret+":"+env.Key)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatSecretMounts(mounts []spec.SecretMount) string {
+	lines := []string{}
+	for _, mount := range mounts {
+		lines = append(lines, mount.Secret+":"+mount.Path)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// secretEnvVarsToEnvVars converts the environment variables read from Secrets into container environment variables
+func secretEnvVarsToEnvVars(envs []spec.SecretEnvVar) []v1.EnvVar {
+	answer := []v1.EnvVar{}
+	for _, env := range envs {
+		answer = append(answer, v1.EnvVar{
+			Name: env.Name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: env.Secret,
+					},
+					Key: env.Key,
+				},
+			},
+		})
+	}
+	return answer
+}
+
+// applySecretMounts adds a volume for each mounted Secret to the pods and mounts it into every container
+func applySecretMounts(podSpec *v1.PodSpec, mounts []spec.SecretMount) {
+	for i, mount := range mounts {
+		volumeName := fmt.Sprintf("secret-%d", i)
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			Name: volumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: mount.Secret,
+				},
+			},
+		})
+		for j, container := range podSpec.Containers {
+			podSpec.Containers[j].VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+				Name:      volumeName,
+				MountPath: mount.Path,
+				ReadOnly:  true,
+			})
+		}
+	}
+}
@@ -0,0 +1,124 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+const secretsRuntimeDeploymentYaml = `apiVersion: extensions/v1beta1
+kind: Deployment
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - image: funktion/nodejs-runtime
+        env:
+        - name: LOG_LEVEL
+          value: info
+        - name: API_KEY
+          value: changeme
+`
+
+func TestParseSecrets(t *testing.T) {
+	env, err := ParseSecretEnvVar("API_KEY=credentials:apiKey")
+	if err != nil {
+		t.Fatalf("Failed to parse the secret env var: %v", err)
+	}
+	assertEquals(t, env.Name, "API_KEY")
+	assertEquals(t, env.Secret, "credentials")
+	assertEquals(t, env.Key, "apiKey")
+	for _, text := range []string{"API_KEY", "API_KEY=credentials", "=credentials:apiKey", "API_KEY=:apiKey", "API_KEY=credentials:"} {
+		if _, err := ParseSecretEnvVar(text); err == nil {
+			t.Errorf("Expected an error parsing the secret env var `%s`", text)
+		}
+	}
+
+	mount, err := ParseSecretMount("tls:/etc/tls/")
+	if err != nil {
+		t.Fatalf("Failed to parse the secret mount: %v", err)
+	}
+	assertEquals(t, mount.Secret, "tls")
+	assertEquals(t, mount.Path, "/etc/tls")
+	for _, text := range []string{"tls", "tls:etc/tls", ":/etc/tls"} {
+		if _, err := ParseSecretMount(text); err == nil {
+			t.Errorf("Expected an error parsing the secret mount `%s`", text)
+		}
+	}
+}
+
+func TestFunctionDeploymentSecrets(t *testing.T) {
+	runtime := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "nodejs", Namespace: testNamespace},
+		Data:       map[string]string{DeploymentProperty: secretsRuntimeDeploymentYaml},
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Data: map[string]string{
+			SourceProperty:        "module.exports = 1",
+			EnvVarsProperty:       "GREETING=hello",
+			SecretEnvVarsProperty: "API_KEY=credentials:apiKey",
+			SecretMountsProperty:  "tls:/etc/tls",
+		},
+	}
+	d, err := makeFunctionDeployment(function, runtime, nil)
+	if err != nil {
+		t.Fatalf("Failed to make the deployment: %v", err)
+	}
+	container := d.Spec.Template.Spec.Containers[0]
+	env := map[string]v1.EnvVar{}
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	if len(env) != 3 {
+		t.Errorf("Expected 3 env vars but got %v", container.Env)
+	}
+	// values the Function does not set are kept
+	assertEquals(t, env["LOG_LEVEL"].Value, "info")
+	assertEquals(t, env["GREETING"].Value, "hello")
+	apiKey := env["API_KEY"]
+	if len(apiKey.Value) > 0 || apiKey.ValueFrom == nil || apiKey.ValueFrom.SecretKeyRef == nil {
+		t.Fatalf("Expected API_KEY to be read from a secret but got %#v", apiKey)
+	}
+	assertEquals(t, apiKey.ValueFrom.SecretKeyRef.Name, "credentials")
+	assertEquals(t, apiKey.ValueFrom.SecretKeyRef.Key, "apiKey")
+
+	found := false
+	for _, volume := range d.Spec.Template.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == "tls" {
+			found = true
+			mounted := false
+			for _, m := range container.VolumeMounts {
+				if m.Name == volume.Name && m.MountPath == "/etc/tls" && m.ReadOnly {
+					mounted = true
+				}
+			}
+			if !mounted {
+				t.Errorf("Expected the secret volume %s to be mounted at /etc/tls but got %v", volume.Name, container.VolumeMounts)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a volume for the secret tls but got %v", d.Spec.Template.Spec.Volumes)
+	}
+
+	function.Data[SecretMountsProperty] = "tls"
+	if _, err := makeFunctionDeployment(function, runtime, nil); errorReason(err) != InvalidDeploymentReason {
+		t.Errorf("Expected an invalid secret mount to fail but got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+const (
+	// StatusAnnotation is the annotation on a Function or Flow ConfigMap which holds
+	// the JSON encoded Status written by the operator
+	StatusAnnotation = "funktion.fabric8.io/status"
+
+	// PendingPhase is the phase of a resource whose Deployment is not yet fully available
+	PendingPhase = "Pending"
+	// RunningPhase is the phase of a resource whose Deployment is fully available
+	RunningPhase = "Running"
+	// FailedPhase is the phase of a resource which could not be reconciled
+	FailedPhase = "Failed"
+	// IdlePhase is the phase of a Function which has been scaled to zero pods until it is next called
+	IdlePhase = "Idle"
+)
+
+// Status is the reconciliation status of a Function or Flow
+type Status struct {
+	// Phase is one of Pending, Running, Idle or Failed
+	Phase string `json:"phase"`
+	// ObservedGeneration is incremented each time the operator reconciles a changed spec
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// SpecHash is the hash of the labels and data last reconciled by the operator
+	SpecHash string `json:"specHash,omitempty"`
+	// LastError is the error from the last failed reconciliation
+	LastError string `json:"lastError,omitempty"`
+	// Deployment is the name of the generated Deployment
+	Deployment string `json:"deployment,omitempty"`
+	// Replicas is the desired number of pods
+	Replicas int32 `json:"replicas"`
+	// ReadyReplicas is the number of available pods
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Revision is the number of the active revision of a Function
+	Revision int `json:"revision,omitempty"`
+	// URL is the external URL of the generated Service if it has been exposed
+	URL string `json:"url,omitempty"`
+}
+
+// GetStatus returns the Status recorded on the given Function or Flow ConfigMap
+// or nil if the operator has not written one yet
+func GetStatus(cm *v1.ConfigMap) (*Status, error) {
+	if cm.Annotations == nil {
+		return nil, nil
+	}
+	text := cm.Annotations[StatusAnnotation]
+	if len(text) == 0 {
+		return nil, nil
+	}
+	status := Status{}
+	err := json.Unmarshal([]byte(text), &status)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse annotation `%s` on ConfigMap %s: %v", StatusAnnotation, cm.Name, err)
+	}
+	return &status, nil
+}
+
+// setDeploymentStatus copies the replica counts from the given Deployment
+func (s *Status) setDeploymentStatus(deployment *v1beta1.Deployment) {
+	if deployment == nil {
+		return
+	}
+	s.Deployment = deployment.Name
+	s.Replicas = deployment.Status.Replicas
+	if deployment.Spec.Replicas != nil {
+		s.Replicas = *deployment.Spec.Replicas
+	}
+	s.ReadyReplicas = deployment.Status.AvailableReplicas
+}
+
+// updateStatus records the outcome of reconciling the given Function or Flow ConfigMap
+// on its StatusAnnotation. The ConfigMap is only updated if the status has changed.
+// The reconciliation error is returned so that the caller can retry.
+func (c *Operator) updateStatus(cm *v1.ConfigMap, status *Status, syncErr error) error {
+	old, err := GetStatus(cm)
+	if err != nil {
+		c.logger.Log("msg", "ignoring invalid status", "name", cm.Name, "err", err)
+	}
+	hash := specHash(cm)
+	status.SpecHash = hash
+	if old != nil {
+		status.ObservedGeneration = old.ObservedGeneration
+		if old.SpecHash != hash {
+			status.ObservedGeneration++
+			if syncErr == nil {
+				c.recorder.Normal(cm, UpdatedReason, "Rolled out changes to Deployment %s", status.Deployment)
+			}
+		}
+		if len(status.Deployment) == 0 {
+			status.Deployment = old.Deployment
+		}
+	} else {
+		status.ObservedGeneration = 1
+	}
+
+	if syncErr != nil {
+		c.recorder.Warning(cm, syncErr)
+		status.Phase = FailedPhase
+		status.LastError = syncErr.Error()
+	} else if len(status.Deployment) > 0 && status.Replicas == 0 && status.ReadyReplicas == 0 {
+		status.Phase = IdlePhase
+	} else if len(status.Deployment) > 0 && status.ReadyReplicas >= status.Replicas {
+		status.Phase = RunningPhase
+	} else {
+		status.Phase = PendingPhase
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	text := string(data)
+	if cm.Annotations != nil && cm.Annotations[StatusAnnotation] == text {
+		return syncErr
+	}
+
+	// lets not modify the ConfigMap in the informer cache
+	updated := *cm
+	updated.Annotations = map[string]string{}
+	for k, v := range cm.Annotations {
+		updated.Annotations[k] = v
+	}
+	updated.Annotations[StatusAnnotation] = text
+	if _, err := c.kclient.Core().ConfigMaps(cm.Namespace).Update(&updated); err != nil {
+		c.logger.Log("msg", "failed to update status", "name", cm.Name, "namespace", cm.Namespace, "err", err)
+		if syncErr == nil {
+			return err
+		}
+	}
+	return syncErr
+}
+
+// specHash returns a hash of the labels and data of the given ConfigMap so that we can detect
+// changes made by users while ignoring the status annotation written by the operator
+func specHash(cm *v1.ConfigMap) string {
+	h := sha256.New()
+	writeSortedMap(h, cm.Labels)
+	writeSortedMap(h, cm.Data)
+	return fmt.Sprintf("%x", h.Sum(nil))[0:16]
+}
+
+func writeSortedMap(w io.Writer, m map[string]string) {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s=%q\n", k, m[k])
+	}
+	w.Write([]byte{0})
+}
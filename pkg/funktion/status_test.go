@@ -0,0 +1,69 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestSpecHashIgnoresStatus(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "hello",
+			Labels: map[string]string{
+				KindLabel:    FunctionKind,
+				RuntimeLabel: "nodejs",
+			},
+		},
+		Data: map[string]string{
+			SourceProperty: "module.exports = function(context, callback) {}",
+		},
+	}
+	hash := specHash(cm)
+
+	cm.Annotations = map[string]string{
+		StatusAnnotation: `{"phase":"Running","observedGeneration":1}`,
+	}
+	assertEquals(t, specHash(cm), hash)
+
+	cm.Data[DebugProperty] = "true"
+	if specHash(cm) == hash {
+		t.Errorf("Expected the hash to change when the data changes")
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "hello",
+		},
+	}
+	status, err := GetStatus(cm)
+	if err != nil || status != nil {
+		t.Errorf("Expected no status but got %v %v", status, err)
+	}
+
+	cm.Annotations = map[string]string{
+		StatusAnnotation: `{"phase":"Failed","lastError":"Runtime default/nodejs does not exist"}`,
+	}
+	status, err = GetStatus(cm)
+	if err != nil {
+		t.Fatalf("Failed to parse status: %v", err)
+	}
+	assertEquals(t, status.Phase, FailedPhase)
+	assertEquals(t, status.LastError, "Runtime default/nodejs does not exist")
+}
@@ -0,0 +1,236 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	funktionclient "github.com/funktionio/funktion/pkg/client"
+	"github.com/funktionio/funktion/pkg/k8sutil"
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/runtime"
+	"k8s.io/client-go/1.5/pkg/watch"
+	"k8s.io/client-go/1.5/rest"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+// TypedResourceAnnotation is the annotation on the ConfigMaps which the operator renders from
+// the typed Function, Flow, Runtime and Connector resources. The ConfigMap is kept in step with
+// the typed resource and removed along with it.
+const TypedResourceAnnotation = "funktion.fabric8.io/typed-resource"
+
+// CreateThirdPartyResources registers the funktion ThirdPartyResources if they do not exist
+// yet and waits until they can be used
+func CreateThirdPartyResources(kclient kubernetes.Interface, restClient *rest.RESTClient) error {
+	tprs := kclient.Extensions().ThirdPartyResources()
+	for _, r := range spec.Resources {
+		tpr := &v1beta1.ThirdPartyResource{
+			ObjectMeta: v1.ObjectMeta{
+				Name: r.TPRName(),
+			},
+			Versions: []v1beta1.APIVersion{
+				{Name: spec.Version},
+			},
+			Description: r.Description,
+		}
+		if _, err := tprs.Create(tpr); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("Failed to create ThirdPartyResource %s: %v", tpr.Name, err)
+		}
+	}
+	for _, r := range spec.Resources {
+		if err := k8sutil.WaitForTPRReady(restClient, spec.Group, spec.Version, r.Plural); err != nil {
+			return fmt.Errorf("ThirdPartyResource %s is not ready: %v", r.TPRName(), err)
+		}
+	}
+	return nil
+}
+
+// NewTypedListWatch creates a watch on the typed resources of the given kind
+func NewTypedListWatch(tclient funktionclient.Interface, kind string, namespace string) *cache.ListWatch {
+	var list func(api.ListOptions) (runtime.Object, error)
+	var watchFn func(api.ListOptions) (watch.Interface, error)
+	switch kind {
+	case FunctionKind:
+		list = func(options api.ListOptions) (runtime.Object, error) {
+			return tclient.Functions(namespace).List(options)
+		}
+		watchFn = tclient.Functions(namespace).Watch
+	case FlowKind:
+		list = func(options api.ListOptions) (runtime.Object, error) {
+			return tclient.Flows(namespace).List(options)
+		}
+		watchFn = tclient.Flows(namespace).Watch
+	case RuntimeKind:
+		list = func(options api.ListOptions) (runtime.Object, error) {
+			return tclient.Runtimes(namespace).List(options)
+		}
+		watchFn = tclient.Runtimes(namespace).Watch
+	case ConnectorKind:
+		list = func(options api.ListOptions) (runtime.Object, error) {
+			return tclient.Connectors(namespace).List(options)
+		}
+		watchFn = tclient.Connectors(namespace).Watch
+	}
+	return &cache.ListWatch{
+		ListFunc:  list,
+		WatchFunc: watchFn,
+	}
+}
+
+// typedObject returns an empty typed resource of the given kind for informers
+func typedObject(kind string) runtime.Object {
+	switch kind {
+	case FunctionKind:
+		return &spec.Function{}
+	case FlowKind:
+		return &spec.Flow{}
+	case RuntimeKind:
+		return &spec.Runtime{}
+	default:
+		return &spec.Connector{}
+	}
+}
+
+// typedToConfigMap renders a typed resource as the ConfigMap reconciled by the operator
+func typedToConfigMap(obj interface{}) (*v1.ConfigMap, error) {
+	switch o := obj.(type) {
+	case *spec.Function:
+		return FunctionToConfigMap(o)
+	case *spec.Flow:
+		return FlowToConfigMap(o)
+	case *spec.Runtime:
+		return RuntimeToConfigMap(o)
+	case *spec.Connector:
+		return ConnectorToConfigMap(o)
+	}
+	return nil, fmt.Errorf("Unknown typed resource %T", obj)
+}
+
+// typedKind returns the queue kind used for the typed resources of the given kind
+func typedKind(kind string) string {
+	return kind + "." + spec.Group
+}
+
+// isTypedKind returns the kind of the typed resource if the queue kind is for a typed resource
+func isTypedKind(queueKind string) (string, bool) {
+	suffix := "." + spec.Group
+	if strings.HasSuffix(queueKind, suffix) {
+		return strings.TrimSuffix(queueKind, suffix), true
+	}
+	return "", false
+}
+
+// runTypedResources registers the ThirdPartyResources and starts the informers which render
+// typed resources into ConfigMaps. If the ThirdPartyResources can not be registered, for
+// example due to missing permissions, only ConfigMaps are used.
+func (c *Operator) runTypedResources(stopc <-chan struct{}) {
+	if err := CreateThirdPartyResources(c.kclient, c.tclient.RESTClient()); err != nil {
+		c.logger.Log("msg", "typed resources are disabled", "err", err)
+		return
+	}
+	for _, kind := range []string{ConnectorKind, FlowKind, RuntimeKind, FunctionKind} {
+		queueKind := typedKind(kind)
+		inf := cache.NewSharedIndexInformer(
+			NewTypedListWatch(c.tclient, kind, c.namespace),
+			typedObject(kind),
+			resyncPeriod,
+			cache.Indexers{},
+		)
+		enqueue := func(obj interface{}) {
+			c.enqueue(obj, queueKind)
+		}
+		inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    enqueue,
+			DeleteFunc: enqueue,
+			UpdateFunc: func(old, cur interface{}) {
+				enqueue(cur)
+			},
+		})
+		c.typedLock.Lock()
+		c.typedInfs[kind] = inf
+		c.typedLock.Unlock()
+		go inf.Run(stopc)
+	}
+}
+
+// syncTyped renders the typed resource with the given key into its ConfigMap, removing the
+// ConfigMap once the typed resource is deleted. Only ConfigMaps annotated as rendered from or
+// migrated to a typed resource are changed.
+func (c *Operator) syncTyped(kind string, key string) error {
+	c.typedLock.Lock()
+	inf := c.typedInfs[kind]
+	c.typedLock.Unlock()
+	if inf == nil {
+		return fmt.Errorf("No informer for typed %s resources", kind)
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	configMaps := c.kclient.Core().ConfigMaps(namespace)
+	old, err := configMaps.Get(name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		old = nil
+	}
+
+	obj, exists, err := inf.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if old != nil && old.Annotations[TypedResourceAnnotation] == "true" {
+			return configMaps.Delete(name, nil)
+		}
+		return nil
+	}
+
+	cm, err := typedToConfigMap(obj)
+	if err != nil {
+		return err
+	}
+	cm.Namespace = namespace
+	cm.Annotations[TypedResourceAnnotation] = "true"
+	if old == nil {
+		_, err = configMaps.Create(cm)
+		return err
+	}
+	if old.Annotations[TypedResourceAnnotation] != "true" {
+		// lets not take over a ConfigMap which was neither rendered from nor migrated to the typed resource
+		c.recorder.Warning(old, reasonErrorf(ConflictingConfigMapReason, "ConfigMap %s was not created for the typed %s %s so it is left unchanged", name, kind, name))
+		return nil
+	}
+
+	// lets keep the status written by the operator and any data which is not part of the spec
+	mergeConfigMapData(old, cm)
+	if status, ok := old.Annotations[StatusAnnotation]; ok {
+		cm.Annotations[StatusAnnotation] = status
+	}
+	if reflect.DeepEqual(cm.Labels, old.Labels) && reflect.DeepEqual(cm.Annotations, old.Annotations) && reflect.DeepEqual(cm.Data, old.Data) {
+		return nil
+	}
+	cm.ResourceVersion = old.ResourceVersion
+	_, err = configMaps.Update(cm)
+	return err
+}
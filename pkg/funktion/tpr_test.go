@@ -0,0 +1,81 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+func TestSyncTypedLeavesConflictingConfigMapAlone(t *testing.T) {
+	source := "module.exports = function(context, callback) { callback(200, 'Hello'); };"
+	hello := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{SourceProperty: source},
+	}
+	kclient := fake.NewSimpleClientset(hello)
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	inf := cache.NewSharedIndexInformer(NewTypedListWatch(NewClient(fake.NewSimpleClientset(), nil), FunctionKind, testNamespace), typedObject(FunctionKind), resyncPeriod, cache.Indexers{})
+	c.typedInfs[FunctionKind] = inf
+	inf.GetStore().Add(&spec.Function{
+		ObjectMeta: v1.ObjectMeta{Name: "hello", Namespace: testNamespace},
+		Spec: spec.FunctionSpec{
+			Runtime: "nodejs",
+			Source:  "module.exports = function(context, callback) { callback(200, 'Other'); };",
+		},
+	})
+	key := testNamespace + "/hello"
+	if err := c.syncTyped(FunctionKind, key); err != nil {
+		t.Fatalf("Failed to sync the typed Function: %v", err)
+	}
+
+	configMaps := kclient.Core().ConfigMaps(testNamespace)
+	cm, err := configMaps.Get("hello")
+	if err != nil {
+		t.Fatalf("Failed to get the Function ConfigMap: %v", err)
+	}
+	assertEquals(t, cm.Data[SourceProperty], source)
+	if _, ok := cm.Annotations[TypedResourceAnnotation]; ok {
+		t.Errorf("Expected the ConfigMap to not be taken over by the typed Function")
+	}
+	events := listEvents(t, kclient)
+	conflict := findEvent(events, ConflictingConfigMapReason)
+	if conflict == nil {
+		t.Fatalf("No %s event in %v", ConflictingConfigMapReason, events)
+	}
+	assertEquals(t, conflict.Type, v1.EventTypeWarning)
+	assertEquals(t, conflict.InvolvedObject.Name, "hello")
+
+	// nor is it deleted along with the typed resource
+	inf.GetStore().Replace([]interface{}{}, "")
+	if err := c.syncTyped(FunctionKind, key); err != nil {
+		t.Fatalf("Failed to sync the deleted typed Function: %v", err)
+	}
+	if _, err := configMaps.Get("hello"); err != nil {
+		t.Errorf("Expected the ConfigMap to be kept but got %v", err)
+	}
+}
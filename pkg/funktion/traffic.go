@@ -0,0 +1,276 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+const (
+	// TrafficProperty is the data key for the percentage of the requests each revision of a
+	// Function receives, such as `3=90,4=10`
+	TrafficProperty = "traffic"
+
+	// FunctionLabel is the label on the pods of every revision of a Function. The Service of a
+	// Function with a traffic split selects it so that the requests are spread over the revisions.
+	FunctionLabel = "funktion.fabric8.io/function"
+)
+
+// ParseTraffic parses the percentage of the requests each revision receives from text such as
+// `3=90,4=10` or `rev3=90 rev4=10`. The percentages must add up to 100.
+func ParseTraffic(text string) ([]spec.TrafficTarget, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+	answer := []spec.TrafficTarget{}
+	seen := map[int]bool{}
+	total := 0
+	for _, field := range fields {
+		pair := strings.SplitN(field, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("Expecting `REVISION=PERCENT` but got: %s", field)
+		}
+		revision, err := strconv.Atoi(strings.TrimPrefix(pair[0], "rev"))
+		if err != nil || revision < 1 {
+			return nil, fmt.Errorf("Invalid revision `%s`", pair[0])
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(pair[1], "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("The percentage of revision %d must be between 0 and 100 but was `%s`", revision, pair[1])
+		}
+		if seen[revision] {
+			return nil, fmt.Errorf("Revision %d is given more than once", revision)
+		}
+		seen[revision] = true
+		total += percent
+		answer = append(answer, spec.TrafficTarget{Revision: revision, Percent: percent})
+	}
+	if len(answer) == 0 {
+		return nil, fmt.Errorf("No revisions given")
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("The percentages must add up to 100 but add up to %d", total)
+	}
+	sort.Sort(trafficByRevision(answer))
+	return answer, nil
+}
+
+// FormatTraffic returns the text of the traffic split which ParseTraffic parses
+func FormatTraffic(targets []spec.TrafficTarget) string {
+	parts := []string{}
+	for _, t := range targets {
+		parts = append(parts, fmt.Sprintf("%d=%d", t.Revision, t.Percent))
+	}
+	return strings.Join(parts, ",")
+}
+
+type trafficByRevision []spec.TrafficTarget
+
+func (t trafficByRevision) Len() int           { return len(t) }
+func (t trafficByRevision) Less(i, j int) bool { return t[i].Revision < t[j].Revision }
+func (t trafficByRevision) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+// getTraffic returns the traffic split of the given Function or nil if all its requests go to
+// the active revision
+func getTraffic(function *v1.ConfigMap) ([]spec.TrafficTarget, error) {
+	text := function.Data[TrafficProperty]
+	if len(strings.TrimSpace(text)) == 0 {
+		return nil, nil
+	}
+	targets, err := ParseTraffic(text)
+	if err != nil {
+		return nil, reasonErrorf(InvalidTrafficReason, "Invalid property `%s` on the Function ConfigMap %s: %v", TrafficProperty, function.Name, err)
+	}
+	// the number of pods of each revision is what splits the requests
+	for _, key := range []string{MaxReplicasProperty, IdleTimeoutProperty} {
+		if len(function.Data[key]) > 0 {
+			return nil, reasonErrorf(InvalidTrafficReason, "Property `%s` on the Function ConfigMap %s can not be combined with property `%s`", TrafficProperty, function.Name, key)
+		}
+	}
+	for _, t := range targets {
+		if len(RevisionName(function.Name, t.Revision)) > maxLabelValueLength {
+			return nil, reasonErrorf(InvalidTrafficReason, "The name of the Function ConfigMap %s is too long to split its traffic", function.Name)
+		}
+	}
+	return targets, nil
+}
+
+// trafficReplicas returns the number of pods which gives a revision roughly its percentage of
+// the requests. Every revision which receives requests has at least one pod.
+func trafficReplicas(total int32, percent int) int32 {
+	if percent <= 0 {
+		return 0
+	}
+	answer := (total*int32(percent) + 50) / 100
+	if answer < 1 {
+		answer = 1
+	}
+	return answer
+}
+
+// totalReplicas returns the number of pods the Deployment would have without a traffic split
+func totalReplicas(deployment *v1beta1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas < 1 {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// applyTraffic sets the number of pods of the Deployment of the active revision of a Function
+// to its share of the requests
+func applyTraffic(deployment *v1beta1.Deployment, targets []spec.TrafficTarget, active int) {
+	percent := 0
+	for _, t := range targets {
+		if t.Revision == active {
+			percent = t.Percent
+		}
+	}
+	replicas := trafficReplicas(totalReplicas(deployment), percent)
+	deployment.Spec.Replicas = &replicas
+}
+
+// makeRevisionDeployment generates the Deployment which runs an older revision of a Function
+// alongside the Deployment of the active revision. The runtime is the Runtime of the revision.
+func makeRevisionDeployment(function *v1.ConfigMap, runtime *v1.ConfigMap, revision *Revision, replicas int32, old *v1beta1.Deployment) (*v1beta1.Deployment, error) {
+	// the revision ConfigMap holds the source which is mounted into the pods
+	rf := *function
+	rf.Name = RevisionName(function.Name, revision.Number)
+	rf.Labels = map[string]string{}
+	for k, v := range function.Labels {
+		rf.Labels[k] = v
+	}
+	if len(revision.Runtime) > 0 {
+		rf.Labels[RuntimeLabel] = revision.Runtime
+	}
+	rf.Data = map[string]string{}
+	for k, v := range function.Data {
+		rf.Data[k] = v
+	}
+	for _, key := range revisionDataKeys {
+		delete(rf.Data, key)
+	}
+	for k, v := range revision.Data {
+		rf.Data[k] = v
+	}
+
+	deployment, err := makeFunctionDeployment(&rf, runtime, old)
+	if err != nil {
+		return nil, err
+	}
+	deployment.Spec.Replicas = &replicas
+	deployment.Spec.Template.Labels[FunctionLabel] = function.Name
+	setRevision(deployment, revision.Number, revision.Hash)
+	setOwner(&deployment.ObjectMeta, function, FunctionKind)
+	return deployment, nil
+}
+
+// syncTraffic creates, updates and deletes the Deployments of the older revisions of a Function
+// so that each revision in its traffic split has its share of the pods
+func (c *Operator) syncTraffic(key string, function *v1.ConfigMap, runtime *v1.ConfigMap, targets []spec.TrafficTarget, active int, total int32) error {
+	deployments := c.kclient.Extensions().Deployments(function.Namespace)
+	wanted := map[string]bool{}
+	if len(targets) > 0 {
+		revisions, err := ListRevisions(c.kclient, function.Namespace, function.Name)
+		if err != nil {
+			return err
+		}
+		byNumber := map[int]*Revision{}
+		for _, r := range revisions {
+			byNumber[r.Number] = r
+		}
+		for _, t := range targets {
+			if t.Revision == active || t.Percent == 0 {
+				continue
+			}
+			revision := byNumber[t.Revision]
+			if revision == nil {
+				return reasonErrorf(InvalidTrafficReason, "Revision %d in property `%s` on the Function ConfigMap %s does not exist", t.Revision, TrafficProperty, function.Name)
+			}
+			name := RevisionName(function.Name, t.Revision)
+			wanted[name] = true
+
+			obj, exists, err := c.deploymentInf.GetIndexer().GetByKey(function.Namespace + "/" + name)
+			if err != nil {
+				return err
+			}
+			var old *v1beta1.Deployment
+			if exists {
+				old = obj.(*v1beta1.Deployment)
+				if _, ownerKey, ok := managedBy(&old.ObjectMeta); !ok || ownerKey != key {
+					return reasonErrorf(InvalidTrafficReason, "Deployment %s was not created for Function %s", name, function.Name)
+				}
+			}
+			revisionRuntime, err := c.revisionRuntime(function, runtime, revision)
+			if err != nil {
+				return err
+			}
+			d, err := makeRevisionDeployment(function, revisionRuntime, revision, trafficReplicas(total, t.Percent), old)
+			if err != nil {
+				return wrapError("make revision deployment", err)
+			}
+			if old == nil {
+				if _, err := deployments.Create(d); err != nil {
+					return fmt.Errorf("create revision deployment: %s", err)
+				}
+				c.recorder.Normal(function, CreatedReason, "Created Deployment %s for revision %d", d.Name, t.Revision)
+				continue
+			}
+			d.ResourceVersion = old.ResourceVersion
+			if _, err := deployments.Update(d); err != nil {
+				return fmt.Errorf("update revision deployment: %s", err)
+			}
+		}
+	}
+
+	// lets remove the Deployments of the revisions which no longer receive requests
+	for _, obj := range c.deploymentInf.GetStore().List() {
+		d := obj.(*v1beta1.Deployment)
+		if d.Namespace != function.Namespace || d.Name == function.Name || wanted[d.Name] || len(d.Labels[RevisionLabel]) == 0 {
+			continue
+		}
+		if _, ownerKey, ok := managedBy(&d.ObjectMeta); !ok || ownerKey != key {
+			continue
+		}
+		if err := deployments.Delete(d.Name, deleteOptions()); err != nil {
+			return fmt.Errorf("delete revision deployment: %s", err)
+		}
+		c.recorder.Normal(function, DeletedReason, "Deleted Deployment %s", d.Name)
+	}
+	return nil
+}
+
+// revisionRuntime returns the Runtime ConfigMap of a revision of a Function given the Runtime of
+// the active revision
+func (c *Operator) revisionRuntime(function *v1.ConfigMap, runtime *v1.ConfigMap, revision *Revision) (*v1.ConfigMap, error) {
+	if len(revision.Runtime) == 0 || revision.Runtime == function.Labels[RuntimeLabel] {
+		return runtime, nil
+	}
+	runtimeKey := referenceKey(function.Namespace, revision.Runtime)
+	obj, exists, err := c.runtimeInf.GetIndexer().GetByKey(runtimeKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, reasonErrorf(MissingRuntimeReason, "Runtime %s of revision %d does not exist for Function %s/%s", runtimeKey, revision.Number, function.Namespace, function.Name)
+	}
+	return obj.(*v1.ConfigMap), nil
+}
@@ -0,0 +1,171 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestParseTraffic(t *testing.T) {
+	targets, err := ParseTraffic("rev4=10, rev3=90")
+	if err != nil {
+		t.Fatalf("Failed to parse the traffic split: %v", err)
+	}
+	if len(targets) != 2 || targets[0].Revision != 3 || targets[0].Percent != 90 || targets[1].Revision != 4 || targets[1].Percent != 10 {
+		t.Errorf("Expected 90%% to revision 3 and 10%% to revision 4 but got %v", targets)
+	}
+	assertEquals(t, FormatTraffic(targets), "3=90,4=10")
+
+	for _, text := range []string{"", "3", "3=90", "3=90,4=20", "3=90,3=10", "x=100", "0=100", "3=101,4=-1"} {
+		if _, err := ParseTraffic(text); err == nil {
+			t.Errorf("Expected an error parsing `%s`", text)
+		}
+	}
+}
+
+func TestTrafficReplicas(t *testing.T) {
+	tests := []struct {
+		total    int32
+		percent  int
+		expected int32
+	}{
+		{1, 0, 0},
+		{1, 10, 1},
+		{1, 90, 1},
+		{10, 90, 9},
+		{4, 75, 3},
+		{4, 25, 1},
+	}
+	for _, test := range tests {
+		if actual := trafficReplicas(test.total, test.percent); actual != test.expected {
+			t.Errorf("Expected %d%% of %d pods to be %d but got %d", test.percent, test.total, test.expected, actual)
+		}
+	}
+}
+
+func TestSyncTraffic(t *testing.T) {
+	kclient := fake.NewSimpleClientset()
+	c, err := newOperator(kclient, log.NewNopLogger(), testNamespace)
+	if err != nil {
+		t.Fatalf("Failed to create operator: %v", err)
+	}
+	runtime := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "nodejs",
+			Namespace: testNamespace,
+			Labels:    map[string]string{KindLabel: RuntimeKind},
+		},
+		Data: map[string]string{
+			DeploymentProperty: sampleRuntimeDeploymentYaml,
+			ServiceProperty:    sampleRuntimeServiceYaml,
+		},
+	}
+	function := &v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "hello",
+			Namespace: testNamespace,
+			UID:       "1234",
+			Labels:    map[string]string{KindLabel: FunctionKind, RuntimeLabel: "nodejs"},
+		},
+		Data: map[string]string{
+			SourceProperty:        "module.exports = 1",
+			SecretEnvVarsProperty: "API_KEY=credentials:apiKey",
+			MemoryLimitProperty:   "128Mi",
+		},
+	}
+	key := testNamespace + "/hello"
+	c.syncRevision(function, nil)
+	function.Data[SourceProperty] = "module.exports = 2"
+	delete(function.Data, SecretEnvVarsProperty)
+	function.Data[MemoryLimitProperty] = "256Mi"
+	active, _, err := c.syncRevision(function, nil)
+	if err != nil || active != 2 {
+		t.Fatalf("Expected revision 2 but got %d: %v", active, err)
+	}
+
+	function.Data[TrafficProperty] = "rev1=25,rev2=75"
+	traffic, err := getTraffic(function)
+	if err != nil {
+		t.Fatalf("Failed to get the traffic split: %v", err)
+	}
+	deployment, err := makeFunctionDeployment(function, runtime, nil)
+	if err != nil {
+		t.Fatalf("Failed to make the deployment: %v", err)
+	}
+	*deployment.Spec.Replicas = 4
+	applyTraffic(deployment, traffic, active)
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("Expected the active revision to have 3 pods but got %d", *deployment.Spec.Replicas)
+	}
+	svc, err := makeFunctionService(function, runtime, nil, deployment)
+	if err != nil {
+		t.Fatalf("Failed to make the service: %v", err)
+	}
+	if len(svc.Spec.Selector) != 1 || svc.Spec.Selector[FunctionLabel] != "hello" {
+		t.Errorf("Expected the service to select the pods of every revision but got %v", svc.Spec.Selector)
+	}
+	assertEquals(t, deployment.Spec.Template.Labels[FunctionLabel], "hello")
+
+	if err := c.syncTraffic(key, function, runtime, traffic, active, 4); err != nil {
+		t.Fatalf("Failed to sync the traffic split: %v", err)
+	}
+	d, err := kclient.Extensions().Deployments(testNamespace).Get("hello-rev-1")
+	if err != nil {
+		t.Fatalf("Failed to get the deployment of revision 1: %v", err)
+	}
+	if *d.Spec.Replicas != 1 {
+		t.Errorf("Expected revision 1 to have 1 pod but got %d", *d.Spec.Replicas)
+	}
+	assertEquals(t, d.Spec.Template.Labels[FunctionLabel], "hello")
+	assertEquals(t, d.Spec.Template.Labels[RevisionLabel], "1")
+	assertEquals(t, d.Spec.Selector.MatchLabels[NameLabel], "hello-rev-1")
+	assertEquals(t, d.Labels[ManagedByLabel], "hello")
+	assertEquals(t, string(d.OwnerReferences[0].UID), "1234")
+	assertEquals(t, d.Spec.Template.Spec.Volumes[0].ConfigMap.Name, "hello-rev-1")
+	// the older revision runs with its own secrets and pod settings
+	container := d.Spec.Template.Spec.Containers[0]
+	memory := container.Resources.Limits[v1.ResourceMemory]
+	assertEquals(t, memory.String(), "128Mi")
+	foundSecret := false
+	for _, env := range container.Env {
+		if env.Name == "API_KEY" && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			foundSecret = true
+		}
+	}
+	if !foundSecret {
+		t.Errorf("Expected revision 1 to read API_KEY from its Secret but got %v", container.Env)
+	}
+
+	// removing the split deletes the deployment of the older revision
+	c.deploymentInf.GetStore().Add(d)
+	delete(function.Data, TrafficProperty)
+	if err := c.syncTraffic(key, function, runtime, nil, active, 4); err != nil {
+		t.Fatalf("Failed to sync the traffic split: %v", err)
+	}
+	if _, err := kclient.Extensions().Deployments(testNamespace).Get("hello-rev-1"); !errors.IsNotFound(err) {
+		t.Errorf("Expected the deployment of revision 1 to be deleted but got %v", err)
+	}
+
+	function.Data[TrafficProperty] = "1=100"
+	function.Data[IdleTimeoutProperty] = "5m"
+	if _, err := getTraffic(function); errorReason(err) != InvalidTrafficReason {
+		t.Errorf("Expected a traffic split with an idle timeout to be invalid but got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package funktion
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/funktionio/funktion/pkg/spec"
+	"github.com/magiconair/properties"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/tools/cache"
+)
+
+// validate checks a funktion ConfigMap using the same checks the operator makes when it
+// reconciles the resource so that invalid resources can be rejected when they are applied
+func (c *Operator) validate(cm *v1.ConfigMap) error {
+	switch cm.Labels[KindLabel] {
+	case FunctionKind:
+		return c.validateFunction(cm)
+	case FlowKind:
+		return c.validateFlow(cm)
+	case RuntimeKind:
+		_, err := ConfigMapToRuntime(cm)
+		return err
+	case ConnectorKind:
+		_, err := ConfigMapToConnector(cm)
+		return err
+	}
+	return nil
+}
+
+func (c *Operator) validateFunction(function *v1.ConfigMap) error {
+	if len(function.Data[SourceProperty]) == 0 {
+		return reasonErrorf(MissingSourceReason, "No property `%s` on the Function ConfigMap %s", SourceProperty, function.Name)
+	}
+	if err := validateEnvVars(function.Data[EnvVarsProperty]); err != nil {
+		return fmt.Errorf("Invalid property `%s` on the Function ConfigMap %s: %v", EnvVarsProperty, function.Name, err)
+	}
+	if _, err := getSchedule(function); err != nil {
+		return err
+	}
+	if _, err := getIdleTimeout(function); err != nil {
+		return err
+	}
+	if _, err := getTraffic(function); err != nil {
+		return err
+	}
+	if _, err := getGatewaySpec(function); err != nil {
+		return err
+	}
+	runtime, err := c.getReferenced(function, RuntimeLabel, RuntimeKind)
+	if err != nil {
+		return err
+	}
+	if runtime == nil {
+		return reasonErrorf(MissingRuntimeReason, "Runtime %s does not exist for Function %s", function.Labels[RuntimeLabel], function.Name)
+	}
+	_, err = makeFunctionDeployment(function, runtime, nil)
+	return err
+}
+
+func (c *Operator) validateFlow(flow *v1.ConfigMap) error {
+	if err := validateFunktionYml(flow); err != nil {
+		return err
+	}
+	connector, err := c.getReferenced(flow, ConnectorLabel, ConnectorKind)
+	if err != nil {
+		return err
+	}
+	if connector == nil {
+		return reasonErrorf(MissingConnectorReason, "Connector %s does not exist for Flow %s", flow.Labels[ConnectorLabel], flow.Name)
+	}
+	if _, err := makeFlowDeployment(flow, connector, nil); err != nil {
+		return err
+	}
+	return validateConnectorProperties(flow, connector)
+}
+
+// getReferenced returns the ConfigMap of the given kind referred to by the label or nil if it
+// does not exist. The API server is queried rather than the informer caches which are only
+// filled in by the elected leader.
+func (c *Operator) getReferenced(cm *v1.ConfigMap, label string, kind string) (*v1.ConfigMap, error) {
+	name := cm.Labels[label]
+	if len(name) == 0 {
+		return nil, fmt.Errorf("%s %s does not have label %s", cm.Labels[KindLabel], cm.Name, label)
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(referenceKey(cm.Namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	answer, err := c.kclient.Core().ConfigMaps(namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if answer.Labels[KindLabel] != kind {
+		return nil, nil
+	}
+	return answer, nil
+}
+
+// validateEnvVars checks each line is of the form NAME=VALUE
+func validateEnvVars(text string) error {
+	for _, line := range strings.Split(text, "\n") {
+		l := strings.TrimSpace(line)
+		if len(l) == 0 {
+			continue
+		}
+		if pair := strings.SplitN(l, "=", 2); len(pair) != 2 || len(pair[0]) == 0 {
+			return fmt.Errorf("Expecting `NAME=VALUE` but got: %s", l)
+		}
+	}
+	return nil
+}
+
+// validateFunktionYml checks the steps of each flow in the funktion.yml of a Flow
+func validateFunktionYml(flow *v1.ConfigMap) error {
+	config, err := ConfigMapToFlow(flow)
+	if err != nil {
+		return err
+	}
+	if config.Spec.Funktion == nil {
+		return nil
+	}
+	for _, f := range config.Spec.Funktion.Flows {
+		if len(f.Steps) == 0 {
+			return fmt.Errorf("Flow `%s` in the property `%s` on the Flow ConfigMap %s has no steps", f.Name, FunktionYmlProperty, flow.Name)
+		}
+		for i, step := range f.Steps {
+			if err := validateStep(&step); err != nil {
+				return fmt.Errorf("Invalid step %d of flow `%s` in the property `%s` on the Flow ConfigMap %s: %v", i+1, f.Name, FunktionYmlProperty, flow.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateStep(step *spec.FunktionStep) error {
+	switch step.Kind {
+	case spec.EndpointKind:
+		if len(step.URI) == 0 {
+			return fmt.Errorf("no uri for the endpoint")
+		}
+	case spec.FunctionKind:
+		if len(step.Name) == 0 {
+			return fmt.Errorf("no name for the function")
+		}
+	case spec.SetBodyKind, spec.SetHeadersKind:
+	default:
+		return fmt.Errorf("unknown kind `%s`", step.Kind)
+	}
+	return nil
+}
+
+// validateConnectorProperties checks the component properties of the Connector configured in
+// the application.properties of a Flow against the schema.yml of the Connector
+func validateConnectorProperties(flow *v1.ConfigMap, connector *v1.ConfigMap) error {
+	text := flow.Data[ApplicationPropertiesProperty]
+	schemaYaml := connector.Data[SchemaYmlProperty]
+	if len(text) == 0 || len(schemaYaml) == 0 {
+		return nil
+	}
+	props, err := properties.LoadString(text)
+	if err != nil {
+		return fmt.Errorf("Failed to parse property `%s` on the Flow ConfigMap %s: %v", ApplicationPropertiesProperty, flow.Name, err)
+	}
+	schema, err := LoadConnectorSchema([]byte(schemaYaml))
+	if err != nil {
+		return fmt.Errorf("Failed to parse property `%s` on the Connector ConfigMap %s: %v", SchemaYmlProperty, connector.Name, err)
+	}
+	known := map[string]spec.PropertySpec{}
+	for k, p := range schema.ComponentProperties {
+		known[ToSpringBootPropertyName(k)] = p
+	}
+
+	prefix := "camel.component." + connector.Name + "."
+	problems := []string{}
+	for _, key := range props.Keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, prefix)
+		p, ok := known[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown property %s", key))
+			continue
+		}
+		if err := validatePropertyValue(&p, props.GetString(key, "")); err != nil {
+			problems = append(problems, fmt.Sprintf("property %s %v", key, err))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("Invalid property `%s` on the Flow ConfigMap %s for Connector %s: %s", ApplicationPropertiesProperty, flow.Name, connector.Name, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func validatePropertyValue(p *spec.PropertySpec, value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	switch p.Type {
+	case "boolean":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be true or false but was `%s`", value)
+		}
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("must be an integer but was `%s`", value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("must be a number but was `%s`", value)
+		}
+	}
+	if len(p.Enum) > 0 {
+		for _, e := range p.Enum {
+			if value == e {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s but was `%s`", strings.Join(p.Enum, ", "), value)
+	}
+	return nil
+}
@@ -29,7 +29,7 @@ import (
 
 // ResolveKubectlBinary resolves the binary to use such as 'kubectl' or 'oc'
 func ResolveKubectlBinary(kubeclient *kubernetes.Clientset) (string, error) {
-	isOpenshift := isOpenShiftCluster(kubeclient)
+	isOpenshift := IsOpenShiftCluster(kubeclient)
 	kubeBinary := "kubectl"
 	if isOpenshift {
 		kubeBinary = "oc"
@@ -49,7 +49,8 @@ func ResolveKubectlBinary(kubeclient *kubernetes.Clientset) (string, error) {
 	return name, nil
 }
 
-func isOpenShiftCluster(kubeclient *kubernetes.Clientset) bool {
+// IsOpenShiftCluster returns true if the API server is OpenShift
+func IsOpenShiftCluster(kubeclient kubernetes.Interface) bool {
 	// The presence of "/oapi" on the API server is our hacky way of
 	// determining if we're talking to OpenShift
 	err := kubeclient.Core().GetRESTClient().Get().AbsPath("/oapi").Do().Error()
@@ -0,0 +1,79 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package k8sutil
+
+import (
+	"io"
+
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+// PodLogStream opens the log of a container of the given pod through the API server. If the
+// options do not name a container the first container of the pod is used.
+func PodLogStream(kubeclient kubernetes.Interface, pod *v1.Pod, opts *v1.PodLogOptions) (io.ReadCloser, error) {
+	if len(opts.Container) == 0 && len(pod.Spec.Containers) > 0 {
+		withContainer := *opts
+		withContainer.Container = pod.Spec.Containers[0].Name
+		opts = &withContainer
+	}
+	return kubeclient.Core().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream()
+}
+
+// StreamPodLog copies the log of a container of the given pod to out until the log ends or the
+// stop channel is closed
+func StreamPodLog(kubeclient kubernetes.Interface, pod *v1.Pod, opts *v1.PodLogOptions, out io.Writer, stopc <-chan struct{}) error {
+	stream, err := PodLogStream(kubeclient, pod, opts)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stopc:
+			// closing the stream stops the copy below
+			stream.Close()
+		case <-done:
+		}
+	}()
+	defer stream.Close()
+
+	_, err = io.Copy(out, stream)
+	select {
+	case <-stopc:
+		return nil
+	default:
+		return err
+	}
+}
+
+// LatestPod returns the newest of the given pods which is ready or, if none are ready, the
+// newest pod. It returns nil if there are no pods.
+func LatestPod(pods []v1.Pod) *v1.Pod {
+	var latest *v1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if latest == nil {
+			latest = pod
+			continue
+		}
+		ready, latestReady := isPodReady(pod), isPodReady(latest)
+		if (ready && !latestReady) || (ready == latestReady && isPodNewer(pod, latest)) {
+			latest = pod
+		}
+	}
+	return latest
+}
@@ -0,0 +1,75 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package k8sutil
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+func TestLatestPod(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, age time.Duration, ready bool) v1.Pod {
+		answer := v1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: unversioned.NewTime(now.Add(-age)),
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+			},
+		}
+		if ready {
+			answer.Status.Phase = v1.PodRunning
+			answer.Status.Conditions = []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			}
+		}
+		return answer
+	}
+
+	if latest := LatestPod(nil); latest != nil {
+		t.Errorf("Expected no pod but got %s", latest.Name)
+	}
+	assertLatestPod(t, []v1.Pod{
+		pod("old", 10*time.Minute, false),
+		pod("new", time.Minute, false),
+	}, "new")
+	assertLatestPod(t, []v1.Pod{
+		pod("new", time.Minute, false),
+		pod("ready", 10*time.Minute, true),
+		pod("newest", time.Second, false),
+	}, "ready")
+	assertLatestPod(t, []v1.Pod{
+		pod("old", 10*time.Minute, true),
+		pod("new", time.Minute, true),
+		pod("newest", time.Second, false),
+		pod("older", 20*time.Minute, true),
+	}, "new")
+}
+
+func assertLatestPod(t *testing.T, pods []v1.Pod, expected string) {
+	latest := LatestPod(pods)
+	if latest == nil {
+		t.Errorf("Expected pod %s but got no pod", expected)
+		return
+	}
+	if latest.Name != expected {
+		t.Errorf("Expected pod %s but got %s", expected, latest.Name)
+	}
+}
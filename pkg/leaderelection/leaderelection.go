@@ -0,0 +1,345 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package leaderelection elects a single leader among several replicas using
+// an annotation on a ConfigMap as the lock. The leader keeps renewing its lease;
+// a standby takes over once the lease has not been renewed for LeaseDuration.
+package leaderelection
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/pkg/api/errors"
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+const (
+	// LeaderAnnotation is the annotation on the lock ConfigMap which holds the LeaderElectionRecord
+	LeaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+
+	// DefaultLeaseDuration is how long a standby waits before taking over a lease which is no longer renewed
+	DefaultLeaseDuration = 15 * time.Second
+	// DefaultRenewDeadline is how long the leader keeps retrying to renew its lease before giving up leadership
+	DefaultRenewDeadline = 10 * time.Second
+	// DefaultRetryPeriod is how long to wait between attempts to acquire or renew the lease
+	DefaultRetryPeriod = 2 * time.Second
+
+	jitterFactor = 1.2
+)
+
+// LeaderElectionRecord is the record stored on the lock ConfigMap
+type LeaderElectionRecord struct {
+	HolderIdentity       string           `json:"holderIdentity"`
+	LeaseDurationSeconds int              `json:"leaseDurationSeconds"`
+	AcquireTime          unversioned.Time `json:"acquireTime"`
+	RenewTime            unversioned.Time `json:"renewTime"`
+	LeaderTransitions    int              `json:"leaderTransitions"`
+}
+
+// Config configures a LeaderElector
+type Config struct {
+	Client kubernetes.Interface
+	Logger log.Logger
+
+	// Namespace and Name of the ConfigMap used as the lock
+	Namespace string
+	Name      string
+	// Identity uniquely identifies this candidate
+	Identity string
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+
+	// OnStartedLeading is invoked once the lease is acquired. The stop channel
+	// is closed when leadership is lost or the elector is stopped; the elector
+	// waits for OnStartedLeading to return before releasing the lease.
+	OnStartedLeading func(stop <-chan struct{})
+	// OnStoppedLeading is invoked once this candidate is no longer the leader
+	OnStoppedLeading func()
+}
+
+// LeaderElector takes part in the election of a leader
+type LeaderElector struct {
+	config Config
+
+	observedRecord LeaderElectionRecord
+	observedRaw    string
+	observedTime   time.Time
+
+	// lock guards the state read by IsLeader and Healthy from other goroutines
+	lock        sync.Mutex
+	leader      bool
+	lastContact time.Time
+}
+
+// New validates the given configuration and creates a LeaderElector
+func New(config Config) (*LeaderElector, error) {
+	if config.LeaseDuration <= config.RenewDeadline {
+		return nil, fmt.Errorf("The lease duration %v must be greater than the renew deadline %v", config.LeaseDuration, config.RenewDeadline)
+	}
+	if config.RenewDeadline <= time.Duration(jitterFactor*float64(config.RetryPeriod)) {
+		return nil, fmt.Errorf("The renew deadline %v must be greater than %v times the retry period %v", config.RenewDeadline, jitterFactor, config.RetryPeriod)
+	}
+	if len(config.Name) == 0 {
+		return nil, fmt.Errorf("No lock name specified")
+	}
+	if len(config.Identity) == 0 {
+		return nil, fmt.Errorf("No leader election identity specified")
+	}
+	if config.OnStartedLeading == nil {
+		return nil, fmt.Errorf("No OnStartedLeading callback specified")
+	}
+	if config.Logger == nil {
+		config.Logger = log.NewNopLogger()
+	}
+	return &LeaderElector{config: config}, nil
+}
+
+// Run blocks until the lease is acquired, runs OnStartedLeading while the lease
+// is renewed and returns once leadership is lost or stopc is closed
+func (le *LeaderElector) Run(stopc <-chan struct{}) {
+	if !le.acquire(stopc) {
+		return
+	}
+
+	leading := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		le.config.OnStartedLeading(leading)
+		close(done)
+	}()
+
+	stopped := le.renew(stopc)
+	le.lock.Lock()
+	le.leader = false
+	le.lock.Unlock()
+	close(leading)
+	<-done
+	if stopped {
+		le.release()
+	}
+	if le.config.OnStoppedLeading != nil {
+		le.config.OnStoppedLeading()
+	}
+}
+
+// IsLeader returns true if the last observed leader was this candidate
+func (le *LeaderElector) IsLeader() bool {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	return le.leader
+}
+
+// Healthy returns true if the lock could be read within the last LeaseDuration so that a
+// standby is able to take over should the leader go away
+func (le *LeaderElector) Healthy() bool {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	return !le.lastContact.IsZero() && time.Since(le.lastContact) < le.config.LeaseDuration
+}
+
+// acquire loops until the lease is acquired, returning false if stopc is closed first
+func (le *LeaderElector) acquire(stopc <-chan struct{}) bool {
+	le.config.Logger.Log("msg", "attempting to acquire leader lease", "lock", le.lockName())
+	for {
+		if le.tryAcquireOrRenew() {
+			le.config.Logger.Log("msg", "acquired leader lease", "lock", le.lockName(), "identity", le.config.Identity)
+			return true
+		}
+		select {
+		case <-stopc:
+			return false
+		case <-time.After(jitter(le.config.RetryPeriod)):
+		}
+	}
+}
+
+// renew keeps renewing the lease until it can not be renewed within RenewDeadline,
+// returning true if it stopped because stopc was closed
+func (le *LeaderElector) renew(stopc <-chan struct{}) bool {
+	for {
+		deadline := time.Now().Add(le.config.RenewDeadline)
+		renewed := false
+		for !renewed && time.Now().Before(deadline) {
+			if renewed = le.tryAcquireOrRenew(); !renewed {
+				select {
+				case <-stopc:
+					return true
+				case <-time.After(le.config.RetryPeriod):
+				}
+			}
+		}
+		if !renewed {
+			le.config.Logger.Log("msg", "failed to renew leader lease", "lock", le.lockName(), "identity", le.config.Identity)
+			return false
+		}
+		select {
+		case <-stopc:
+			return true
+		case <-time.After(le.config.RetryPeriod):
+		}
+	}
+}
+
+// tryAcquireOrRenew creates or updates the lock if it is not held by another
+// candidate whose lease is still valid, returning true if this candidate holds the lease
+func (le *LeaderElector) tryAcquireOrRenew() bool {
+	now := unversioned.Now()
+	record := LeaderElectionRecord{
+		HolderIdentity:       le.config.Identity,
+		LeaseDurationSeconds: int(le.config.LeaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	configMaps := le.config.Client.Core().ConfigMaps(le.config.Namespace)
+	cm, err := configMaps.Get(le.config.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		le.config.Logger.Log("msg", "failed to get leader lock", "lock", le.lockName(), "err", err)
+		return false
+	}
+	le.contacted()
+	if err != nil {
+		cm = &v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      le.config.Name,
+				Namespace: le.config.Namespace,
+			},
+		}
+		if err := setRecord(cm, &record); err != nil {
+			le.config.Logger.Log("msg", "failed to encode leader record", "err", err)
+			return false
+		}
+		if _, err := configMaps.Create(cm); err != nil {
+			le.config.Logger.Log("msg", "failed to create leader lock", "lock", le.lockName(), "err", err)
+			return false
+		}
+		le.observe(record, cm.Annotations[LeaderAnnotation])
+		return true
+	}
+
+	raw := cm.Annotations[LeaderAnnotation]
+	var old LeaderElectionRecord
+	if len(raw) > 0 {
+		if err := json.Unmarshal([]byte(raw), &old); err != nil {
+			le.config.Logger.Log("msg", "failed to parse leader record", "lock", le.lockName(), "err", err)
+			return false
+		}
+	}
+	if raw != le.observedRaw {
+		le.observe(old, raw)
+	}
+	held := len(old.HolderIdentity) > 0 && old.HolderIdentity != le.config.Identity
+	if held && le.observedTime.Add(le.config.LeaseDuration).After(time.Now()) {
+		return false
+	}
+
+	if old.HolderIdentity == le.config.Identity {
+		record.AcquireTime = old.AcquireTime
+		record.LeaderTransitions = old.LeaderTransitions
+	} else {
+		record.LeaderTransitions = old.LeaderTransitions + 1
+	}
+
+	// the ResourceVersion of the ConfigMap we read makes the update fail if another candidate got there first
+	updated := *cm
+	if err := setRecord(&updated, &record); err != nil {
+		le.config.Logger.Log("msg", "failed to encode leader record", "err", err)
+		return false
+	}
+	if _, err := configMaps.Update(&updated); err != nil {
+		le.config.Logger.Log("msg", "failed to update leader lock", "lock", le.lockName(), "err", err)
+		return false
+	}
+	le.observe(record, updated.Annotations[LeaderAnnotation])
+	return true
+}
+
+// release gives up the lease so that a standby can take over straight away
+func (le *LeaderElector) release() {
+	configMaps := le.config.Client.Core().ConfigMaps(le.config.Namespace)
+	cm, err := configMaps.Get(le.config.Name)
+	if err != nil {
+		le.config.Logger.Log("msg", "failed to get leader lock", "lock", le.lockName(), "err", err)
+		return
+	}
+	var old LeaderElectionRecord
+	if err := json.Unmarshal([]byte(cm.Annotations[LeaderAnnotation]), &old); err != nil || old.HolderIdentity != le.config.Identity {
+		return
+	}
+	now := unversioned.Now()
+	record := LeaderElectionRecord{
+		LeaseDurationSeconds: 1,
+		AcquireTime:          now,
+		RenewTime:            now,
+		LeaderTransitions:    old.LeaderTransitions,
+	}
+	updated := *cm
+	if err := setRecord(&updated, &record); err != nil {
+		le.config.Logger.Log("msg", "failed to encode leader record", "err", err)
+		return
+	}
+	if _, err := configMaps.Update(&updated); err != nil {
+		le.config.Logger.Log("msg", "failed to release leader lock", "lock", le.lockName(), "err", err)
+		return
+	}
+	le.config.Logger.Log("msg", "released leader lease", "lock", le.lockName())
+}
+
+func (le *LeaderElector) observe(record LeaderElectionRecord, raw string) {
+	le.observedRecord = record
+	le.observedRaw = raw
+	le.observedTime = time.Now()
+
+	le.lock.Lock()
+	le.leader = record.HolderIdentity == le.config.Identity
+	le.lock.Unlock()
+}
+
+func (le *LeaderElector) contacted() {
+	le.lock.Lock()
+	le.lastContact = time.Now()
+	le.lock.Unlock()
+}
+
+func (le *LeaderElector) lockName() string {
+	return le.config.Namespace + "/" + le.config.Name
+}
+
+// setRecord stores the record on a copy of the annotations of the ConfigMap
+func setRecord(cm *v1.ConfigMap, record *LeaderElectionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{}
+	for k, v := range cm.Annotations {
+		annotations[k] = v
+	}
+	annotations[LeaderAnnotation] = string(data)
+	cm.Annotations = annotations
+	return nil
+}
+
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Float64()*(jitterFactor-1)*float64(d))
+}
@@ -0,0 +1,126 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package leaderelection
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/1.5/kubernetes"
+	"k8s.io/client-go/1.5/kubernetes/fake"
+)
+
+func newTestElector(t *testing.T, client kubernetes.Interface, identity string) *LeaderElector {
+	le, err := New(Config{
+		Client:           client,
+		Namespace:        "default",
+		Name:             "funktion-operator",
+		Identity:         identity,
+		LeaseDuration:    DefaultLeaseDuration,
+		RenewDeadline:    DefaultRenewDeadline,
+		RetryPeriod:      DefaultRetryPeriod,
+		OnStartedLeading: func(stop <-chan struct{}) {},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create leader elector: %v", err)
+	}
+	return le
+}
+
+func TestOnlyOneLeader(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	a := newTestElector(t, client, "a")
+	b := newTestElector(t, client, "b")
+
+	if !a.tryAcquireOrRenew() {
+		t.Fatalf("Expected a to acquire the lease")
+	}
+	if b.tryAcquireOrRenew() {
+		t.Errorf("Expected b to not acquire a lease held by a")
+	}
+	if b.IsLeader() {
+		t.Errorf("Expected b to observe a as the leader")
+	}
+	if !a.tryAcquireOrRenew() {
+		t.Errorf("Expected a to renew its lease")
+	}
+
+	// once the lease has not been renewed for the lease duration the standby takes over
+	b.observedTime = time.Now().Add(-DefaultLeaseDuration)
+	if !b.tryAcquireOrRenew() {
+		t.Fatalf("Expected b to take over the expired lease")
+	}
+	if b.observedRecord.LeaderTransitions != 1 {
+		t.Errorf("Expected 1 leader transition but got %d", b.observedRecord.LeaderTransitions)
+	}
+	if a.tryAcquireOrRenew() {
+		t.Errorf("Expected a to have lost the lease to b")
+	}
+}
+
+func TestReleaseLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	a := newTestElector(t, client, "a")
+	b := newTestElector(t, client, "b")
+
+	if !a.tryAcquireOrRenew() {
+		t.Fatalf("Expected a to acquire the lease")
+	}
+	a.release()
+	if !b.tryAcquireOrRenew() {
+		t.Errorf("Expected b to acquire a released lease straight away")
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := New(Config{
+		Name:             "funktion-operator",
+		Identity:         "a",
+		LeaseDuration:    time.Second,
+		RenewDeadline:    2 * time.Second,
+		RetryPeriod:      time.Second,
+		OnStartedLeading: func(stop <-chan struct{}) {},
+	})
+	if err == nil {
+		t.Errorf("Expected an error for a lease duration shorter than the renew deadline")
+	}
+}
+
+func TestHealthyStandby(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	a := newTestElector(t, client, "a")
+	b := newTestElector(t, client, "b")
+
+	if b.Healthy() {
+		t.Errorf("Expected b to not be healthy before it has read the lock")
+	}
+	if !a.tryAcquireOrRenew() {
+		t.Fatalf("Expected a to acquire the lease")
+	}
+	if b.tryAcquireOrRenew() {
+		t.Fatalf("Expected b to not acquire a lease held by a")
+	}
+	if !a.IsLeader() || !a.Healthy() {
+		t.Errorf("Expected a to be a healthy leader")
+	}
+	if b.IsLeader() || !b.Healthy() {
+		t.Errorf("Expected b to be a healthy standby")
+	}
+
+	b.lastContact = time.Now().Add(-DefaultLeaseDuration)
+	if b.Healthy() {
+		t.Errorf("Expected b to not be healthy once it has not read the lock for the lease duration")
+	}
+}
@@ -0,0 +1,291 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package metrics exposes counters, gauges and histograms in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4"
+
+// DefaultBuckets are the default histogram buckets in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry holds the metrics to expose and serves them over HTTP
+type Registry struct {
+	lock       sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// NewCounterVec registers a counter partitioned by the given label
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		label:  label,
+		values: map[string]float64{},
+	}
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec registers a histogram partitioned by the given label
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{
+		name:       name,
+		help:       help,
+		label:      label,
+		buckets:    buckets,
+		histograms: map[string]*histogram{},
+	}
+	r.register(h)
+	return h
+}
+
+// NewGaugeFunc registers a gauge whose value is returned by fn when the metrics are scraped
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeFunc{
+		name: name,
+		help: help,
+		fn: func() map[string]float64 {
+			return map[string]float64{"": fn()}
+		},
+	})
+}
+
+// NewGaugeVecFunc registers a gauge partitioned by the given label whose values,
+// keyed by label value, are returned by fn when the metrics are scraped
+func (r *Registry) NewGaugeVecFunc(name, help, label string, fn func() map[string]float64) {
+	r.register(&gaugeFunc{
+		name:  name,
+		help:  help,
+		label: label,
+		fn:    fn,
+	})
+}
+
+// Write writes all the metrics in the Prometheus text format
+func (r *Registry) Write(w io.Writer) {
+	r.lock.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.lock.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	r.Write(&buf)
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+// CounterVec is a set of counters partitioned by a label
+type CounterVec struct {
+	name  string
+	help  string
+	label string
+
+	lock   sync.Mutex
+	values map[string]float64
+}
+
+// Inc increments the counter for the given label value
+func (c *CounterVec) Inc(labelValue string) {
+	c.Add(labelValue, 1)
+}
+
+// Add adds the given value to the counter for the given label value
+func (c *CounterVec) Add(labelValue string, v float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.values[labelValue] += v
+}
+
+// Value returns the current value of the counter for the given label value
+func (c *CounterVec) Value(labelValue string) float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.values[labelValue]
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.lock.Lock()
+	values := copyValues(c.values)
+	c.lock.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labels(c.label, k), formatFloat(values[k]))
+	}
+}
+
+// HistogramVec is a set of histograms partitioned by a label
+type HistogramVec struct {
+	name    string
+	help    string
+	label   string
+	buckets []float64
+
+	lock       sync.Mutex
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records a value in the histogram for the given label value
+func (h *HistogramVec) Observe(labelValue string, v float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	hist := h.histograms[labelValue]
+	if hist == nil {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.histograms[labelValue] = hist
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	keys := []string{}
+	for k := range h.histograms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		hist := h.histograms[k]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels(h.label, k, "le", formatFloat(upper)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labels(h.label, k, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels(h.label, k), formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels(h.label, k), hist.count)
+	}
+}
+
+type gaugeFunc struct {
+	name  string
+	help  string
+	label string
+	fn    func() map[string]float64
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	values := g.fn()
+	writeHeader(w, g.name, g.help, "gauge")
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, labels(g.label, k), formatFloat(values[k]))
+	}
+}
+
+func writeHeader(w io.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.Replace(strings.Replace(help, `\`, `\\`, -1), "\n", `\n`, -1))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// labels formats the given label name and value pairs skipping any without a name
+func labels(nameValues ...string) string {
+	pairs := []string{}
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		if len(nameValues[i]) == 0 {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", nameValues[i], escapeLabelValue(nameValues[i+1])))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabelValue(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `"`, `\"`, -1)
+	return strings.Replace(v, "\n", `\n`, -1)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func copyValues(m map[string]float64) map[string]float64 {
+	answer := map[string]float64{}
+	for k, v := range m {
+		answer[k] = v
+	}
+	return answer
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounterVec("test_total", "Total tests", "kind")
+	counter.Inc("Function")
+	counter.Add("Flow", 2)
+	histogram := r.NewHistogramVec("test_duration_seconds", "Test durations", "kind", []float64{0.1, 1})
+	histogram.Observe("Function", 0.5)
+	r.NewGaugeFunc("test_depth", "Test depth", func() float64 { return 3 })
+	r.NewGaugeVecFunc("test_cached", "Cached \"tests\"", "kind", func() map[string]float64 {
+		return map[string]float64{"Runtime": 4}
+	})
+
+	var buf bytes.Buffer
+	r.Write(&buf)
+	expected := `# HELP test_total Total tests
+# TYPE test_total counter
+test_total{kind="Flow"} 2
+test_total{kind="Function"} 1
+# HELP test_duration_seconds Test durations
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{kind="Function",le="0.1"} 0
+test_duration_seconds_bucket{kind="Function",le="1"} 1
+test_duration_seconds_bucket{kind="Function",le="+Inf"} 1
+test_duration_seconds_sum{kind="Function"} 0.5
+test_duration_seconds_count{kind="Function"} 1
+# HELP test_depth Test depth
+# TYPE test_depth gauge
+test_depth 3
+# HELP test_cached Cached "tests"
+# TYPE test_cached gauge
+test_cached{kind="Runtime"} 4
+`
+	if actual := buf.String(); actual != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if actual := labels("name", "a\"b\\c\nd"); actual != `{name="a\"b\\c\nd"}` {
+		t.Errorf("Unexpected escaped labels %s", actual)
+	}
+}
@@ -0,0 +1,180 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package queue
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter decides how long an item has to wait before it is re-added to a queue.
+type RateLimiter interface {
+	// When returns how long to wait before the item is processed again.
+	When(item interface{}) time.Duration
+	// Forget indicates that an item is finished being retried. It stops
+	// tracking the failures of the item.
+	Forget(item interface{})
+	// NumRequeues returns how many times the item has been re-queued.
+	NumRequeues(item interface{}) int
+}
+
+// DefaultRateLimiter returns a limiter which backs off failing items
+// exponentially from 1 second up to 5 minutes while limiting the overall
+// retry rate to 10 qps with bursts of 100.
+func DefaultRateLimiter() RateLimiter {
+	return NewMaxOfRateLimiter(
+		NewItemExponentialFailureRateLimiter(time.Second, 5*time.Minute),
+		NewBucketRateLimiter(10, 100),
+	)
+}
+
+// ItemExponentialFailureRateLimiter doubles the delay of an item each time it fails.
+type ItemExponentialFailureRateLimiter struct {
+	lock     sync.Mutex
+	failures map[interface{}]int
+
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// NewItemExponentialFailureRateLimiter creates a limiter whose delay is
+// baseDelay*2^<num-failures> capped at maxDelay.
+func NewItemExponentialFailureRateLimiter(baseDelay time.Duration, maxDelay time.Duration) *ItemExponentialFailureRateLimiter {
+	return &ItemExponentialFailureRateLimiter{
+		failures:  map[interface{}]int{},
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+	}
+}
+
+// When returns the backoff delay for the item and records another failure.
+func (r *ItemExponentialFailureRateLimiter) When(item interface{}) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	exp := r.failures[item]
+	r.failures[item] = exp + 1
+
+	backoff := float64(r.baseDelay.Nanoseconds()) * math.Pow(2, float64(exp))
+	if backoff > math.MaxInt64 || time.Duration(backoff) > r.maxDelay {
+		return r.maxDelay
+	}
+	return time.Duration(backoff)
+}
+
+// NumRequeues returns the number of failures recorded for the item.
+func (r *ItemExponentialFailureRateLimiter) NumRequeues(item interface{}) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.failures[item]
+}
+
+// Forget clears the failures recorded for the item.
+func (r *ItemExponentialFailureRateLimiter) Forget(item interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.failures, item)
+}
+
+// BucketRateLimiter is a token bucket shared by all items which limits
+// the overall rate of retries.
+type BucketRateLimiter struct {
+	lock   sync.Mutex
+	qps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucketRateLimiter creates a full token bucket which refills at qps
+// tokens per second and holds at most burst tokens.
+func NewBucketRateLimiter(qps float64, burst int) *BucketRateLimiter {
+	return &BucketRateLimiter{
+		qps:    qps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// When takes a token from the bucket and returns how long to wait until
+// that token would have been available.
+func (r *BucketRateLimiter) When(item interface{}) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	r.tokens = math.Min(r.burst, r.tokens+now.Sub(r.last).Seconds()*r.qps)
+	r.last = now
+
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.qps * float64(time.Second))
+}
+
+// NumRequeues is not tracked by the bucket.
+func (r *BucketRateLimiter) NumRequeues(item interface{}) int {
+	return 0
+}
+
+// Forget is a no-op as the bucket does not track items.
+func (r *BucketRateLimiter) Forget(item interface{}) {
+}
+
+// MaxOfRateLimiter returns the longest delay of a list of limiters.
+type MaxOfRateLimiter struct {
+	limiters []RateLimiter
+}
+
+// NewMaxOfRateLimiter combines the given limiters.
+func NewMaxOfRateLimiter(limiters ...RateLimiter) *MaxOfRateLimiter {
+	return &MaxOfRateLimiter{limiters: limiters}
+}
+
+// When returns the longest delay of all the limiters.
+func (r *MaxOfRateLimiter) When(item interface{}) time.Duration {
+	ret := time.Duration(0)
+	for _, limiter := range r.limiters {
+		curr := limiter.When(item)
+		if curr > ret {
+			ret = curr
+		}
+	}
+	return ret
+}
+
+// NumRequeues returns the highest number of requeues of all the limiters.
+func (r *MaxOfRateLimiter) NumRequeues(item interface{}) int {
+	ret := 0
+	for _, limiter := range r.limiters {
+		curr := limiter.NumRequeues(item)
+		if curr > ret {
+			ret = curr
+		}
+	}
+	return ret
+}
+
+// Forget forgets the item in all the limiters.
+func (r *MaxOfRateLimiter) Forget(item interface{}) {
+	for _, limiter := range r.limiters {
+		limiter.Forget(item)
+	}
+}
@@ -0,0 +1,73 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package queue
+
+import "time"
+
+// NewRateLimiting constructs a new workqueue which re-adds failed items after
+// the delay chosen by the limiter. Items are dropped once they have been
+// re-added maxRetries times; a maxRetries of 0 retries forever.
+func NewRateLimiting(limiter RateLimiter, maxRetries int) *RateLimitingQueue {
+	return &RateLimitingQueue{
+		Queue:      New(),
+		limiter:    limiter,
+		maxRetries: maxRetries,
+	}
+}
+
+// RateLimitingQueue is a work queue which rate limits re-adding failed items.
+type RateLimitingQueue struct {
+	*Queue
+
+	limiter    RateLimiter
+	maxRetries int
+}
+
+// AddAfter adds the item to the queue once the given duration has passed.
+func (q *RateLimitingQueue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() {
+		q.Add(item)
+	})
+}
+
+// AddRateLimited adds the item to the queue once the rate limiter says it is ok.
+// It returns false if the item has already been retried maxRetries times, in
+// which case it is forgotten rather than added.
+func (q *RateLimitingQueue) AddRateLimited(item interface{}) bool {
+	if q.maxRetries > 0 && q.limiter.NumRequeues(item) >= q.maxRetries {
+		q.limiter.Forget(item)
+		return false
+	}
+	q.AddAfter(item, q.limiter.When(item))
+	return true
+}
+
+// Forget indicates that the item was processed successfully so that its
+// backoff is reset. You must still call Done with the item.
+func (q *RateLimitingQueue) Forget(item interface{}) {
+	q.limiter.Forget(item)
+}
+
+// NumRequeues returns how many times the item has been re-added since it was last forgotten.
+func (q *RateLimitingQueue) NumRequeues(item interface{}) int {
+	return q.limiter.NumRequeues(item)
+}
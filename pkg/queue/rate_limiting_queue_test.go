@@ -0,0 +1,105 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestItemExponentialFailureRateLimiter(t *testing.T) {
+	limiter := NewItemExponentialFailureRateLimiter(time.Millisecond, time.Second)
+
+	expected := []time.Duration{1, 2, 4, 8, 16}
+	for _, e := range expected {
+		if d := limiter.When("one"); d != e*time.Millisecond {
+			t.Errorf("Expected %v but got %v", e*time.Millisecond, d)
+		}
+	}
+	if n := limiter.NumRequeues("one"); n != 5 {
+		t.Errorf("Expected 5 requeues but got %d", n)
+	}
+	if d := limiter.When("two"); d != time.Millisecond {
+		t.Errorf("Expected a separate backoff per item but got %v", d)
+	}
+
+	for i := 0; i < 20; i++ {
+		limiter.When("one")
+	}
+	if d := limiter.When("one"); d != time.Second {
+		t.Errorf("Expected the backoff to be capped at %v but got %v", time.Second, d)
+	}
+
+	limiter.Forget("one")
+	if n := limiter.NumRequeues("one"); n != 0 {
+		t.Errorf("Expected no requeues after Forget but got %d", n)
+	}
+	if d := limiter.When("one"); d != time.Millisecond {
+		t.Errorf("Expected the backoff to be reset after Forget but got %v", d)
+	}
+}
+
+func TestBucketRateLimiter(t *testing.T) {
+	limiter := NewBucketRateLimiter(1, 2)
+
+	if d := limiter.When("one"); d != 0 {
+		t.Errorf("Expected no delay within the burst but got %v", d)
+	}
+	if d := limiter.When("two"); d != 0 {
+		t.Errorf("Expected no delay within the burst but got %v", d)
+	}
+	if d := limiter.When("three"); d <= 0 || d > time.Second {
+		t.Errorf("Expected a delay of up to a second once the burst is used up but got %v", d)
+	}
+}
+
+func TestRateLimitingQueueMaxRetries(t *testing.T) {
+	q := NewRateLimiting(NewItemExponentialFailureRateLimiter(0, 0), 2)
+	defer q.ShutDown()
+
+	q.Add("one")
+	for i := 0; i < 2; i++ {
+		item, _ := q.Get()
+		if !q.AddRateLimited(item) {
+			t.Fatalf("Expected retry %d to be allowed", i+1)
+		}
+		q.Done(item)
+	}
+	item, _ := q.Get()
+	if q.AddRateLimited(item) {
+		t.Errorf("Expected the item to be dropped after 2 retries")
+	}
+	q.Done(item)
+	if q.Len() != 0 {
+		t.Errorf("Expected an empty queue but got %d items", q.Len())
+	}
+	if n := q.NumRequeues("one"); n != 0 {
+		t.Errorf("Expected a dropped item to be forgotten but got %d requeues", n)
+	}
+}
+
+func TestRateLimitingQueueAddAfter(t *testing.T) {
+	q := NewRateLimiting(DefaultRateLimiter(), 0)
+	defer q.ShutDown()
+
+	q.AddAfter("one", 10*time.Millisecond)
+	if q.Len() != 0 {
+		t.Errorf("Expected the item to be delayed")
+	}
+	item, _ := q.Get()
+	if item != "one" {
+		t.Errorf("Expected item one but got %v", item)
+	}
+}
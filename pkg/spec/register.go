@@ -0,0 +1,60 @@
+//  Copyright 2016 Red Hat, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package spec
+
+import (
+	"strings"
+
+	"k8s.io/client-go/1.5/pkg/api/unversioned"
+)
+
+const (
+	// Group is the API group of the funktion ThirdPartyResources
+	Group = "funktion.fabric8.io"
+	// Version is the API version of the funktion ThirdPartyResources
+	Version = "v1"
+)
+
+// Resource describes one of the funktion ThirdPartyResources
+type Resource struct {
+	// Kind of the resource such as Function
+	Kind string
+	// Plural is the name of the resource in REST paths such as functions
+	Plural string
+	// Description of the ThirdPartyResource
+	Description string
+}
+
+// TPRName returns the name of the ThirdPartyResource which registers the resource
+func (r Resource) TPRName() string {
+	return strings.ToLower(r.Kind) + "." + Group
+}
+
+var (
+	// FunctionResource is the resource of Functions
+	FunctionResource = Resource{Kind: "Function", Plural: "functions", Description: "Source code run by a funktion Runtime"}
+	// FlowResource is the resource of Flows
+	FlowResource = Resource{Kind: "Flow", Plural: "flows", Description: "A sequence of steps run by a funktion Connector"}
+	// RuntimeResource is the resource of Runtimes
+	RuntimeResource = Resource{Kind: "Runtime", Plural: "runtimes", Description: "A language runtime for funktion Functions"}
+	// ConnectorResource is the resource of Connectors
+	ConnectorResource = Resource{Kind: "Connector", Plural: "connectors", Description: "A connector for funktion Flows"}
+
+	// Resources are all the funktion ThirdPartyResources
+	Resources = []Resource{FunctionResource, FlowResource, RuntimeResource, ConnectorResource}
+
+	// GroupVersion is the group version of the funktion ThirdPartyResources
+	GroupVersion = unversioned.GroupVersion{Group: Group, Version: Version}
+)
@@ -34,7 +34,7 @@ type Connector struct {
 	Spec                 ConnectorSpec `json:"spec"`
 }
 
-// ConnectorList is a list of Funktion.
+// ConnectorList is a list of Connectors.
 type ConnectorList struct {
 	unversioned.TypeMeta `json:",inline"`
 	unversioned.ListMeta `json:"metadata,omitempty"`
@@ -44,9 +44,183 @@ type ConnectorList struct {
 
 // ConnectorSpec holds specification parameters of a Flow deployment along with configuration metadata.
 type ConnectorSpec struct {
+	// DeploymentSpec is the template of the Deployment created for each Flow using the Connector
 	DeploymentSpec *v1beta1.DeploymentSpec `json:"deploymentSpec"`
+	// DeploymentLabels and DeploymentAnnotations are the metadata of the Deployment created for each Flow
+	DeploymentLabels      map[string]string `json:"deploymentLabels,omitempty"`
+	DeploymentAnnotations map[string]string `json:"deploymentAnnotations,omitempty"`
+	// Schema describes how to configure the endpoints of the Connector
+	Schema *ConnectorSchema `json:"schema,omitempty"`
+	// ApplicationProperties are the default spring boot properties of Flows using the Connector
+	ApplicationProperties string `json:"applicationProperties,omitempty"`
+	// DebugPort is the port to connect a debugger to
+	DebugPort int `json:"debugPort,omitempty"`
+}
+
+// Flow is a sequence of steps run by a Connector
+type Flow struct {
+	unversioned.TypeMeta `json:",inline"`
+	v1.ObjectMeta        `json:"metadata,omitempty"`
+	Spec                 FlowSpec `json:"spec"`
+}
+
+// FlowList is a list of Flows.
+type FlowList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []*Flow `json:"items"`
+}
+
+// FlowSpec holds the steps of a Flow and the Connector which runs them
+type FlowSpec struct {
+	// Connector is the name of the Connector used to run the Flow
+	Connector string `json:"connector"`
+	// Funktion holds the steps of the Flow
+	Funktion *FunkionConfig `json:"funktion,omitempty"`
+	// ApplicationProperties are the spring boot properties of the Flow
+	ApplicationProperties string `json:"applicationProperties,omitempty"`
+	// ApplicationYml is the spring boot YAML configuration of the Flow
+	ApplicationYml string `json:"applicationYml,omitempty"`
+	// Pod overrides the resources and scheduling of the pods of the Flow
+	Pod *PodSettings `json:"pod,omitempty"`
+}
+
+// PodSettings override the resources and scheduling of the pods generated for a Function or Flow
+type PodSettings struct {
+	// Resources are the compute resources of the container running the Function or Flow
+	Resources      v1.ResourceRequirements `json:"resources,omitempty"`
+	NodeSelector   map[string]string       `json:"nodeSelector,omitempty"`
+	Tolerations    []v1.Toleration         `json:"tolerations,omitempty"`
+	Affinity       *v1.Affinity            `json:"affinity,omitempty"`
+	ServiceAccount string                  `json:"serviceAccount,omitempty"`
+}
+
+// Runtime defines how to create a Deployment and Service for a Function
+type Runtime struct {
+	unversioned.TypeMeta `json:",inline"`
+	v1.ObjectMeta        `json:"metadata,omitempty"`
+	Spec                 RuntimeSpec `json:"spec"`
+}
+
+// RuntimeList is a list of Runtimes.
+type RuntimeList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []*Runtime `json:"items"`
+}
+
+// RuntimeSpec holds the templates of the resources created for each Function using the Runtime
+type RuntimeSpec struct {
+	Deployment      *v1beta1.Deployment `json:"deployment,omitempty"`
+	DeploymentDebug *v1beta1.Deployment `json:"deploymentDebug,omitempty"`
+	Service         *v1.Service         `json:"service,omitempty"`
+	// DebugPort is the port to connect a debugger to
+	DebugPort int `json:"debugPort,omitempty"`
+	// FileExtensions are the extensions (without the dot) of the source files handled by the Runtime
+	FileExtensions []string `json:"fileExtensions,omitempty"`
+	// SourceMountPath is the path in the container where the source of a Function is mounted
+	SourceMountPath string `json:"sourceMountPath,omitempty"`
+}
+
+// Function is some source code run by a Runtime
+type Function struct {
+	unversioned.TypeMeta `json:",inline"`
+	v1.ObjectMeta        `json:"metadata,omitempty"`
+	Spec                 FunctionSpec `json:"spec"`
+}
+
+// FunctionList is a list of Functions.
+type FunctionList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []*Function `json:"items"`
+}
+
+// FunctionSpec holds the source of a Function and the Runtime which runs it
+type FunctionSpec struct {
+	// Runtime is the name of the Runtime used to run the Function
+	Runtime string `json:"runtime"`
+	Source  string `json:"source"`
+	// Debug enables debugging of the Function
+	Debug bool        `json:"debug,omitempty"`
+	Env   []v1.EnvVar `json:"env,omitempty"`
+	// SecretEnv are the environment variables whose values are read from Secrets
+	SecretEnv []SecretEnvVar `json:"secretEnv,omitempty"`
+	// SecretMounts are the Secrets mounted as files into the pods of the Function
+	SecretMounts []SecretMount `json:"secretMounts,omitempty"`
+	// Autoscaling scales the pods of the Function with a HorizontalPodAutoscaler
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// IdleTimeout is how long the Function may receive no requests before it is scaled to
+	// zero pods, such as 15m. The Function is scaled up again on its next request.
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// Schedule invokes the Function periodically
+	Schedule *ScheduleSpec `json:"schedule,omitempty"`
+	// Pod overrides the resources and scheduling of the pods of the Function
+	Pod *PodSettings `json:"pod,omitempty"`
+	// Traffic splits the requests between revisions of the Function such as for a canary release
+	Traffic []TrafficTarget `json:"traffic,omitempty"`
+	// Gateway configures how the gateway serves the Function
+	Gateway *GatewaySpec `json:"gateway,omitempty"`
+}
+
+// GatewaySpec configures how the gateway serves a Function
+type GatewaySpec struct {
+	// Auth is how requests are authenticated: apiKey or jwt. Requests are not authenticated if it is empty.
+	Auth string `json:"auth,omitempty"`
+	// AuthSecret is the key of the Secret holding the API key or the HMAC key which signs the tokens
+	AuthSecret *SecretKey `json:"authSecret,omitempty"`
+	// CORSOrigins are the origins allowed to call the Function from a browser. `*` allows any origin.
+	CORSOrigins []string `json:"corsOrigins,omitempty"`
+	// MaxRequestSize is the largest request body forwarded to the Function such as 1Mi
+	MaxRequestSize string `json:"maxRequestSize,omitempty"`
+	// Timeout is how long to wait for the Function to respond such as 30s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// SecretKey refers to a key of a Secret
+type SecretKey struct {
+	Secret string `json:"secret"`
+	Key    string `json:"key"`
+}
+
+// TrafficTarget is the percentage of the requests of a Function which a revision receives
+type TrafficTarget struct {
+	Revision int `json:"revision"`
+	Percent  int `json:"percent"`
+}
+
+// SecretEnvVar is an environment variable whose value is read from a key of a Secret
+type SecretEnvVar struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+	Key    string `json:"key"`
+}
+
+// SecretMount mounts the keys of a Secret as files in a directory of the pods of a Function
+type SecretMount struct {
+	Secret string `json:"secret"`
+	Path   string `json:"path"`
+}
+
+// ScheduleSpec holds the schedule on which a Function is invoked
+type ScheduleSpec struct {
+	// Cron is the cron expression of the schedule such as */5 * * * *
+	Cron string `json:"cron"`
+	// Payload is the body POSTed to the Function. The Function is called with a GET if it is empty.
+	Payload string `json:"payload,omitempty"`
+}
 
-	// TODO lets add a JSON Schema for how to configure the endpoints?
+// AutoscalingSpec holds the autoscaling settings of a Function
+type AutoscalingSpec struct {
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the average CPU utilization of the pods to scale to
+	TargetCPUUtilizationPercentage int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetConcurrency is the average number of concurrent requests per pod to scale to
+	TargetConcurrency int32 `json:"targetConcurrency,omitempty"`
 }
 
 // ComponentSpec holds the component metadata in a ConnectorSchema